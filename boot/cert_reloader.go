@@ -0,0 +1,286 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-query"
+	"go.uber.org/zap"
+)
+
+const defaultCertReloadInterval = 10 * time.Second
+
+// BootConfigCertReload Boot config which is for certificate reloading of grpc entry.
+type BootConfigCertReload struct {
+	Enabled    bool  `yaml:"enabled" json:"enabled"`
+	IntervalMs int64 `yaml:"intervalMs" json:"intervalMs"`
+}
+
+// CertReloader watches key pair and CA bundle of CertEntry on disk, and reloads them once modified.
+//
+// Certificates are served with tls.Config.GetConfigForClient, so that new connections use reloaded
+// certificates while existing connections are kept. Old certificates are kept if new ones fail to parse.
+type CertReloader struct {
+	certPath   string
+	keyPath    string
+	caPath     string
+	interval   time.Duration
+	entryName  string
+	logger     *zap.Logger
+	eventEntry *rkentry.EventEntry
+	cert       *tls.Certificate
+	caPool     *x509.CertPool
+	modTimes   map[string]time.Time
+	lock       sync.RWMutex
+	quit       chan struct{}
+	wg         sync.WaitGroup
+	startOnce  sync.Once
+	stopOnce   sync.Once
+}
+
+// NewCertReloader Create CertReloader with paths of CertEntry, interval would be 10 seconds if not positive.
+//
+// Key pair is read from certEntry and CA bundle is read from caEntry, certEntry is used if caEntry is nil.
+func NewCertReloader(certEntry, caEntry *rkentry.CertEntry, interval time.Duration, logger *rkentry.LoggerEntry, event *rkentry.EventEntry) *CertReloader {
+	if interval <= 0 {
+		interval = defaultCertReloadInterval
+	}
+
+	if logger == nil {
+		logger = rkentry.GlobalAppCtx.GetLoggerEntryDefault()
+	}
+
+	if event == nil {
+		event = rkentry.GlobalAppCtx.GetEventEntryDefault()
+	}
+
+	reloader := &CertReloader{
+		interval:   interval,
+		logger:     logger.Logger,
+		eventEntry: event,
+		modTimes:   make(map[string]time.Time),
+		quit:       make(chan struct{}),
+	}
+
+	if caEntry == nil {
+		caEntry = certEntry
+	}
+
+	if certEntry != nil {
+		reloader.entryName = certEntry.GetName()
+		reloader.cert = certEntry.Certificate
+		reloader.certPath, reloader.keyPath, _ = certEntryPaths(certEntry)
+	}
+
+	if caEntry != nil {
+		_, _, reloader.caPath = certEntryPaths(caEntry)
+		if caEntry.RootCA != nil {
+			reloader.caPool = x509.NewCertPool()
+			reloader.caPool.AddCert(caEntry.RootCA)
+		}
+	}
+
+	// record current modification time, so that files won't be reloaded at the first check
+	for _, p := range []string{reloader.certPath, reloader.keyPath, reloader.caPath} {
+		if info, err := os.Stat(p); err == nil {
+			reloader.modTimes[p] = info.ModTime()
+		}
+	}
+
+	return reloader
+}
+
+// Start watching files in background.
+func (r *CertReloader) Start() {
+	r.startOnce.Do(func() {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+
+			ticker := time.NewTicker(r.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-r.quit:
+					return
+				case <-ticker.C:
+					if r.modified() {
+						r.Reload()
+					}
+				}
+			}
+		}()
+	})
+}
+
+// Stop watching files.
+func (r *CertReloader) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.quit)
+	})
+	r.wg.Wait()
+}
+
+// Reload key pair and CA bundle from disk, existing ones are kept if failed.
+func (r *CertReloader) Reload() error {
+	event := r.eventEntry.Start(
+		"CertReload",
+		rkquery.WithEntryName(r.entryName),
+		rkquery.WithEntryType(rkentry.CertEntryType))
+	defer r.eventEntry.Finish(event)
+
+	var cert *tls.Certificate
+	if len(r.certPath) > 0 && len(r.keyPath) > 0 {
+		pair, err := tls.LoadX509KeyPair(r.certPath, r.keyPath)
+		if err != nil {
+			event.AddErr(err)
+			r.logger.Error("Failed to reload certificate, keep the old one", zap.String("certPath", r.certPath), zap.Error(err))
+			return err
+		}
+		cert = &pair
+	}
+
+	var caPool *x509.CertPool
+	if len(r.caPath) > 0 {
+		bytes, err := os.ReadFile(r.caPath)
+		if err != nil {
+			event.AddErr(err)
+			r.logger.Error("Failed to reload CA bundle, keep the old one", zap.String("caPath", r.caPath), zap.Error(err))
+			return err
+		}
+
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(bytes) {
+			err := errors.New("no certificate found in CA bundle")
+			event.AddErr(err)
+			r.logger.Error("Failed to reload CA bundle, keep the old one", zap.String("caPath", r.caPath), zap.Error(err))
+			return err
+		}
+	}
+
+	r.lock.Lock()
+	if cert != nil {
+		r.cert = cert
+	}
+	if caPool != nil {
+		r.caPool = caPool
+	}
+	r.lock.Unlock()
+
+	event.AddPayloads(
+		zap.String("certPath", r.certPath),
+		zap.String("caPath", r.caPath))
+	r.logger.Info("Certificate reloaded", zap.String("certEntry", r.entryName))
+
+	return nil
+}
+
+// GetCertificate returns current certificate, used as tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.cert == nil {
+		return nil, errors.New("certificate is missing")
+	}
+
+	return r.cert, nil
+}
+
+// GetClientCertificate returns current certificate, used as tls.Config.GetClientCertificate.
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.cert == nil {
+		return &tls.Certificate{}, nil
+	}
+
+	return r.cert, nil
+}
+
+// GetCAPool returns current CA pool, nil if CA is missing.
+func (r *CertReloader) GetCAPool() *x509.CertPool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.caPool
+}
+
+// GetConfigForClient returns function used as tls.Config.GetConfigForClient.
+//
+// Config is cloned from base with current certificate, ClientCAs is replaced if base verifies client certificates.
+func (r *CertReloader) GetConfigForClient(base *tls.Config) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	base = base.Clone()
+	base.GetConfigForClient = nil
+
+	return func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		cert, err := r.GetCertificate(hello)
+		if err != nil {
+			return nil, err
+		}
+
+		config := base.Clone()
+		config.Certificates = []tls.Certificate{*cert}
+		if config.ClientCAs != nil {
+			if pool := r.GetCAPool(); pool != nil {
+				config.ClientCAs = pool
+			}
+		}
+
+		return config, nil
+	}
+}
+
+// Check whether any of files was modified.
+func (r *CertReloader) modified() bool {
+	res := false
+	for _, p := range []string{r.certPath, r.keyPath, r.caPath} {
+		if len(p) < 1 {
+			continue
+		}
+
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+
+		if !info.ModTime().Equal(r.modTimes[p]) {
+			r.modTimes[p] = info.ModTime()
+			res = true
+		}
+	}
+
+	return res
+}
+
+// Read paths of CertEntry, which are only exposed by MarshalJSON.
+func certEntryPaths(certEntry *rkentry.CertEntry) (certPath, keyPath, caPath string) {
+	bytes, err := certEntry.MarshalJSON()
+	if err != nil {
+		return "", "", ""
+	}
+
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(bytes, &m); err != nil {
+		return "", "", ""
+	}
+
+	certPath, _ = m["certPemPath"].(string)
+	keyPath, _ = m["keyPemPath"].(string)
+	caPath, _ = m["caPath"].(string)
+
+	return certPath, keyPath, caPath
+}
@@ -0,0 +1,233 @@
+//go:build !race
+// +build !race
+
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+package rkgrpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	testdata "github.com/rookie-ninja/rk-grpc/v2/example/middleware/proto/testdata"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestNewCertReloader(t *testing.T) {
+	// without cert entry
+	reloader := NewCertReloader(nil, nil, 0, nil, nil)
+	assert.Equal(t, defaultCertReloadInterval, reloader.interval)
+	_, err := reloader.GetCertificate(nil)
+	assert.NotNil(t, err)
+	cert, err := reloader.GetClientCertificate(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, cert.Certificate)
+	assert.Nil(t, reloader.GetCAPool())
+
+	// with cert entry
+	dir := t.TempDir()
+	ca, caKey := newTestCA()
+	writeTestCerts(t, dir, ca, newTestLeaf(ca, caKey, "ut-server", nil))
+
+	certEntry := registerTestCertEntry(t, "ut-reload-cert", dir)
+	reloader = NewCertReloader(certEntry, nil, time.Second, nil, nil)
+	assert.Equal(t, filepath.Join(dir, "server.pem"), reloader.certPath)
+	assert.Equal(t, filepath.Join(dir, "server-key.pem"), reloader.keyPath)
+	assert.Equal(t, filepath.Join(dir, "ca.pem"), reloader.caPath)
+	assert.NotNil(t, reloader.GetCAPool())
+	assert.False(t, reloader.modified())
+
+	cert, err = reloader.GetCertificate(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, certEntry.Certificate, cert)
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCA()
+	writeTestCerts(t, dir, ca, newTestLeaf(ca, caKey, "ut-server", nil))
+
+	certEntry := registerTestCertEntry(t, "ut-reload-cert", dir)
+	reloader := NewCertReloader(certEntry, nil, time.Second, nil, nil)
+
+	// happy case
+	writeTestCerts(t, dir, ca, newTestLeaf(ca, caKey, "ut-server-new", nil))
+	assert.Nil(t, reloader.Reload())
+	assert.Equal(t, "ut-server-new", leafCommonName(t, reloader))
+
+	// invalid certificate, keep the old one
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "server.pem"), []byte("invalid"), 0600))
+	assert.NotNil(t, reloader.Reload())
+	assert.Equal(t, "ut-server-new", leafCommonName(t, reloader))
+
+	// invalid CA bundle, keep the old one
+	writeTestCerts(t, dir, ca, newTestLeaf(ca, caKey, "ut-server", nil))
+	pool := reloader.GetCAPool()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "ca.pem"), []byte("invalid"), 0600))
+	assert.NotNil(t, reloader.Reload())
+	assert.Equal(t, pool, reloader.GetCAPool())
+	assert.Equal(t, "ut-server-new", leafCommonName(t, reloader))
+}
+
+func TestCertReloader_GetConfigForClient(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCA()
+	writeTestCerts(t, dir, ca, newTestLeaf(ca, caKey, "ut-server", nil))
+
+	certEntry := registerTestCertEntry(t, "ut-reload-cert", dir)
+	reloader := NewCertReloader(certEntry, nil, time.Second, nil, nil)
+
+	base := &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  x509.NewCertPool(),
+	}
+	base.GetConfigForClient = reloader.GetConfigForClient(base)
+
+	config, err := base.GetConfigForClient(&tls.ClientHelloInfo{})
+	assert.Nil(t, err)
+	assert.Nil(t, config.GetConfigForClient)
+	assert.Len(t, config.Certificates, 1)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+	assert.Equal(t, reloader.GetCAPool(), config.ClientCAs)
+
+	// without client CAs in base config
+	config, err = reloader.GetConfigForClient(&tls.Config{})(&tls.ClientHelloInfo{})
+	assert.Nil(t, err)
+	assert.Nil(t, config.ClientCAs)
+}
+
+func TestGrpcEntry_CertReload(t *testing.T) {
+	defer assertNotPanic(t)
+
+	dir := t.TempDir()
+	ca, caKey := newTestCA()
+	writeTestCerts(t, dir, ca, newTestLeaf(ca, caKey, "ut-server", nil))
+
+	certEntry := registerTestCertEntry(t, "ut-reload-cert", dir)
+
+	entry := RegisterGrpcEntry(
+		WithName("ut-cert-reload"),
+		WithPort(1963),
+		WithCertEntry(certEntry),
+		WithCertReload(true, 100*time.Millisecond, nil))
+	entry.AddRegFuncGrpc(func(server *grpc.Server) {
+		testdata.RegisterGreeterServer(server, &GreeterServer{})
+	})
+	assert.NotNil(t, entry.CertReloader)
+
+	entry.Bootstrap(context.TODO())
+	defer entry.Interrupt(context.TODO())
+	time.Sleep(time.Second)
+
+	assert.Equal(t, "ut-server", servedCommonName(t, "localhost:1963"))
+
+	// rotate certificate, make sure modification time changed
+	time.Sleep(10 * time.Millisecond)
+	writeTestCerts(t, dir, ca, newTestLeaf(ca, caKey, "ut-server-rotated", nil))
+	time.Sleep(500 * time.Millisecond)
+
+	assert.Equal(t, "ut-server-rotated", servedCommonName(t, "localhost:1963"))
+
+	// grpc call still works with rotated certificate
+	conn, err := grpc.Dial("localhost:1963", grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		InsecureSkipVerify: true,
+	})))
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	_, err = testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{})
+	assert.Nil(t, err)
+}
+
+func TestRegisterGrpcEntryYAML_WithCertReload(t *testing.T) {
+	defer assertNotPanic(t)
+
+	dir := t.TempDir()
+	ca, caKey := newTestCA()
+	writeTestCerts(t, dir, ca, newTestLeaf(ca, caKey, "ut-server", nil))
+	registerTestCertEntry(t, "ut-reload-cert", dir)
+
+	configFile := `
+---
+grpc:
+  - name: ut-grpc-reload
+    port: 1964
+    enabled: true
+    certEntry: ut-reload-cert
+    certReload:
+      enabled: true
+      intervalMs: 200
+`
+	entries := RegisterGrpcEntryYAML([]byte(configFile))
+	entry := entries["ut-grpc-reload"].(*GrpcEntry)
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	assert.NotNil(t, entry.CertReloader)
+	assert.Equal(t, 200*time.Millisecond, entry.CertReloader.interval)
+	assert.NotNil(t, entry.TlsConfig.GetConfigForClient)
+	assert.NotNil(t, entry.TlsConfigInsecure.GetClientCertificate)
+}
+
+// ************ Test utility ************
+
+func writeTestCerts(t *testing.T, dir string, ca *x509.Certificate, cert tls.Certificate) {
+	keyDer, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	assert.Nil(t, err)
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "ca.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "server.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "server-key.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
+
+func registerTestCertEntry(t *testing.T, name, dir string) *rkentry.CertEntry {
+	certEntry := rkentry.RegisterCertEntry(&rkentry.BootCert{
+		Cert: []*rkentry.BootCertE{{
+			Name:        name,
+			CAPath:      filepath.Join(dir, "ca.pem"),
+			CertPemPath: filepath.Join(dir, "server.pem"),
+			KeyPemPath:  filepath.Join(dir, "server-key.pem"),
+		}},
+	})[0]
+	certEntry.Bootstrap(context.TODO())
+	t.Cleanup(func() {
+		rkentry.GlobalAppCtx.RemoveEntry(certEntry)
+	})
+
+	return certEntry
+}
+
+func leafCommonName(t *testing.T, reloader *CertReloader) string {
+	cert, err := reloader.GetCertificate(nil)
+	assert.Nil(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.Nil(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+func servedCommonName(t *testing.T, addr string) string {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"h2"},
+	})
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
@@ -0,0 +1,542 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-entry/v2/middleware"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/auth"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/cors"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/csrf"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/jwt"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/log"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/meta"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/prom"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/ratelimit"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/secure"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/timeout"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/tracing"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/cors"
+	"github.com/rookie-ninja/rk-query"
+	"go.uber.org/zap"
+)
+
+const defaultConfigReloadInterval = 10 * time.Second
+
+// BootConfigReload Boot config which is for reloading middleware config of grpc entry.
+//
+// Path is the boot file which is watched, usually the same file used to boot the entry.
+// Endpoint serves POST /rk/v1/reload with common service, which is not authenticated and is disabled by default.
+type BootConfigReload struct {
+	Enabled    bool   `yaml:"enabled" json:"enabled"`
+	Path       string `yaml:"path" json:"path"`
+	IntervalMs int64  `yaml:"intervalMs" json:"intervalMs"`
+	Endpoint   bool   `yaml:"endpoint" json:"endpoint"`
+}
+
+// reloadableConfig is part of boot config of grpc entry which is compared while reloading.
+//
+// Sections in restartRequired are compared only for logging, the others are applied without restart.
+type reloadableConfig struct {
+	Ignore     []string                 `json:"ignore"`
+	Order      []string                 `json:"order"`
+	ErrorModel string                   `json:"errorModel"`
+	Logging    rkmidlog.BootConfig      `json:"logging"`
+	Prom       rkmidprom.BootConfig     `json:"prom"`
+	Auth       rkmidauth.BootConfig     `json:"auth"`
+	Cors       rkmidcors.BootConfig     `json:"cors"`
+	Secure     rkmidsec.BootConfig      `json:"secure"`
+	Meta       rkmidmeta.BootConfig     `json:"meta"`
+	Jwt        rkmidjwt.BootConfig      `json:"jwt"`
+	Csrf       rkmidcsrf.BootConfig     `json:"csrf"`
+	RateLimit  rkmidlimit.BootConfig    `json:"rateLimit"`
+	Timeout    rkmidtimeout.BootConfig  `json:"timeout"`
+	Trace      rkmidtrace.BootConfig    `json:"trace"`
+	Policies   []BootConfigMethodPolicy `json:"policies"`
+	Proxy      BootConfigProxy          `json:"proxy"`
+}
+
+// Sections of reloadableConfig which are applied only after restart.
+var restartRequired = []string{
+	"order", "errorModel", "prom", "secure", "meta", "csrf", "proxy.enabled", "proxy.pool",
+	"proxy.healthCheck", "proxy.outlier",
+}
+
+// Keys of which values are masked in logs.
+var secretKeys = map[string]bool{
+	"token":      true,
+	"privatekey": true,
+	"publickey":  true,
+	"basic":      true,
+	"apikey":     true,
+}
+
+// Copy reloadable sections of grpc entry at index i.
+func toReloadableConfig(config *BootConfig, i int) *reloadableConfig {
+	element := &config.Grpc[i]
+
+	return &reloadableConfig{
+		Ignore:     element.Middleware.Ignore,
+		Order:      element.Middleware.Order,
+		ErrorModel: element.Middleware.ErrorModel,
+		Logging:    element.Middleware.Logging,
+		Prom:       element.Middleware.Prom,
+		Auth:       element.Middleware.Auth,
+		Cors:       element.Middleware.Cors,
+		Secure:     element.Middleware.Secure,
+		Meta:       element.Middleware.Meta,
+		Jwt:        element.Middleware.Jwt,
+		Csrf:       element.Middleware.Csrf,
+		RateLimit:  element.Middleware.RateLimit,
+		Timeout:    element.Middleware.Timeout,
+		Trace:      element.Middleware.Trace,
+		Policies:   element.Middleware.Policies,
+		Proxy:      element.Proxy,
+	}
+}
+
+// Global policy of middlewares which are able to be overridden for methods.
+func (config *reloadableConfig) toMethodPolicy() BootConfigMethodPolicy {
+	return BootConfigMethodPolicy{
+		Logging:   &config.Logging,
+		Trace:     &config.Trace,
+		Jwt:       &config.Jwt,
+		Auth:      &config.Auth,
+		Timeout:   &config.Timeout,
+		RateLimit: &config.RateLimit,
+	}
+}
+
+// configChange is a changed value in reloadableConfig, values are JSON encoded and secrets are masked.
+type configChange struct {
+	path string
+	from string
+	to   string
+}
+
+// String returns change like "rateLimit.reqPerSec: 10 -> 20", missing value is printed as <none>.
+func (change configChange) String() string {
+	from, to := change.from, change.to
+	if len(from) < 1 {
+		from = "<none>"
+	}
+	if len(to) < 1 {
+		to = "<none>"
+	}
+
+	return fmt.Sprintf("%s: %s -> %s", change.path, from, to)
+}
+
+// Whether change is applied only after restart.
+func (change configChange) restartRequired() bool {
+	for _, section := range restartRequired {
+		if change.path == section || strings.HasPrefix(change.path, section+".") || strings.HasPrefix(change.path, section+"[") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Compare configs value by value, changes are sorted by path.
+func diffReloadableConfig(from, to *reloadableConfig) []configChange {
+	fromValues, toValues := flattenConfig(from), flattenConfig(to)
+
+	res := make([]configChange, 0)
+	for k, v := range fromValues {
+		if other, ok := toValues[k]; !ok || other != v {
+			res = append(res, configChange{path: k, from: v.String(), to: other.String()})
+		}
+	}
+	for k, v := range toValues {
+		if _, ok := fromValues[k]; !ok {
+			res = append(res, configChange{path: k, to: v.String()})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].path < res[j].path
+	})
+
+	return res
+}
+
+// flatValue is a JSON encoded value in config.
+type flatValue struct {
+	raw    string
+	secret bool
+}
+
+// String returns masked value if secret.
+func (v flatValue) String() string {
+	if v.secret && len(v.raw) > 0 {
+		return `"******"`
+	}
+
+	return v.raw
+}
+
+// Flatten config into JSON encoded values keyed with paths like policies[0].methods[1], zero values are omitted.
+func flattenConfig(config *reloadableConfig) map[string]flatValue {
+	res := make(map[string]flatValue)
+	if config == nil {
+		return res
+	}
+
+	bytes, err := json.Marshal(config)
+	if err != nil {
+		return res
+	}
+
+	var m interface{}
+	if err := json.Unmarshal(bytes, &m); err != nil {
+		return res
+	}
+
+	var flatten func(prefix string, v interface{}, secret bool)
+	flatten = func(prefix string, v interface{}, secret bool) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			for k := range value {
+				path := k
+				if len(prefix) > 0 {
+					path = prefix + "." + k
+				}
+				flatten(path, value[k], secret || secretKeys[strings.ToLower(k)])
+			}
+		case []interface{}:
+			for i := range value {
+				flatten(fmt.Sprintf("%s[%d]", prefix, i), value[i], secret)
+			}
+		default:
+			if value == nil || value == false || value == "" || value == float64(0) {
+				return
+			}
+
+			bytes, _ := json.Marshal(value)
+			res[prefix] = flatValue{raw: string(bytes), secret: secret}
+		}
+	}
+	flatten("", m, false)
+
+	return res
+}
+
+// ReloadYAML Reload middleware config of entry from boot config, all grpc entries in config are validated and
+// the element with the same name of entry is applied.
+//
+// Rules of logging, trace, jwt, auth, timeout and rate limit middlewares, method policies, CORS of grpc-gateway and
+// proxy rules are replaced without restart. Global ignore paths are added, removed ones are kept until restart and
+// logged as changes which require restart.
+// Changes are logged with secrets masked, current config is kept if new one fails to parse, validate or build.
+func (entry *GrpcEntry) ReloadYAML(raw []byte) (err error) {
+	entry.reloadLock.Lock()
+	defer entry.reloadLock.Unlock()
+
+	event := entry.EventEntry.Start(
+		"ConfigReload",
+		rkquery.WithEntryName(entry.entryName),
+		rkquery.WithEntryType(entry.entryType))
+	defer entry.EventEntry.Finish(event)
+
+	logger := entry.LoggerEntry.Logger.With(zap.String("entryName", entry.entryName))
+
+	defer func() {
+		if err != nil {
+			event.AddErr(err)
+			logger.Error("Failed to reload config, keep the old one", zap.Error(err))
+		}
+	}()
+
+	if entry.methodPolicies == nil || entry.reloadable == nil {
+		return errors.New("entry is not registered from boot config")
+	}
+
+	config := &BootConfig{}
+	if err := unmarshalBootYAML(raw, config); err != nil {
+		return err
+	}
+
+	if err := ValidateBootConfig(config); err != nil {
+		return err
+	}
+
+	index := -1
+	for i := range config.Grpc {
+		if config.Grpc[i].Enabled && config.Grpc[i].Name == entry.entryName {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("grpc entry %s is missing or disabled in config", entry.entryName)
+	}
+
+	next := toReloadableConfig(config, index)
+	changes := diffReloadableConfig(entry.reloadable, next)
+	if len(changes) < 1 {
+		logger.Info("Config reloaded without changes")
+		return nil
+	}
+
+	// build everything before swapping, so that nothing is replaced if any of them fails
+	policies, proxyRule, corsOpts, err := entry.buildReloadableConfig(next)
+	if err != nil {
+		return err
+	}
+
+	entry.methodPolicies.swap(policies)
+	if entry.ProxyEntry != nil {
+		entry.ProxyEntry.setRule(proxyRule)
+	}
+	if !jsonEqual(entry.reloadable.Cors, next.Cors) {
+		entry.setGwCorsOptions(corsOpts...)
+	}
+	rkmid.AddPathToIgnoreGlobal(next.Ignore...)
+
+	applied, skipped := make([]string, 0), make([]string, 0)
+	added, removed := diffIgnore(entry.reloadable.Ignore, next.Ignore)
+	if len(added) > 0 {
+		applied = append(applied, fmt.Sprintf("ignore: added %s", strings.Join(added, ", ")))
+	}
+	if len(removed) > 0 {
+		skipped = append(skipped, fmt.Sprintf("ignore: removed %s", strings.Join(removed, ", ")))
+	}
+	for _, change := range changes {
+		// ignore paths are reported above, since changes of them are compared by index
+		if change.path == "ignore" || strings.HasPrefix(change.path, "ignore[") {
+			continue
+		}
+
+		// proxy rules are applied only if proxy is enabled at bootstrap
+		if change.restartRequired() || (entry.ProxyEntry == nil && strings.HasPrefix(change.path, "proxy.")) {
+			skipped = append(skipped, change.String())
+		} else {
+			applied = append(applied, change.String())
+		}
+	}
+
+	event.AddPayloads(zap.Strings("changes", applied))
+	logger.Info("Config reloaded", zap.Strings("changes", applied))
+	if len(skipped) > 0 {
+		event.AddPayloads(zap.Strings("restartRequired", skipped))
+		logger.Warn("Config changed which requires restart", zap.Strings("changes", skipped))
+	}
+
+	entry.reloadable = next
+	return nil
+}
+
+// Compare ignore paths, removed paths are still ignored until restart since global ignore paths can't be removed.
+func diffIgnore(from, to []string) (added, removed []string) {
+	contains := func(paths []string, p string) bool {
+		for i := range paths {
+			if paths[i] == p {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, p := range to {
+		if !contains(from, p) {
+			added = append(added, p)
+		}
+	}
+	for _, p := range from {
+		if !contains(to, p) {
+			removed = append(removed, p)
+		}
+	}
+
+	return added, removed
+}
+
+// Build slots, proxy rule and CORS options from config, ShutdownWithError in middlewares is recovered as error.
+func (entry *GrpcEntry) buildReloadableConfig(config *reloadableConfig) (policies *methodPolicies, proxyRule *rule, corsOpts []rkmidcors.Option, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	policies = newMethodPolicies(config.toMethodPolicy(), entry.entryName, entry.LoggerEntry, entry.EventEntry)
+	policies.add(config.Policies...)
+	policies.addMethodOptions(entry.MethodOptions)
+
+	if proxyRule, err = toProxyRule(&config.Proxy); err != nil {
+		return nil, nil, nil, err
+	}
+	corsOpts = rkmidcors.ToOptions(&config.Cors, entry.entryName, entry.entryType)
+
+	return policies, proxyRule, corsOpts, nil
+}
+
+// Compare values with JSON encoding.
+func jsonEqual(a, b interface{}) bool {
+	left, _ := json.Marshal(a)
+	right, _ := json.Marshal(b)
+
+	return string(left) == string(right)
+}
+
+// Replace CORS options of grpc-gateway, interceptor is replaced as well if gateway is bootstrapped.
+func (entry *GrpcEntry) setGwCorsOptions(opts ...rkmidcors.Option) {
+	entry.gwCorsOptions = opts
+	if entry.gwCors != nil {
+		entry.gwCors.set(opts...)
+	}
+}
+
+// gwCorsHandler serves grpc-gateway with CORS interceptor which could be replaced while reloading.
+type gwCorsHandler struct {
+	next    http.Handler
+	handler http.Handler
+	lock    sync.RWMutex
+}
+
+// Create handler with CORS options, next is served directly without options.
+func newGwCorsHandler(next http.Handler, opts ...rkmidcors.Option) *gwCorsHandler {
+	h := &gwCorsHandler{
+		next: next,
+	}
+	h.set(opts...)
+
+	return h
+}
+
+// Replace CORS interceptor with options.
+func (h *gwCorsHandler) set(opts ...rkmidcors.Option) {
+	handler := h.next
+	if len(opts) > 0 {
+		handler = rkgrpccors.Interceptor(h.next, opts...)
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.handler = handler
+}
+
+// ServeHTTP serves request with current CORS interceptor.
+func (h *gwCorsHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	h.lock.RLock()
+	handler := h.handler
+	h.lock.RUnlock()
+
+	handler.ServeHTTP(writer, req)
+}
+
+// ConfigReloader watches boot file on disk and reloads middleware config of grpc entry once modified.
+type ConfigReloader struct {
+	path      string
+	interval  time.Duration
+	reload    func([]byte) error
+	logger    *zap.Logger
+	modTime   time.Time
+	quit      chan struct{}
+	wg        sync.WaitGroup
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewConfigReloader Create ConfigReloader which passes content of boot file to reload, interval would be 10 seconds
+// if not positive.
+func NewConfigReloader(path string, interval time.Duration, reload func([]byte) error, logger *rkentry.LoggerEntry) *ConfigReloader {
+	if interval <= 0 {
+		interval = defaultConfigReloadInterval
+	}
+
+	if logger == nil {
+		logger = rkentry.GlobalAppCtx.GetLoggerEntryDefault()
+	}
+
+	reloader := &ConfigReloader{
+		path:     path,
+		interval: interval,
+		reload:   reload,
+		logger:   logger.Logger,
+		quit:     make(chan struct{}),
+	}
+
+	// record current modification time, so that file won't be reloaded at the first check
+	if info, err := os.Stat(path); err == nil {
+		reloader.modTime = info.ModTime()
+	}
+
+	return reloader
+}
+
+// Start watching file in background.
+func (r *ConfigReloader) Start() {
+	r.startOnce.Do(func() {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+
+			ticker := time.NewTicker(r.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-r.quit:
+					return
+				case <-ticker.C:
+					if r.modified() {
+						r.Reload()
+					}
+				}
+			}
+		}()
+	})
+}
+
+// Stop watching file.
+func (r *ConfigReloader) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.quit)
+	})
+	r.wg.Wait()
+}
+
+// Reload Read boot file and reload it.
+func (r *ConfigReloader) Reload() error {
+	bytes, err := os.ReadFile(r.path)
+	if err != nil {
+		r.logger.Error("Failed to read boot file, keep the old config", zap.String("path", r.path), zap.Error(err))
+		return err
+	}
+
+	return r.reload(bytes)
+}
+
+// Check whether file was modified.
+func (r *ConfigReloader) modified() bool {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return false
+	}
+
+	if info.ModTime().Equal(r.modTime) {
+		return false
+	}
+	r.modTime = info.ModTime()
+
+	return true
+}
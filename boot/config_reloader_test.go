@@ -0,0 +1,272 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-entry/v2/middleware"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/cors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const reloadConfigTemplate = `
+---
+grpc:
+  - name: ut-reload
+    enabled: true
+    proxy:
+      enabled: true
+      rules:
+        - type: pathBased
+          paths: ["/ut.v1.Greeter/*"]
+          dest: ["%s"]
+    middleware:
+      auth:
+        enabled: true
+        apiKey: ["%s"]
+`
+
+func TestDiffReloadableConfig(t *testing.T) {
+	from := &reloadableConfig{}
+	from.Auth.Enabled = true
+	from.Auth.ApiKey = []string{"old-key"}
+	from.Order = []string{"auth"}
+
+	to := &reloadableConfig{}
+	to.Auth.Enabled = true
+	to.Auth.ApiKey = []string{"new-key"}
+	to.Timeout.Enabled = true
+	to.Timeout.TimeoutMs = 500
+
+	changes := diffReloadableConfig(from, to)
+	assert.Len(t, changes, 4)
+
+	// secrets are masked
+	assert.Equal(t, `auth.apiKey[0]: "******" -> "******"`, changes[0].String())
+	assert.False(t, changes[0].restartRequired())
+
+	// order requires restart
+	assert.Equal(t, `order[0]: "auth" -> <none>`, changes[1].String())
+	assert.True(t, changes[1].restartRequired())
+
+	assert.Equal(t, `timeout.enabled: <none> -> true`, changes[2].String())
+	assert.Equal(t, `timeout.timeoutMs: <none> -> 500`, changes[3].String())
+
+	// without changes
+	assert.Empty(t, diffReloadableConfig(to, to))
+}
+
+func TestDiffIgnore(t *testing.T) {
+	added, removed := diffIgnore([]string{"/a", "/b"}, []string{"/b", "/c"})
+	assert.Equal(t, []string{"/c"}, added)
+	assert.Equal(t, []string{"/a"}, removed)
+
+	// with reordered paths
+	added, removed = diffIgnore([]string{"/a", "/b"}, []string{"/b", "/a"})
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+}
+
+func TestGrpcEntry_Reload(t *testing.T) {
+	p := path.Join(t.TempDir(), "boot.yaml")
+	assert.Nil(t, os.WriteFile(p, []byte(fmt.Sprintf(reloadConfigTemplate, "localhost:1972", "old-key")), 0644))
+
+	entries, err := NewGrpcEntriesFromYAML([]byte(fmt.Sprintf(reloadConfigTemplate, "localhost:1972", "old-key")))
+	assert.Nil(t, err)
+	entry := entries["ut-reload"]
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+	entry.ConfigReloader = NewConfigReloader(p, 0, entry.ReloadYAML, entry.LoggerEntry)
+	entry.CommonServiceEntry = rkentry.RegisterCommonServiceEntry(&rkentry.BootCommonService{Enabled: true})
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry.CommonServiceEntry)
+	assert.Equal(t, "/rk/v1/reload", entry.reloadPath())
+
+	serve := func(method string) *httptest.ResponseRecorder {
+		writer := httptest.NewRecorder()
+		entry.reload(writer, httptest.NewRequest(method, entry.reloadPath(), nil))
+		return writer
+	}
+
+	// with GET
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet).Code)
+
+	// happy case
+	assert.Nil(t, os.WriteFile(p, []byte(fmt.Sprintf(reloadConfigTemplate, "localhost:1973", "new-key")), 0644))
+	writer := serve(http.MethodPost)
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Contains(t, writer.Body.String(), `"reloaded": true`)
+	assert.Equal(t, []string{"localhost:1973"}, entry.ProxyEntry.getRule().PathPattern[0].Dest)
+
+	// with invalid config
+	assert.Nil(t, os.WriteFile(p, []byte("grpc: ["), 0644))
+	writer = serve(http.MethodPost)
+	assert.Equal(t, http.StatusInternalServerError, writer.Code)
+	assert.Contains(t, writer.Body.String(), `"reloaded": false`)
+}
+
+func TestGrpcEntry_ReloadEndpoint(t *testing.T) {
+	p := path.Join(t.TempDir(), "boot.yaml")
+	assert.Nil(t, os.WriteFile(p, []byte(fmt.Sprintf(reloadConfigTemplate, "localhost:1972", "old-key")), 0644))
+
+	pattern := func(endpoint bool) string {
+		entry := RegisterGrpcEntry(
+			WithName("ut-reload-endpoint"),
+			WithPort(0),
+			WithConfigReload(p, time.Minute),
+			WithConfigReloadEndpoint(endpoint),
+			WithCommonServiceEntry(rkentry.RegisterCommonServiceEntry(&rkentry.BootCommonService{Enabled: true})))
+		defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+		defer rkentry.GlobalAppCtx.RemoveEntry(entry.CommonServiceEntry)
+
+		assert.Nil(t, entry.BootstrapE(context.TODO()))
+		defer entry.Interrupt(context.TODO())
+
+		_, res := entry.HttpMux.Handler(httptest.NewRequest(http.MethodPost, entry.reloadPath(), nil))
+		return res
+	}
+
+	// disabled by default, request falls through to grpc-gateway
+	assert.Equal(t, "/", pattern(false))
+
+	// with endpoint enabled
+	assert.Equal(t, "/rk/v1/reload", pattern(true))
+
+	// with boot config
+	entries, err := NewGrpcEntriesFromYAML([]byte(fmt.Sprintf(`
+---
+grpc:
+  - name: ut-reload-endpoint
+    port: 1949
+    enabled: true
+    reload:
+      enabled: true
+      path: %s
+      endpoint: true
+`, p)))
+	assert.Nil(t, err)
+	defer rkentry.GlobalAppCtx.RemoveEntry(entries["ut-reload-endpoint"])
+	assert.True(t, entries["ut-reload-endpoint"].reloadEndpoint)
+}
+
+func TestGrpcEntry_ReloadYAML(t *testing.T) {
+	entries, err := NewGrpcEntriesFromYAML([]byte(fmt.Sprintf(reloadConfigTemplate, "localhost:1972", "old-key")))
+	assert.Nil(t, err)
+	entry := entries["ut-reload"]
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	call := func(apiKey string) error {
+		ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs(rkmid.HeaderApiKey, apiKey))
+		// logging, panic, trace, jwt and auth
+		_, err := entry.UnaryInterceptors[4](ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/ut.v1.Greeter/Greeter"},
+			func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		return err
+	}
+
+	assert.Nil(t, call("old-key"))
+	assert.NotNil(t, call("new-key"))
+
+	// happy case
+	assert.Nil(t, entry.ReloadYAML([]byte(fmt.Sprintf(reloadConfigTemplate, "localhost:1973", "new-key"))))
+	assert.NotNil(t, call("old-key"))
+	assert.Nil(t, call("new-key"))
+	assert.Equal(t, []string{"localhost:1973"}, entry.ProxyEntry.getRule().PathPattern[0].Dest)
+
+	// with invalid config, old config is kept
+	assert.NotNil(t, entry.ReloadYAML([]byte(`
+---
+grpc:
+  - name: ut-reload
+    enabled: true
+    middleware:
+      policies:
+        - auth:
+            enabled: false
+`)))
+	assert.Nil(t, call("new-key"))
+	assert.Equal(t, []string{"localhost:1973"}, entry.ProxyEntry.getRule().PathPattern[0].Dest)
+
+	// with missing entry
+	assert.NotNil(t, entry.ReloadYAML([]byte(`
+---
+grpc:
+  - name: ut-reload
+    enabled: false
+`)))
+	assert.Nil(t, call("new-key"))
+
+	// with entry which is not registered from boot config
+	assert.NotNil(t, (&GrpcEntry{
+		LoggerEntry: rkentry.GlobalAppCtx.GetLoggerEntryDefault(),
+		EventEntry:  rkentry.GlobalAppCtx.GetEventEntryDefault(),
+	}).ReloadYAML([]byte(fmt.Sprintf(reloadConfigTemplate, "localhost:1973", "new-key"))))
+}
+
+func TestGwCorsHandler(t *testing.T) {
+	next := http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(h http.Handler) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ut", nil)
+		req.Header.Set("Origin", "http://ut.com")
+		writer := httptest.NewRecorder()
+		h.ServeHTTP(writer, req)
+		return writer
+	}
+
+	// without options
+	h := newGwCorsHandler(next)
+	assert.Empty(t, serve(h).Header().Get("Access-Control-Allow-Origin"))
+
+	// with options
+	h.set(rkmidcors.WithAllowOrigins("http://ut.com"))
+	assert.Equal(t, "http://ut.com", serve(h).Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestConfigReloader(t *testing.T) {
+	p := path.Join(t.TempDir(), "boot.yaml")
+	assert.Nil(t, os.WriteFile(p, []byte("old"), 0644))
+
+	var lock sync.Mutex
+	reloaded := make([]string, 0)
+	reloader := NewConfigReloader(p, 10*time.Millisecond, func(raw []byte) error {
+		lock.Lock()
+		defer lock.Unlock()
+		reloaded = append(reloaded, string(raw))
+		return nil
+	}, nil)
+	reloader.Start()
+	defer reloader.Stop()
+
+	// file is not modified
+	time.Sleep(50 * time.Millisecond)
+	lock.Lock()
+	assert.Empty(t, reloaded)
+	lock.Unlock()
+
+	// file is modified
+	assert.Nil(t, os.WriteFile(p, []byte("new"), 0644))
+	assert.Nil(t, os.Chtimes(p, time.Now(), time.Now().Add(time.Minute)))
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(reloaded) == 1 && reloaded[0] == "new"
+	}, time.Second, 10*time.Millisecond)
+
+	// with missing file
+	assert.NotNil(t, NewConfigReloader(path.Join(t.TempDir(), "not-exist.yaml"), 0, nil, nil).Reload())
+}
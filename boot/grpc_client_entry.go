@@ -0,0 +1,700 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/log"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/prom"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/tracing"
+	"github.com/rookie-ninja/rk-grpc/v2/boot/resolver"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/breaker"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/log"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/prom"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/propagation"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/retry"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/tracing"
+	"github.com/rookie-ninja/rk-query"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"sync"
+	"time"
+)
+
+const (
+	// GrpcClientEntryType default entry type
+	GrpcClientEntryType = "gRPCClientEntry"
+
+	// defaultDialTimeout is used with block if dial timeout is not provided
+	defaultDialTimeout = 10 * time.Second
+)
+
+// errClientInterrupted is returned by dial after entry is interrupted.
+var errClientInterrupted = errors.New("grpc client entry is interrupted")
+
+// BootConfigGrpcClient Boot config which is for grpc client entry.
+type BootConfigGrpcClient struct {
+	GrpcClient []struct {
+		Name          string                    `yaml:"name" json:"name"`
+		Description   string                    `yaml:"description" json:"description"`
+		Enabled       bool                      `yaml:"enabled" json:"enabled"`
+		Target        string                    `yaml:"target" json:"target"`
+		Authority     string                    `yaml:"authority" json:"authority"`
+		UserAgent     string                    `yaml:"userAgent" json:"userAgent"`
+		Block         bool                      `yaml:"block" json:"block"`
+		DialTimeoutMs int64                     `yaml:"dialTimeoutMs" json:"dialTimeoutMs"`
+		CertEntry     string                    `yaml:"certEntry" json:"certEntry"`
+		LoggerEntry   string                    `yaml:"loggerEntry" json:"loggerEntry"`
+		EventEntry    string                    `yaml:"eventEntry" json:"eventEntry"`
+		GrpcEntry     string                    `yaml:"grpcEntry" json:"grpcEntry"`
+		Balancer      string                    `yaml:"balancer" json:"balancer"`
+		Resolver      rkgrpcresolver.BootConfig `yaml:"resolver" json:"resolver"`
+		CallOption    struct {
+			WaitForReady       bool `yaml:"waitForReady" json:"waitForReady"`
+			MaxCallRecvMsgSize int  `yaml:"maxCallRecvMsgSize" json:"maxCallRecvMsgSize"`
+			MaxCallSendMsgSize int  `yaml:"maxCallSendMsgSize" json:"maxCallSendMsgSize"`
+		} `yaml:"callOption" json:"callOption"`
+		Middleware struct {
+			Logging     rkmidlog.BootConfig      `yaml:"logging" json:"logging"`
+			Prom        rkmidprom.BootConfig     `yaml:"prom" json:"prom"`
+			Propagation rkgrpcprop.BootConfig    `yaml:"propagation" json:"propagation"`
+			Trace       rkmidtrace.BootConfig    `yaml:"trace" json:"trace"`
+			Retry       rkgrpcretry.BootConfig   `yaml:"retry" json:"retry"`
+			Breaker     rkgrpcbreaker.BootConfig `yaml:"breaker" json:"breaker"`
+		} `yaml:"middleware" json:"middleware"`
+	} `yaml:"grpcClient" json:"grpcClient"`
+}
+
+// GrpcClientEntry implements rkentry.Entry interface.
+//
+// GrpcClientEntry holds a long-lived *grpc.ClientConn to Target. The connection is created
+// during Bootstrap (or lazily on the first call of Conn()) and closed during Interrupt, it is not
+// created again after Interrupt.
+type GrpcClientEntry struct {
+	entryName          string                         `json:"-" yaml:"-"`
+	entryType          string                         `json:"-" yaml:"-"`
+	entryDescription   string                         `json:"-" yaml:"-"`
+	Target             string                         `json:"-" yaml:"-"`
+	LoggerEntry        *rkentry.LoggerEntry           `json:"-" yaml:"-"`
+	EventEntry         *rkentry.EventEntry            `json:"-" yaml:"-"`
+	CertEntry          *rkentry.CertEntry             `json:"-" yaml:"-"`
+	PromRegistry       *prometheus.Registry           `json:"-" yaml:"-"`
+	Resolver           *rkgrpcresolver.Builder        `json:"-" yaml:"-"`
+	Balancer           string                         `json:"-" yaml:"-"`
+	Block              bool                           `json:"-" yaml:"-"`
+	DialTimeout        time.Duration                  `json:"-" yaml:"-"`
+	DialOpts           []grpc.DialOption              `json:"-" yaml:"-"`
+	CallOpts           []grpc.CallOption              `json:"-" yaml:"-"`
+	UnaryInterceptors  []grpc.UnaryClientInterceptor  `json:"-" yaml:"-"`
+	StreamInterceptors []grpc.StreamClientInterceptor `json:"-" yaml:"-"`
+	conn               *grpc.ClientConn               `json:"-" yaml:"-"`
+	connDial           *clientDial                    `json:"-" yaml:"-"`
+	connClosed         bool                           `json:"-" yaml:"-"`
+	connLock           sync.Mutex                     `json:"-" yaml:"-"`
+}
+
+// clientDial is dialing in progress, done is closed once conn or err is set.
+type clientDial struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	conn   *grpc.ClientConn
+	err    error
+}
+
+// RegisterGrpcClientEntryYAML Register grpc client entries with provided config file (Must YAML file).
+//
+// Entries are declared in grpcClient section of boot config file.
+//
+// Client side metrics are registered into a registry owned by client entry, and exposed by
+// /metrics of GrpcEntry referenced by grpcEntry.
+//
+// Error handling:
+// Process will shutdown if any errors occur with rkentry.ShutdownWithError function
+func RegisterGrpcClientEntryYAML(raw []byte) map[string]rkentry.Entry {
+	res := make(map[string]rkentry.Entry)
+
+	// 1: decode config map into boot config struct
+	config := &BootConfigGrpcClient{}
+	rkentry.UnmarshalBootYAML(raw, config)
+
+	for i := range config.GrpcClient {
+		element := config.GrpcClient[i]
+		if !element.Enabled {
+			continue
+		}
+
+		// logger entry
+		loggerEntry := rkentry.GlobalAppCtx.GetLoggerEntry(element.LoggerEntry)
+		if loggerEntry == nil {
+			loggerEntry = rkentry.GlobalAppCtx.GetLoggerEntryDefault()
+		}
+
+		// event entry
+		eventEntry := rkentry.GlobalAppCtx.GetEventEntry(element.EventEntry)
+		if eventEntry == nil {
+			eventEntry = rkentry.GlobalAppCtx.GetEventEntryDefault()
+		}
+
+		// resolver, dial target would be provided by resolver
+		builder, err := rkgrpcresolver.NewBuilderFromConfig(&element.Resolver, element.Name)
+		if err != nil {
+			rkentry.ShutdownWithError(err)
+		}
+		if builder != nil {
+			element.Target = builder.Target()
+		}
+
+		if _, err := rkgrpcresolver.ServiceConfig(element.Balancer); err != nil {
+			rkentry.ShutdownWithError(err)
+		}
+
+		if err := rkgrpcretry.ValidateBootConfig(&element.Middleware.Retry); err != nil {
+			rkentry.ShutdownWithError(err)
+		}
+
+		// dial options
+		dialOpts := make([]grpc.DialOption, 0)
+		if len(element.Authority) > 0 {
+			dialOpts = append(dialOpts, grpc.WithAuthority(element.Authority))
+		}
+		if len(element.UserAgent) > 0 {
+			dialOpts = append(dialOpts, grpc.WithUserAgent(element.UserAgent))
+		}
+
+		// default call options
+		callOpts := make([]grpc.CallOption, 0)
+		if element.CallOption.WaitForReady {
+			callOpts = append(callOpts, grpc.WaitForReady(true))
+		}
+		if element.CallOption.MaxCallRecvMsgSize > 0 {
+			callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(element.CallOption.MaxCallRecvMsgSize))
+		}
+		if element.CallOption.MaxCallSendMsgSize > 0 {
+			callOpts = append(callOpts, grpc.MaxCallSendMsgSize(element.CallOption.MaxCallSendMsgSize))
+		}
+
+		entry := RegisterGrpcClientEntry(
+			WithNameClient(element.Name),
+			WithDescriptionClient(element.Description),
+			WithTargetClient(element.Target),
+			WithLoggerEntryClient(loggerEntry),
+			WithEventEntryClient(eventEntry),
+			WithCertEntryClient(rkentry.GlobalAppCtx.GetCertEntry(element.CertEntry)),
+			WithResolverClient(builder),
+			WithBalancerClient(element.Balancer),
+			WithBlockClient(element.Block, time.Duration(element.DialTimeoutMs)*time.Millisecond),
+			WithDialOptionsClient(dialOpts...),
+			WithCallOptionsClient(callOpts...))
+
+		// logging middleware
+		if element.Middleware.Logging.Enabled {
+			entry.AddUnaryInterceptors(rkgrpclog.UnaryClientInterceptor(
+				rkmidlog.ToOptions(&element.Middleware.Logging, element.Name, GrpcClientEntryType,
+					loggerEntry, eventEntry)...))
+			entry.AddStreamInterceptors(rkgrpclog.StreamClientInterceptor(
+				rkmidlog.ToOptions(&element.Middleware.Logging, element.Name, GrpcClientEntryType,
+					loggerEntry, eventEntry)...))
+		}
+
+		// did we enable metrics interceptor?
+		if element.Middleware.Prom.Enabled {
+			entry.AddUnaryInterceptors(rkgrpcprom.UnaryClientInterceptor(
+				rkmidprom.ToOptions(&element.Middleware.Prom, element.Name, GrpcClientEntryType,
+					entry.PromRegistry, rkmidprom.LabelerTypeGrpc)...))
+			entry.AddStreamInterceptors(rkgrpcprom.StreamClientInterceptor(
+				rkmidprom.ToOptions(&element.Middleware.Prom, element.Name, GrpcClientEntryType,
+					entry.PromRegistry, rkmidprom.LabelerTypeGrpc)...))
+		}
+
+		// propagation middleware, should be placed before trace middleware which overrides propagated span
+		if element.Middleware.Propagation.Enabled {
+			entry.AddUnaryInterceptors(rkgrpcprop.UnaryClientInterceptor(
+				rkgrpcprop.ToOptions(&element.Middleware.Propagation, element.Name, GrpcClientEntryType)...))
+			entry.AddStreamInterceptors(rkgrpcprop.StreamClientInterceptor(
+				rkgrpcprop.ToOptions(&element.Middleware.Propagation, element.Name, GrpcClientEntryType)...))
+		}
+
+		// trace middleware
+		if element.Middleware.Trace.Enabled {
+			entry.AddUnaryInterceptors(rkgrpctrace.UnaryClientInterceptor(
+				rkmidtrace.ToOptions(&element.Middleware.Trace, element.Name, GrpcClientEntryType)...))
+			entry.AddStreamInterceptors(rkgrpctrace.StreamClientInterceptor(
+				rkmidtrace.ToOptions(&element.Middleware.Trace, element.Name, GrpcClientEntryType)...))
+		}
+
+		// breaker middleware, placed before retry so that calls rejected by open breaker are not retried
+		if element.Middleware.Breaker.Enabled {
+			entry.AddUnaryInterceptors(rkgrpcbreaker.UnaryClientInterceptor(
+				rkgrpcbreaker.ToOptions(&element.Middleware.Breaker, element.Name, GrpcClientEntryType,
+					entry.PromRegistry)...))
+			entry.AddStreamInterceptors(rkgrpcbreaker.StreamClientInterceptor(
+				rkgrpcbreaker.ToOptions(&element.Middleware.Breaker, element.Name, GrpcClientEntryType,
+					entry.PromRegistry)...))
+		}
+
+		// retry middleware, should be the last one so that each attempt won't be logged, traced or counted by breaker
+		// separately
+		if element.Middleware.Retry.Enabled {
+			entry.AddUnaryInterceptors(rkgrpcretry.UnaryClientInterceptor(
+				rkgrpcretry.ToOptions(&element.Middleware.Retry, element.Name, GrpcClientEntryType,
+					entry.PromRegistry)...))
+			entry.AddStreamInterceptors(rkgrpcretry.StreamClientInterceptor(
+				rkgrpcretry.ToOptions(&element.Middleware.Retry, element.Name, GrpcClientEntryType,
+					entry.PromRegistry)...))
+		}
+
+		// expose client metrics with referenced grpc entry
+		if element.Middleware.Prom.Enabled || element.Middleware.Retry.Enabled || element.Middleware.Breaker.Enabled {
+			if grpcEntry := GetGrpcEntry(element.GrpcEntry); grpcEntry != nil {
+				grpcEntry.AddPromGatherers(entry.PromRegistry)
+			}
+		}
+
+		res[element.Name] = entry
+	}
+
+	return res
+}
+
+// RegisterGrpcClientEntry Register GrpcClientEntry with options.
+func RegisterGrpcClientEntry(opts ...GrpcClientEntryOption) *GrpcClientEntry {
+	entry := &GrpcClientEntry{
+		entryType:          GrpcClientEntryType,
+		entryDescription:   "Internal RK entry which helps to create grpc client connection.",
+		LoggerEntry:        rkentry.GlobalAppCtx.GetLoggerEntryDefault(),
+		EventEntry:         rkentry.GlobalAppCtx.GetEventEntryDefault(),
+		DialOpts:           make([]grpc.DialOption, 0),
+		CallOpts:           make([]grpc.CallOption, 0),
+		UnaryInterceptors:  make([]grpc.UnaryClientInterceptor, 0),
+		StreamInterceptors: make([]grpc.StreamClientInterceptor, 0),
+	}
+
+	for i := range opts {
+		opts[i](entry)
+	}
+
+	if entry.Resolver != nil && len(entry.Target) < 1 {
+		entry.Target = entry.Resolver.Target()
+	}
+
+	if len(entry.entryName) < 1 {
+		entry.entryName = "grpc-client-" + entry.Target
+	}
+
+	if entry.LoggerEntry == nil {
+		entry.LoggerEntry = rkentry.GlobalAppCtx.GetLoggerEntryDefault()
+	}
+
+	if entry.EventEntry == nil {
+		entry.EventEntry = rkentry.GlobalAppCtx.GetEventEntryDefault()
+	}
+
+	if entry.PromRegistry == nil {
+		entry.PromRegistry = prometheus.NewRegistry()
+	}
+
+	rkentry.GlobalAppCtx.AddEntry(entry)
+
+	return entry
+}
+
+// ************* Entry function *************
+
+// GetName Get entry name.
+func (entry *GrpcClientEntry) GetName() string {
+	return entry.entryName
+}
+
+// GetType Get entry type.
+func (entry *GrpcClientEntry) GetType() string {
+	return entry.entryType
+}
+
+// GetDescription Get description of entry.
+func (entry *GrpcClientEntry) GetDescription() string {
+	return entry.entryDescription
+}
+
+// String Stringfy entry.
+func (entry *GrpcClientEntry) String() string {
+	bytes, _ := json.Marshal(entry)
+	return string(bytes)
+}
+
+// Bootstrap GrpcClientEntry, create client connection to target.
+func (entry *GrpcClientEntry) Bootstrap(ctx context.Context) {
+	event, _ := entry.logBasicInfo("Bootstrap", ctx)
+
+	if _, err := entry.dial(ctx); err != nil {
+		entry.EventEntry.FinishWithError(event, err)
+		rkentry.ShutdownWithError(err)
+	}
+
+	entry.EventEntry.Finish(event)
+}
+
+// Interrupt GrpcClientEntry, close client connection.
+func (entry *GrpcClientEntry) Interrupt(ctx context.Context) {
+	event, logger := entry.logBasicInfo("Interrupt", ctx)
+
+	// dialing in progress is canceled, connection dialed after this is closed by dial
+	entry.connLock.Lock()
+	entry.connClosed = true
+	if entry.connDial != nil {
+		entry.connDial.cancel()
+	}
+	if entry.conn != nil {
+		if err := entry.conn.Close(); err != nil {
+			event.AddErr(err)
+			logger.Warn("Error occurs while closing client connection", zap.Error(err))
+		}
+		entry.conn = nil
+	}
+	entry.connLock.Unlock()
+
+	entry.EventEntry.Finish(event)
+
+	rkentry.GlobalAppCtx.RemoveEntry(entry)
+}
+
+// MarshalJSON Marshal entry.
+func (entry *GrpcClientEntry) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{
+		"name":        entry.entryName,
+		"type":        entry.entryType,
+		"description": entry.entryDescription,
+		"target":      entry.Target,
+		"balancer":    entry.Balancer,
+	}
+
+	if entry.CertEntry != nil {
+		m["certEntry"] = entry.CertEntry.GetName()
+	}
+
+	return json.Marshal(&m)
+}
+
+// UnmarshalJSON Not supported.
+func (entry *GrpcClientEntry) UnmarshalJSON([]byte) error {
+	return nil
+}
+
+// ************* public function *************
+
+// Conn returns *grpc.ClientConn of this entry.
+//
+// Connection will be created if entry was not bootstrapped yet, nil will be returned if failed to dial
+// or entry is interrupted.
+func (entry *GrpcClientEntry) Conn() *grpc.ClientConn {
+	conn, err := entry.dial(context.Background())
+	if err != nil {
+		entry.LoggerEntry.Warn("Failed to dial grpc target",
+			zap.String("entryName", entry.entryName),
+			zap.String("target", entry.Target),
+			zap.Error(err))
+		return nil
+	}
+
+	return conn
+}
+
+// AddDialOptions Add grpc dial options.
+func (entry *GrpcClientEntry) AddDialOptions(opts ...grpc.DialOption) {
+	entry.DialOpts = append(entry.DialOpts, opts...)
+}
+
+// AddCallOptions Add default grpc call options.
+func (entry *GrpcClientEntry) AddCallOptions(opts ...grpc.CallOption) {
+	entry.CallOpts = append(entry.CallOpts, opts...)
+}
+
+// AddUnaryInterceptors Add unary client interceptor.
+func (entry *GrpcClientEntry) AddUnaryInterceptors(inter ...grpc.UnaryClientInterceptor) {
+	entry.UnaryInterceptors = append(entry.UnaryInterceptors, inter...)
+}
+
+// AddStreamInterceptors Add stream client interceptor.
+func (entry *GrpcClientEntry) AddStreamInterceptors(inter ...grpc.StreamClientInterceptor) {
+	entry.StreamInterceptors = append(entry.StreamInterceptors, inter...)
+}
+
+// IsTlsEnabled Is TLS enabled?
+func (entry *GrpcClientEntry) IsTlsEnabled() bool {
+	return entry.CertEntry != nil
+}
+
+// Create client connection if missing, concurrent callers wait for the same dialing without holding connLock.
+func (entry *GrpcClientEntry) dial(ctx context.Context) (*grpc.ClientConn, error) {
+	entry.connLock.Lock()
+	switch {
+	case entry.connClosed:
+		entry.connLock.Unlock()
+		return nil, errClientInterrupted
+	case entry.conn != nil:
+		conn := entry.conn
+		entry.connLock.Unlock()
+		return conn, nil
+	case entry.connDial != nil:
+		call := entry.connDial
+		entry.connLock.Unlock()
+
+		select {
+		case <-call.done:
+			return call.conn, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	call := &clientDial{done: make(chan struct{}), cancel: cancel}
+	entry.connDial = call
+	entry.connLock.Unlock()
+
+	call.conn, call.err = entry.dialContext(ctx)
+
+	entry.connLock.Lock()
+	entry.connDial = nil
+	switch {
+	case entry.connClosed:
+		if call.conn != nil {
+			call.conn.Close()
+		}
+		call.conn, call.err = nil, errClientInterrupted
+	case call.err == nil:
+		entry.conn = call.conn
+	}
+	entry.connLock.Unlock()
+	close(call.done)
+
+	return call.conn, call.err
+}
+
+// Dial target with options of entry, dial timeout is defaultDialTimeout with block if missing.
+func (entry *GrpcClientEntry) dialContext(ctx context.Context) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(entry.transportCredentials()),
+		grpc.WithChainUnaryInterceptor(entry.UnaryInterceptors...),
+		grpc.WithChainStreamInterceptor(entry.StreamInterceptors...),
+	}
+
+	if len(entry.CallOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(entry.CallOpts...))
+	}
+
+	if entry.Resolver != nil {
+		opts = append(opts, grpc.WithResolvers(entry.Resolver))
+	}
+
+	serviceConfig, err := rkgrpcresolver.ServiceConfig(entry.Balancer)
+	if err != nil {
+		return nil, err
+	}
+	if len(serviceConfig) > 0 {
+		opts = append(opts, grpc.WithDefaultServiceConfig(serviceConfig))
+	}
+
+	// user provided options could override default ones
+	opts = append(opts, entry.DialOpts...)
+
+	if entry.Block {
+		opts = append(opts, grpc.WithBlock())
+
+		timeout := entry.DialTimeout
+		if timeout <= 0 {
+			timeout = defaultDialTimeout
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return grpc.DialContext(ctx, entry.Target, opts...)
+}
+
+// Build transport credentials based on CertEntry.
+func (entry *GrpcClientEntry) transportCredentials() credentials.TransportCredentials {
+	if !entry.IsTlsEnabled() {
+		return insecure.NewCredentials()
+	}
+
+	conf := &tls.Config{}
+
+	// use RootCA in cert entry to verify server, system cert pool will be used if missing
+	if entry.CertEntry.RootCA != nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(entry.CertEntry.RootCA)
+		conf.RootCAs = pool
+	}
+
+	// send client certificate to server
+	if entry.CertEntry.Certificate != nil {
+		conf.Certificates = []tls.Certificate{*entry.CertEntry.Certificate}
+	}
+
+	return credentials.NewTLS(conf)
+}
+
+// Add basic fields into event.
+func (entry *GrpcClientEntry) logBasicInfo(operation string, ctx context.Context) (rkquery.Event, *zap.Logger) {
+	event := entry.EventEntry.Start(
+		operation,
+		rkquery.WithEntryName(entry.GetName()),
+		rkquery.WithEntryType(entry.GetType()))
+
+	// extract eventId if exists
+	if val := ctx.Value("eventId"); val != nil {
+		if id, ok := val.(string); ok {
+			event.SetEventId(id)
+		}
+	}
+
+	logger := entry.LoggerEntry.With(
+		zap.String("eventId", event.GetEventId()),
+		zap.String("entryName", entry.entryName),
+		zap.String("entryType", entry.entryType))
+
+	// add general info
+	event.AddPayloads(
+		zap.String("target", entry.Target))
+
+	// add tls info
+	if entry.IsTlsEnabled() {
+		event.AddPayloads(
+			zap.Bool("tlsEnabled", true))
+	}
+
+	logger.Info(fmt.Sprintf("%s grpcClientEntry", operation))
+
+	return event, logger
+}
+
+// GetGrpcClientEntry Get GrpcClientEntry from rkentry.GlobalAppCtx.
+func GetGrpcClientEntry(name string) *GrpcClientEntry {
+	if raw := rkentry.GlobalAppCtx.GetEntry(GrpcClientEntryType, name); raw != nil {
+		if res, ok := raw.(*GrpcClientEntry); ok {
+			return res
+		}
+	}
+
+	return nil
+}
+
+// *********** Options ***********
+
+// GrpcClientEntryOption GrpcClientEntry option.
+type GrpcClientEntryOption func(*GrpcClientEntry)
+
+// WithNameClient Provide name.
+func WithNameClient(name string) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.entryName = name
+	}
+}
+
+// WithDescriptionClient Provide description.
+func WithDescriptionClient(description string) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.entryDescription = description
+	}
+}
+
+// WithTargetClient Provide dial target, any target supported by grpc.DialContext is accepted.
+func WithTargetClient(target string) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.Target = target
+	}
+}
+
+// WithLoggerEntryClient Provide rkentry.LoggerEntry.
+func WithLoggerEntryClient(logger *rkentry.LoggerEntry) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.LoggerEntry = logger
+	}
+}
+
+// WithEventEntryClient Provide rkentry.EventEntry.
+func WithEventEntryClient(event *rkentry.EventEntry) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.EventEntry = event
+	}
+}
+
+// WithCertEntryClient Provide rkentry.CertEntry, TLS will be enabled if provided.
+func WithCertEntryClient(certEntry *rkentry.CertEntry) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.CertEntry = certEntry
+	}
+}
+
+// WithPromRegistryClient Provide prometheus.Registry which client side metrics would be registered into.
+func WithPromRegistryClient(registry *prometheus.Registry) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.PromRegistry = registry
+	}
+}
+
+// WithResolverClient Provide rkgrpcresolver.Builder, target of resolver would be used if target is empty.
+func WithResolverClient(builder *rkgrpcresolver.Builder) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.Resolver = builder
+	}
+}
+
+// WithBalancerClient Provide name of balancer, one of round_robin, pick_first and weighted.
+func WithBalancerClient(balancer string) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.Balancer = balancer
+	}
+}
+
+// WithBlockClient Block in Bootstrap until connection is up or timeout exceeded, timeout is 10 seconds if not positive.
+func WithBlockClient(block bool, timeout time.Duration) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.Block = block
+		entry.DialTimeout = timeout
+	}
+}
+
+// WithDialOptionsClient Provide grpc.DialOption.
+func WithDialOptionsClient(opts ...grpc.DialOption) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.DialOpts = append(entry.DialOpts, opts...)
+	}
+}
+
+// WithCallOptionsClient Provide default grpc.CallOption.
+func WithCallOptionsClient(opts ...grpc.CallOption) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.CallOpts = append(entry.CallOpts, opts...)
+	}
+}
+
+// WithUnaryInterceptorsClient Provide grpc.UnaryClientInterceptor.
+func WithUnaryInterceptorsClient(opts ...grpc.UnaryClientInterceptor) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.UnaryInterceptors = append(entry.UnaryInterceptors, opts...)
+	}
+}
+
+// WithStreamInterceptorsClient Provide grpc.StreamClientInterceptor.
+func WithStreamInterceptorsClient(opts ...grpc.StreamClientInterceptor) GrpcClientEntryOption {
+	return func(entry *GrpcClientEntry) {
+		entry.StreamInterceptors = append(entry.StreamInterceptors, opts...)
+	}
+}
@@ -0,0 +1,439 @@
+//go:build !race
+// +build !race
+
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+package rkgrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-grpc/v2/boot/resolver"
+	testdata "github.com/rookie-ninja/rk-grpc/v2/example/middleware/proto/testdata"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegisterGrpcClientEntryYAML(t *testing.T) {
+	defer assertNotPanic(t)
+
+	configFile := `
+---
+grpcClient:
+  - name: ut-client
+    enabled: true
+    target: localhost:1950
+    authority: ut
+    userAgent: ut-agent
+    dialTimeoutMs: 1000
+    callOption:
+      waitForReady: true
+      maxCallRecvMsgSize: 1024
+      maxCallSendMsgSize: 1024
+  - name: ut-client-disabled
+    enabled: false
+    target: localhost:1951
+`
+
+	entries := RegisterGrpcClientEntryYAML([]byte(configFile))
+	assert.Len(t, entries, 1)
+
+	entry := entries["ut-client"].(*GrpcClientEntry)
+	assert.Equal(t, "localhost:1950", entry.Target)
+	assert.Len(t, entry.DialOpts, 2)
+	assert.Len(t, entry.CallOpts, 3)
+	assert.Equal(t, time.Second, entry.DialTimeout)
+	assert.Equal(t, entry, GetGrpcClientEntry("ut-client"))
+	assert.Nil(t, GetGrpcClientEntry("ut-client-disabled"))
+
+	rkentry.GlobalAppCtx.RemoveEntry(entry)
+}
+
+func TestRegisterGrpcClientEntryYAML_WithMiddleware(t *testing.T) {
+	defer assertNotPanic(t)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+	server := grpc.NewServer()
+	testdata.RegisterGreeterServer(server, &GreeterServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	grpcEntry := RegisterGrpcEntry(
+		WithName("ut-server"),
+		WithPromEntry(rkentry.RegisterPromEntry(&rkentry.BootProm{Enabled: true})))
+	defer rkentry.GlobalAppCtx.RemoveEntry(grpcEntry)
+
+	configFile := fmt.Sprintf(`
+---
+grpcClient:
+  - name: ut-client
+    enabled: true
+    target: %s
+    grpcEntry: ut-server
+    middleware:
+      logging:
+        enabled: true
+      prom:
+        enabled: true
+      propagation:
+        enabled: true
+        headers: ["x-tenant-id"]
+      trace:
+        enabled: true
+      retry:
+        enabled: true
+        policies:
+          - methods: ["/Greeter/*"]
+            maxAttempts: 3
+            initialBackoffMs: 10
+            codes: ["UNAVAILABLE"]
+      breaker:
+        enabled: true
+        consecutiveFailures: 3
+`, lis.Addr().String())
+
+	entry := RegisterGrpcClientEntryYAML([]byte(configFile))["ut-client"].(*GrpcClientEntry)
+	assert.Len(t, entry.UnaryInterceptors, 6)
+	assert.Len(t, entry.StreamInterceptors, 6)
+
+	entry.Bootstrap(context.TODO())
+	defer entry.Interrupt(context.TODO())
+
+	_, err = testdata.NewGreeterClient(entry.Conn()).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Nil(t, err)
+
+	// client metrics should be exposed by grpc entry
+	families, err := grpcEntry.gatherPromMetrics()
+	assert.Nil(t, err)
+	found, breakerFound := false, false
+	for _, family := range families {
+		if family.GetName() == "rk_grpc_client_breaker_state" {
+			breakerFound = true
+		}
+		if family.GetName() != "rk_prom_resCode" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "grpcType" && label.GetValue() == "UnaryClient" {
+					found = true
+				}
+			}
+		}
+	}
+	assert.True(t, found)
+	assert.True(t, breakerFound)
+
+	// calls rejected by open breaker are not retried
+	server.Stop()
+	for i := 0; i < 3; i++ {
+		_, err = testdata.NewGreeterClient(entry.Conn()).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+		assert.NotNil(t, err)
+	}
+	retried := sumClientRetry(t, grpcEntry)
+	assert.True(t, retried > 0)
+
+	_, err = testdata.NewGreeterClient(entry.Conn()).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, retried, sumClientRetry(t, grpcEntry))
+}
+
+// Sum of retried attempts of client exposed by grpc entry.
+func sumClientRetry(t *testing.T, grpcEntry *GrpcEntry) float64 {
+	families, err := grpcEntry.gatherPromMetrics()
+	assert.Nil(t, err)
+
+	res := 0.0
+	for _, family := range families {
+		if family.GetName() != "rk_grpc_client_retry" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			res += metric.GetCounter().GetValue()
+		}
+	}
+
+	return res
+}
+
+func TestRegisterGrpcClientEntryYAML_WithResolver(t *testing.T) {
+	defer assertNotPanic(t)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+	server := grpc.NewServer()
+	testdata.RegisterGreeterServer(server, &GreeterServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	configFile := fmt.Sprintf(`
+---
+grpcClient:
+  - name: ut-client
+    enabled: true
+    target: ignored
+    balancer: round_robin
+    resolver:
+      type: static
+      endpoints:
+        - address: %s
+          weight: 2
+`, lis.Addr().String())
+
+	entry := RegisterGrpcClientEntryYAML([]byte(configFile))["ut-client"].(*GrpcClientEntry)
+	assert.Equal(t, "rk-static:///ut-client", entry.Target)
+	assert.NotNil(t, entry.Resolver)
+	assert.Equal(t, "round_robin", entry.Balancer)
+
+	entry.Bootstrap(context.TODO())
+	defer entry.Interrupt(context.TODO())
+
+	resp, err := testdata.NewGreeterClient(entry.Conn()).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello rk!", resp.GetMessage())
+}
+
+func TestRegisterGrpcClientEntryYAML_WithInvalidResolver(t *testing.T) {
+	defer assertPanic(t)
+
+	configFile := `
+---
+grpcClient:
+  - name: ut-client
+    enabled: true
+    resolver:
+      type: unknown
+`
+
+	RegisterGrpcClientEntryYAML([]byte(configFile))
+}
+
+func TestRegisterGrpcClientEntryYAML_WithInvalidBalancer(t *testing.T) {
+	defer assertPanic(t)
+
+	configFile := `
+---
+grpcClient:
+  - name: ut-client
+    enabled: true
+    target: localhost:1950
+    balancer: unknown
+`
+
+	RegisterGrpcClientEntryYAML([]byte(configFile))
+}
+
+func TestRegisterGrpcClientEntryYAML_WithInvalidRetryCode(t *testing.T) {
+	defer assertPanic(t)
+
+	configFile := `
+---
+grpcClient:
+  - name: ut-client
+    enabled: true
+    target: localhost:1950
+    middleware:
+      retry:
+        enabled: true
+        policies:
+          - codes: ["UNAVAILABLE", "not-exist"]
+`
+
+	RegisterGrpcClientEntryYAML([]byte(configFile))
+}
+
+func TestRegisterGrpcClientEntry(t *testing.T) {
+	// without options
+	entry := RegisterGrpcClientEntry()
+	assert.Equal(t, "grpc-client-", entry.GetName())
+	assert.Equal(t, GrpcClientEntryType, entry.GetType())
+	assert.NotEmpty(t, entry.GetDescription())
+	assert.NotNil(t, entry.LoggerEntry)
+	assert.NotNil(t, entry.EventEntry)
+	assert.False(t, entry.IsTlsEnabled())
+	rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	certEntry := rkentry.RegisterCertEntry(&rkentry.BootCert{
+		Cert: []*rkentry.BootCertE{
+			{
+				Name: "ut-cert",
+			},
+		},
+	})[0]
+	certificate, _ := tls.X509KeyPair(generateCerts())
+	certEntry.Certificate = &certificate
+
+	// with options
+	entry = RegisterGrpcClientEntry(
+		WithNameClient("ut-client"),
+		WithDescriptionClient("desc"),
+		WithTargetClient("localhost:1950"),
+		WithLoggerEntryClient(rkentry.LoggerEntryNoop),
+		WithEventEntryClient(rkentry.EventEntryNoop),
+		WithCertEntryClient(certEntry),
+		WithBlockClient(true, time.Second),
+		WithDialOptionsClient(grpc.WithUserAgent("ut")),
+		WithCallOptionsClient(grpc.WaitForReady(true)),
+		WithUnaryInterceptorsClient(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}),
+		WithStreamInterceptorsClient(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}))
+
+	assert.Equal(t, "ut-client", entry.GetName())
+	assert.Equal(t, "desc", entry.GetDescription())
+	assert.Equal(t, "localhost:1950", entry.Target)
+	assert.True(t, entry.IsTlsEnabled())
+	assert.True(t, entry.Block)
+	assert.Len(t, entry.DialOpts, 1)
+	assert.Len(t, entry.CallOpts, 1)
+	assert.Len(t, entry.UnaryInterceptors, 1)
+	assert.Len(t, entry.StreamInterceptors, 1)
+	assert.NotEmpty(t, entry.String())
+	assert.Nil(t, entry.UnmarshalJSON([]byte{}))
+
+	rkentry.GlobalAppCtx.RemoveEntry(entry)
+	rkentry.GlobalAppCtx.RemoveEntry(certEntry)
+}
+
+func TestRegisterGrpcClientEntry_WithResolver(t *testing.T) {
+	entry := RegisterGrpcClientEntry(
+		WithResolverClient(rkgrpcresolver.NewStaticBuilder("ut", rkgrpcresolver.Endpoint{Address: "localhost:1950"})),
+		WithBalancerClient("weighted"))
+
+	assert.Equal(t, "rk-static:///ut", entry.Target)
+	assert.Equal(t, "grpc-client-rk-static:///ut", entry.GetName())
+	assert.Equal(t, "weighted", entry.Balancer)
+
+	rkentry.GlobalAppCtx.RemoveEntry(entry)
+}
+
+func TestGrpcClientEntry_PublicFunc(t *testing.T) {
+	entry := RegisterGrpcClientEntry()
+
+	entry.AddDialOptions(grpc.WithUserAgent("ut"))
+	entry.AddCallOptions(grpc.WaitForReady(true))
+	entry.AddUnaryInterceptors(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	})
+	entry.AddStreamInterceptors(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(ctx, desc, cc, method, opts...)
+	})
+
+	assert.NotEmpty(t, entry.DialOpts)
+	assert.NotEmpty(t, entry.CallOpts)
+	assert.NotEmpty(t, entry.UnaryInterceptors)
+	assert.NotEmpty(t, entry.StreamInterceptors)
+
+	rkentry.GlobalAppCtx.RemoveEntry(entry)
+}
+
+func TestGrpcClientEntry_EntryFunc(t *testing.T) {
+	defer assertNotPanic(t)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+	server := grpc.NewServer()
+	testdata.RegisterGreeterServer(server, &GreeterServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	entry := RegisterGrpcClientEntry(
+		WithNameClient("ut-client"),
+		WithTargetClient(lis.Addr().String()),
+		WithBlockClient(true, 3*time.Second))
+
+	entry.Bootstrap(context.TODO())
+
+	conn := entry.Conn()
+	assert.NotNil(t, conn)
+	// same connection should be returned
+	assert.Equal(t, conn, GetGrpcClientEntry("ut-client").Conn())
+
+	resp, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello rk!", resp.GetMessage())
+
+	entry.Interrupt(context.TODO())
+	assert.Nil(t, GetGrpcClientEntry("ut-client"))
+
+	// connection is not created again after interrupt
+	assert.Nil(t, entry.Conn())
+}
+
+func TestGrpcClientEntry_Conn_Concurrently(t *testing.T) {
+	lis, _ := net.Listen("tcp", "localhost:0")
+	addr := lis.Addr().String()
+	lis.Close()
+
+	entry := RegisterGrpcClientEntry(
+		WithNameClient("ut-client"),
+		WithTargetClient(addr),
+		WithBlockClient(true, 300*time.Millisecond))
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	// callers wait for the same dialing instead of dialing one by one
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, entry.Conn())
+		}()
+	}
+	wg.Wait()
+	assert.True(t, time.Since(start) < 600*time.Millisecond)
+
+	// caller is not blocked by dialing longer than its context
+	entry.Block, entry.DialTimeout = true, time.Minute
+	dialed := make(chan error)
+	go func() {
+		_, err := entry.dial(context.TODO())
+		dialed <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	_, err := entry.dial(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// dialing in progress is canceled by interrupt
+	entry.Interrupt(context.TODO())
+	select {
+	case err = <-dialed:
+		assert.Equal(t, errClientInterrupted, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "dialing is not canceled by interrupt")
+	}
+	_, err = entry.dial(context.TODO())
+	assert.Equal(t, errClientInterrupted, err)
+}
+
+func TestGrpcClientEntry_Bootstrap_Panic(t *testing.T) {
+	defer assertPanic(t)
+
+	lis, _ := net.Listen("tcp", "localhost:0")
+	addr := lis.Addr().String()
+	lis.Close()
+
+	entry := RegisterGrpcClientEntry(
+		WithNameClient("ut-client"),
+		WithTargetClient(addr),
+		WithBlockClient(true, 100*time.Millisecond))
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	entry.Bootstrap(context.TODO())
+}
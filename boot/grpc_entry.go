@@ -9,12 +9,14 @@ package rkgrpc
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/rookie-ninja/rk-entry/v2/entry"
 	"github.com/rookie-ninja/rk-entry/v2/error"
 	"github.com/rookie-ninja/rk-entry/v2/middleware"
@@ -30,18 +32,11 @@ import (
 	"github.com/rookie-ninja/rk-entry/v2/middleware/secure"
 	"github.com/rookie-ninja/rk-entry/v2/middleware/timeout"
 	"github.com/rookie-ninja/rk-entry/v2/middleware/tracing"
-	"github.com/rookie-ninja/rk-grpc/v2/middleware/auth"
-	"github.com/rookie-ninja/rk-grpc/v2/middleware/cors"
 	"github.com/rookie-ninja/rk-grpc/v2/middleware/csrf"
-	"github.com/rookie-ninja/rk-grpc/v2/middleware/jwt"
-	"github.com/rookie-ninja/rk-grpc/v2/middleware/log"
 	"github.com/rookie-ninja/rk-grpc/v2/middleware/meta"
 	"github.com/rookie-ninja/rk-grpc/v2/middleware/panic"
 	"github.com/rookie-ninja/rk-grpc/v2/middleware/prom"
-	"github.com/rookie-ninja/rk-grpc/v2/middleware/ratelimit"
 	"github.com/rookie-ninja/rk-grpc/v2/middleware/secure"
-	"github.com/rookie-ninja/rk-grpc/v2/middleware/timeout"
-	"github.com/rookie-ninja/rk-grpc/v2/middleware/tracing"
 	"github.com/rookie-ninja/rk-query"
 	"github.com/soheilhy/cmux"
 	"go.uber.org/zap"
@@ -49,6 +44,8 @@ import (
 	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"math"
 	"net"
@@ -58,12 +55,15 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // This must be declared in order to register registration function into rk context
 // otherwise, rk-boot won't able to bootstrap grpc entry automatically from boot config file
 func init() {
 	rkentry.RegisterWebFrameRegFunc(RegisterGrpcEntryYAML)
+	rkentry.RegisterWebFrameRegFunc(RegisterGrpcClientEntryYAML)
 }
 
 const (
@@ -81,6 +81,8 @@ type BootConfig struct {
 		Enabled            bool                          `yaml:"enabled" json:"enabled"`
 		EnableReflection   bool                          `yaml:"enableReflection" json:"enableReflection"`
 		NoRecvMsgSizeLimit bool                          `yaml:"noRecvMsgSizeLimit" json:"noRecvMsgSizeLimit"`
+		ServerOption       BootConfigServerOption        `yaml:"serverOption" json:"serverOption"`
+		HttpServer         BootConfigHttpServer          `yaml:"httpServer" json:"httpServer"`
 		CommonService      rkentry.BootCommonService     `yaml:"commonService" json:"commonService"`
 		SW                 rkentry.BootSW                `yaml:"sw" json:"sw"`
 		Docs               rkentry.BootDocs              `yaml:"docs" json:"docs"`
@@ -89,25 +91,32 @@ type BootConfig struct {
 		Proxy              BootConfigProxy               `yaml:"proxy" json:"proxy"`
 		GrpcWeb            BootConfigGrpcWeb             `yaml:"grpcWeb" json:"grpcWeb"`
 		CertEntry          string                        `yaml:"certEntry" json:"certEntry"`
+		Mtls               BootConfigMtls                `yaml:"mtls" json:"mtls"`
+		CertReload         BootConfigCertReload          `yaml:"certReload" json:"certReload"`
+		Reload             BootConfigReload              `yaml:"reload" json:"reload"`
+		Shutdown           BootConfigShutdown            `yaml:"shutdown" json:"shutdown"`
+		Listen             BootConfigListen              `yaml:"listen" json:"listen"`
 		LoggerEntry        string                        `yaml:"loggerEntry" json:"loggerEntry"`
 		EventEntry         string                        `yaml:"eventEntry" json:"eventEntry"`
 		PProf              rkentry.BootPProf             `yaml:"pprof" json:"pprof"`
 		EnableRkGwOption   bool                          `yaml:"enableRkGwOption" json:"enableRkGwOption"`
 		GwOption           *gwOption                     `yaml:"gwOption" json:"gwOption"`
 		Middleware         struct {
-			Ignore     []string                `yaml:"ignore" json:"ignore"`
-			ErrorModel string                  `yaml:"errorModel" json:"errorModel"`
-			Logging    rkmidlog.BootConfig     `yaml:"logging" json:"logging"`
-			Prom       rkmidprom.BootConfig    `yaml:"prom" json:"prom"`
-			Auth       rkmidauth.BootConfig    `yaml:"auth" json:"auth"`
-			Cors       rkmidcors.BootConfig    `yaml:"cors" json:"cors"`
-			Secure     rkmidsec.BootConfig     `yaml:"secure" json:"secure"`
-			Meta       rkmidmeta.BootConfig    `yaml:"meta" json:"meta"`
-			Jwt        rkmidjwt.BootConfig     `yaml:"jwt" json:"jwt"`
-			Csrf       rkmidcsrf.BootConfig    `yaml:"csrf" yaml:"csrf"`
-			RateLimit  rkmidlimit.BootConfig   `yaml:"rateLimit" json:"rateLimit"`
-			Timeout    rkmidtimeout.BootConfig `yaml:"timeout" json:"timeout"`
-			Trace      rkmidtrace.BootConfig   `yaml:"trace" json:"trace"`
+			Ignore     []string                 `yaml:"ignore" json:"ignore"`
+			Order      []string                 `yaml:"order" json:"order"`
+			ErrorModel string                   `yaml:"errorModel" json:"errorModel"`
+			Logging    rkmidlog.BootConfig      `yaml:"logging" json:"logging"`
+			Prom       rkmidprom.BootConfig     `yaml:"prom" json:"prom"`
+			Auth       rkmidauth.BootConfig     `yaml:"auth" json:"auth"`
+			Cors       rkmidcors.BootConfig     `yaml:"cors" json:"cors"`
+			Secure     rkmidsec.BootConfig      `yaml:"secure" json:"secure"`
+			Meta       rkmidmeta.BootConfig     `yaml:"meta" json:"meta"`
+			Jwt        rkmidjwt.BootConfig      `yaml:"jwt" json:"jwt"`
+			Csrf       rkmidcsrf.BootConfig     `yaml:"csrf" yaml:"csrf"`
+			RateLimit  rkmidlimit.BootConfig    `yaml:"rateLimit" json:"rateLimit"`
+			Timeout    rkmidtimeout.BootConfig  `yaml:"timeout" json:"timeout"`
+			Trace      rkmidtrace.BootConfig    `yaml:"trace" json:"trace"`
+			Policies   []BootConfigMethodPolicy `yaml:"policies" json:"policies"`
 		} `yaml:"middleware" json:"middleware"`
 	} `yaml:"grpc" json:"grpc"`
 }
@@ -121,8 +130,29 @@ type GrpcEntry struct {
 	EventEntry        *rkentry.EventEntry  `json:"-" yaml:"-"`
 	Port              uint64               `json:"-" yaml:"-"`
 	GwPort            uint64               `json:"-" yaml:"-"`
+	GrpcListen        ListenConfig         `json:"-" yaml:"-"`
+	GwListen          ListenConfig         `json:"-" yaml:"-"`
+	grpcLis           net.Listener         `json:"-" yaml:"-"`
+	gwLis             net.Listener         `json:"-" yaml:"-"`
+	customLis         net.Listener         `json:"-" yaml:"-"`
+	lisClosed         int32                `json:"-" yaml:"-"`
+	returnServeErr    bool                 `json:"-" yaml:"-"`
+	serveErr          error                `json:"-" yaml:"-"`
+	serveErrLock      sync.Mutex           `json:"-" yaml:"-"`
 	TlsConfig         *tls.Config          `json:"-" yaml:"-"`
 	TlsConfigInsecure *tls.Config          `json:"-" yaml:"-"`
+	ClientAuth        tls.ClientAuthType   `json:"-" yaml:"-"`
+	ClientCAs         *x509.CertPool       `json:"-" yaml:"-"`
+	CertReloader      *CertReloader        `json:"-" yaml:"-"`
+	certReload        bool                 `json:"-" yaml:"-"`
+	certReloadCA      *rkentry.CertEntry   `json:"-" yaml:"-"`
+	certReloadTick    time.Duration        `json:"-" yaml:"-"`
+	ConfigReloader    *ConfigReloader      `json:"-" yaml:"-"`
+	configReloadPath  string               `json:"-" yaml:"-"`
+	configReloadTick  time.Duration        `json:"-" yaml:"-"`
+	reloadEndpoint    bool                 `json:"-" yaml:"-"`
+	reloadable        *reloadableConfig    `json:"-" yaml:"-"`
+	reloadLock        sync.Mutex           `json:"-" yaml:"-"`
 	// GRPC related
 	Server             *grpc.Server                   `json:"-" yaml:"-"`
 	ServerOpts         []grpc.ServerOption            `json:"-" yaml:"-"`
@@ -130,16 +160,24 @@ type GrpcEntry struct {
 	StreamInterceptors []grpc.StreamServerInterceptor `json:"-" yaml:"-"`
 	GrpcRegF           []GrpcRegFunc                  `json:"-" yaml:"-"`
 	EnableReflection   bool                           `json:"-" yaml:"-"`
+	HealthServer       *health.Server                 `json:"-" yaml:"-"`
+	PreStopDelay       time.Duration                  `json:"-" yaml:"-"`
+	DrainTimeout       time.Duration                  `json:"-" yaml:"-"`
+	inFlight           *inFlightHandler               `json:"-" yaml:"-"`
+	MethodOptions      *MethodOptionsResolver         `json:"-" yaml:"-"`
+	methodPolicies     *methodPolicies                `json:"-" yaml:"-"`
 	// grpcWeb related
 	GrpcWebOptions []grpcweb.Option `json:"-" yaml:"-"`
 	// Gateway related
 	HttpMux         *http.ServeMux             `json:"-" yaml:"-"`
 	HttpServer      *http.Server               `json:"-" yaml:"-"`
+	HttpServerOpts  []HttpServerOption         `json:"-" yaml:"-"`
 	GwMux           *gwruntime.ServeMux        `json:"-" yaml:"-"`
 	GwMuxOptions    []gwruntime.ServeMuxOption `json:"-" yaml:"-"`
 	GwRegF          []GwRegFunc                `json:"-" yaml:"-"`
 	GwDialOptions   []grpc.DialOption          `json:"-" yaml:"-"`
 	gwCorsOptions   []rkmidcors.Option         `json:"-" yaml:"-"`
+	gwCors          *gwCorsHandler             `json:"-" yaml:"-"`
 	gwSecureOptions []rkmidsec.Option          `json:"-" yaml:"-"`
 	gwCsrfOptions   []rkmidcsrf.Option         `json:"-" yaml:"-"`
 	// Utility related
@@ -152,6 +190,8 @@ type GrpcEntry struct {
 	PProfEntry         *rkentry.PProfEntry             `json:"-" yaml:"-"`
 	CertEntry          *rkentry.CertEntry              `json:"-" yaml:"-"`
 	bootstrapLogOnce   sync.Once                       `json:"-" yaml:"-"`
+	promGatherers      []prometheus.Gatherer           `json:"-" yaml:"-"`
+	promGatherersLock  sync.Mutex                      `json:"-" yaml:"-"`
 }
 
 // RegisterGrpcEntryYAML Register grpc entries with provided config file (Must YAML file).
@@ -178,12 +218,115 @@ func RegisterGrpcEntryYAML(raw []byte) map[string]rkentry.Entry {
 	// 1: decode config map into boot config struct
 	config := &BootConfig{}
 	rkentry.UnmarshalBootYAML(raw, config)
+	enableMethodPolicies(raw, config)
+
+	// 2: register entries
+	entries, err := registerGrpcEntries(config)
+	if err != nil {
+		rkentry.ShutdownWithError(err)
+	}
+
+	for k, v := range entries {
+		res[k] = v
+	}
+
+	return res
+}
+
+// NewGrpcEntriesFromYAML Register grpc entries with provided config file (Must YAML file) and return errors instead of
+// shutting down process.
+//
+// Config is validated with ValidateBootConfig before any entries registered, all problems found are returned
+// as one error combined with multierr.
+func NewGrpcEntriesFromYAML(raw []byte) (map[string]*GrpcEntry, error) {
+	config := &BootConfig{}
+	if err := unmarshalBootYAML(raw, config); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateBootConfig(config); err != nil {
+		return nil, err
+	}
+
+	return registerGrpcEntries(config)
+}
+
+// grpcEntryBuild is options of grpc entry which are able to fail, built before any entries registered.
+type grpcEntryBuild struct {
+	mtlsOpt    GrpcEntryOption
+	grpcListen ListenConfig
+	gwListen   ListenConfig
+	order      []string
+	proxyRule  *rule
+}
+
+// Build options of enabled grpc entries which are able to fail, the first error is returned.
+func buildGrpcEntries(config *BootConfig) (map[int]*grpcEntryBuild, error) {
+	res := make(map[int]*grpcEntryBuild)
+
+	for i := range config.Grpc {
+		element := config.Grpc[i]
+		if !element.Enabled {
+			continue
+		}
+
+		build := &grpcEntryBuild{
+			mtlsOpt: WithMtls(tls.NoClientCert, nil),
+		}
+
+		// mutual TLS
+		if element.Mtls.Enabled {
+			clientAuth, clientCAs, err := toMtlsOption(&element.Mtls, rkentry.GlobalAppCtx.GetCertEntry(element.CertEntry))
+			if err != nil {
+				return nil, err
+			}
+			build.mtlsOpt = WithMtls(clientAuth, clientCAs)
+		}
+
+		// listeners of grpc and gateway
+		var err error
+		if build.grpcListen, err = ToListenConfig(&element.Listen.Grpc); err != nil {
+			return nil, err
+		}
+		if build.gwListen, err = ToListenConfig(&element.Listen.Gateway); err != nil {
+			return nil, err
+		}
+
+		// order of interceptors
+		if build.order, err = resolveInterceptorOrder(element.Middleware.Order); err != nil {
+			return nil, err
+		}
+
+		// rule of proxy
+		if element.Proxy.Enabled {
+			if build.proxyRule, err = toProxyRule(&element.Proxy); err != nil {
+				return nil, err
+			}
+		}
+
+		res[i] = build
+	}
+
+	return res, nil
+}
+
+// Register enabled grpc entries in boot config, the first error is returned.
+//
+// Options which are able to fail are built before any entries registered, nothing is registered if error returned.
+func registerGrpcEntries(config *BootConfig) (map[string]*GrpcEntry, error) {
+	res := make(map[string]*GrpcEntry)
+
+	builds, err := buildGrpcEntries(config)
+	if err != nil {
+		return nil, err
+	}
 
 	for i := range config.Grpc {
 		element := config.Grpc[i]
 		if !element.Enabled {
 			continue
 		}
+		build := builds[i]
 
 		// logger entry
 		loggerEntry := rkentry.GlobalAppCtx.GetLoggerEntry(element.LoggerEntry)
@@ -200,6 +343,23 @@ func RegisterGrpcEntryYAML(raw []byte) map[string]rkentry.Entry {
 		// cert entry
 		certEntry := rkentry.GlobalAppCtx.GetCertEntry(element.CertEntry)
 
+		// certificate reloading, CA bundle is read from cert entry of mtls if provided
+		certReloadOpt := WithCertReload(false, 0, nil)
+		if element.CertReload.Enabled {
+			var caEntry *rkentry.CertEntry
+			if element.Mtls.Enabled && len(element.Mtls.CertEntry) > 0 {
+				caEntry = rkentry.GlobalAppCtx.GetCertEntry(element.Mtls.CertEntry)
+			}
+			certReloadOpt = WithCertReload(true, time.Duration(element.CertReload.IntervalMs)*time.Millisecond, caEntry)
+		}
+
+		// config reloading, boot file is watched and reloaded once modified
+		configReloadOpt := WithConfigReload("", 0)
+		if element.Reload.Enabled {
+			configReloadOpt = WithConfigReload(element.Reload.Path, time.Duration(element.Reload.IntervalMs)*time.Millisecond)
+		}
+		configReloadEndpointOpt := WithConfigReloadEndpoint(element.Reload.Enabled && element.Reload.Endpoint)
+
 		// Register swagger entry
 		swEntry := rkentry.RegisterSWEntry(&element.SW, rkentry.WithNameSWEntry(element.Name))
 
@@ -222,44 +382,25 @@ func RegisterGrpcEntryYAML(raw []byte) map[string]rkentry.Entry {
 		// Did we enable proxy?
 		var proxy *ProxyEntry
 		if element.Proxy.Enabled {
-			opts := make([]ruleOption, 0)
-			for i := range element.Proxy.Rules {
-				rule := element.Proxy.Rules[i]
-				switch rule.Type {
-				case HeaderBased:
-					headers := make(map[string]string, 0)
-
-					for i := range rule.HeaderPairs {
-						tokens := strings.SplitN(rule.HeaderPairs[i], ":", 2)
-						if len(tokens) != 2 {
-							continue
-						}
-						headers[tokens[0]] = tokens[1]
-					}
-
-					opts = append(opts, WithHeaderPatterns(&HeaderPattern{
-						Headers: headers,
-						Dest:    rule.Dest,
-					}))
-
-				case PathBased:
-					opts = append(opts, WithPathPatterns(&PathPattern{
-						Paths: rule.Paths,
-						Dest:  rule.Dest,
-					}))
-				case IpBased:
-					opts = append(opts, WithIpPatterns(&IpPattern{
-						Cidrs: rule.Ips,
-						Dest:  rule.Dest,
-					}))
-				}
-			}
-
-			proxy = NewProxyEntry(
+			proxyOpts := []ProxyEntryOption{
 				WithNameProxy(element.Name),
 				WithEventEntryProxy(eventEntry),
 				WithLoggerEntryProxy(loggerEntry),
-				WithRuleProxy(NewRule(opts...)))
+				WithRuleProxy(build.proxyRule),
+				WithPoolProxy(element.Proxy.Pool.MaxConns,
+					time.Duration(element.Proxy.Pool.IdleTimeoutMs)*time.Millisecond),
+				WithPromRegistryProxy(promRegistry),
+			}
+			if element.Proxy.HealthCheck.Enabled {
+				proxyOpts = append(proxyOpts, WithHealthCheckProxy(element.Proxy.HealthCheck.Type,
+					time.Duration(element.Proxy.HealthCheck.IntervalMs)*time.Millisecond,
+					time.Duration(element.Proxy.HealthCheck.TimeoutMs)*time.Millisecond))
+			}
+			if element.Proxy.Outlier.Enabled {
+				proxyOpts = append(proxyOpts, WithOutlierProxy(element.Proxy.Outlier.ConsecutiveErrors,
+					time.Duration(element.Proxy.Outlier.EjectionMs)*time.Millisecond))
+			}
+			proxy = NewProxyEntry(proxyOpts...)
 		}
 
 		var grpcDialOptions = make([]grpc.DialOption, 0)
@@ -288,6 +429,7 @@ func RegisterGrpcEntryYAML(raw []byte) map[string]rkentry.Entry {
 			WithEventEntry(eventEntry),
 			WithPort(element.Port),
 			WithGwPort(element.GwPort),
+			WithListen(build.grpcListen, build.gwListen),
 			WithGrpcDialOptions(grpcDialOptions...),
 			WithSwEntry(swEntry),
 			WithDocsEntry(docsEntry),
@@ -300,7 +442,19 @@ func RegisterGrpcEntryYAML(raw []byte) map[string]rkentry.Entry {
 			WithCertEntry(certEntry),
 			WithPProfEntry(pprofEntry),
 			WithEnableReflection(element.EnableReflection),
-			WithCertEntry(rkentry.GlobalAppCtx.GetCertEntry(element.CertEntry)))
+			WithCertEntry(rkentry.GlobalAppCtx.GetCertEntry(element.CertEntry)),
+			WithServerOptions(ToServerOptions(&element.ServerOption)...),
+			WithHttpServerOptions(ToHttpServerOptions(&element.HttpServer)...),
+			WithShutdown(
+				time.Duration(element.Shutdown.PreStopDelayMs)*time.Millisecond,
+				time.Duration(element.Shutdown.DrainTimeoutMs)*time.Millisecond),
+			build.mtlsOpt,
+			certReloadOpt,
+			configReloadOpt,
+			configReloadEndpointOpt)
+
+		// Let grpc-gateway send and receive messages allowed by grpc server
+		entry.GwDialOptions = append(entry.GwDialOptions, ToGwDialOptions(&element.ServerOption)...)
 
 		// Did we disable message size for receiving?
 		if element.NoRecvMsgSizeLimit {
@@ -321,40 +475,40 @@ func RegisterGrpcEntryYAML(raw []byte) map[string]rkentry.Entry {
 			rkmid.SetErrorBuilder(rkerror.NewErrorBuilderAMZN())
 		}
 
+		// middlewares which are able to be overridden for methods with policies and rk.api.v1.method options
+		entry.reloadable = toReloadableConfig(config, i)
+		entry.methodPolicies = newMethodPolicies(entry.reloadable.toMethodPolicy(), element.Name, loggerEntry, eventEntry)
+		policies := entry.methodPolicies
+
+		// built-in interceptors keyed with lower cased names, nil if disabled
+		builtin := make(map[string]*namedInterceptor)
+
 		// logging middleware
-		if element.Middleware.Logging.Enabled {
-			entry.AddUnaryInterceptors(rkgrpclog.UnaryServerInterceptor(
-				rkmidlog.ToOptions(&element.Middleware.Logging, element.Name, GrpcEntryType,
-					loggerEntry, eventEntry)...))
-			entry.AddStreamInterceptors(rkgrpclog.StreamServerInterceptor(
-				rkmidlog.ToOptions(&element.Middleware.Logging, element.Name, GrpcEntryType,
-					loggerEntry, eventEntry)...))
-		}
+		builtin[InterceptorLogging] = policies.interceptors(InterceptorLogging)
 
 		// Default middleware should be placed after logging middleware, we should make sure interceptors never panic
 		// insert panic interceptor
-		entry.UnaryInterceptors = append(entry.UnaryInterceptors, rkgrpcpanic.UnaryServerInterceptor(
-			rkmidpanic.WithEntryNameAndType(entry.entryName, entry.entryType)))
-		entry.StreamInterceptors = append(entry.StreamInterceptors, rkgrpcpanic.StreamServerInterceptor(
-			rkmidpanic.WithEntryNameAndType(entry.entryName, entry.entryType)))
+		builtin[InterceptorPanic] = &namedInterceptor{
+			unary: rkgrpcpanic.UnaryServerInterceptor(
+				rkmidpanic.WithEntryNameAndType(entry.entryName, entry.entryType)),
+			stream: rkgrpcpanic.StreamServerInterceptor(
+				rkmidpanic.WithEntryNameAndType(entry.entryName, entry.entryType)),
+		}
 
 		// did we enable metrics interceptor?
 		if element.Middleware.Prom.Enabled {
-			entry.AddUnaryInterceptors(rkgrpcprom.UnaryServerInterceptor(
-				rkmidprom.ToOptions(&element.Middleware.Prom, element.Name, GrpcEntryType,
-					promRegistry, rkmidprom.LabelerTypeGrpc)...))
-			entry.AddStreamInterceptors(rkgrpcprom.StreamServerInterceptor(
-				rkmidprom.ToOptions(&element.Middleware.Prom, element.Name, GrpcEntryType,
-					promRegistry, rkmidprom.LabelerTypeGrpc)...))
+			builtin[InterceptorProm] = &namedInterceptor{
+				unary: rkgrpcprom.UnaryServerInterceptor(
+					rkmidprom.ToOptions(&element.Middleware.Prom, element.Name, GrpcEntryType,
+						promRegistry, rkmidprom.LabelerTypeGrpc)...),
+				stream: rkgrpcprom.StreamServerInterceptor(
+					rkmidprom.ToOptions(&element.Middleware.Prom, element.Name, GrpcEntryType,
+						promRegistry, rkmidprom.LabelerTypeGrpc)...),
+			}
 		}
 
 		// trace middleware
-		if element.Middleware.Trace.Enabled {
-			entry.AddUnaryInterceptors(rkgrpctrace.UnaryServerInterceptor(
-				rkmidtrace.ToOptions(&element.Middleware.Trace, element.Name, GrpcEntryType)...))
-			entry.AddStreamInterceptors(rkgrpctrace.StreamServerInterceptor(
-				rkmidtrace.ToOptions(&element.Middleware.Trace, element.Name, GrpcEntryType)...))
-		}
+		builtin[InterceptorTrace] = policies.interceptors(InterceptorTrace)
 
 		// cors middleware
 		if element.Middleware.Cors.Enabled {
@@ -363,12 +517,7 @@ func RegisterGrpcEntryYAML(raw []byte) map[string]rkentry.Entry {
 		}
 
 		// jwt middleware
-		if element.Middleware.Jwt.Enabled {
-			entry.AddUnaryInterceptors(rkgrpcjwt.UnaryServerInterceptor(
-				rkmidjwt.ToOptions(&element.Middleware.Jwt, element.Name, GrpcEntryType)...))
-			entry.AddStreamInterceptors(rkgrpcjwt.StreamServerInterceptor(
-				rkmidjwt.ToOptions(&element.Middleware.Jwt, element.Name, GrpcEntryType)...))
-		}
+		builtin[InterceptorJwt] = policies.interceptors(InterceptorJwt)
 
 		// secure middleware
 		if element.Middleware.Secure.Enabled {
@@ -384,41 +533,37 @@ func RegisterGrpcEntryYAML(raw []byte) map[string]rkentry.Entry {
 
 		// meta middleware
 		if element.Middleware.Meta.Enabled {
-			entry.AddUnaryInterceptors(rkgrpcmeta.UnaryServerInterceptor(
-				rkmidmeta.ToOptions(&element.Middleware.Meta, element.Name, GrpcEntryType)...))
-			entry.AddStreamInterceptors(rkgrpcmeta.StreamServerInterceptor(
-				rkmidmeta.ToOptions(&element.Middleware.Meta, element.Name, GrpcEntryType)...))
+			builtin[InterceptorMeta] = &namedInterceptor{
+				unary: rkgrpcmeta.UnaryServerInterceptor(
+					rkmidmeta.ToOptions(&element.Middleware.Meta, element.Name, GrpcEntryType)...),
+				stream: rkgrpcmeta.StreamServerInterceptor(
+					rkmidmeta.ToOptions(&element.Middleware.Meta, element.Name, GrpcEntryType)...),
+			}
 		}
 
 		// auth middleware
-		if element.Middleware.Auth.Enabled {
-			entry.AddUnaryInterceptors(rkgrpcauth.UnaryServerInterceptor(
-				rkmidauth.ToOptions(&element.Middleware.Auth, element.Name, GrpcEntryType)...))
-			entry.AddStreamInterceptors(rkgrpcauth.StreamServerInterceptor(
-				rkmidauth.ToOptions(&element.Middleware.Auth, element.Name, GrpcEntryType)...))
-		}
+		builtin[InterceptorAuth] = policies.interceptors(InterceptorAuth)
 
 		// timeout middleware
-		if element.Middleware.Timeout.Enabled {
-			entry.AddUnaryInterceptors(rkgrpctimeout.UnaryServerInterceptor(
-				rkmidtimeout.ToOptions(&element.Middleware.Timeout, element.Name, GrpcEntryType)...))
-			entry.AddStreamInterceptors(rkgrpctimeout.StreamServerInterceptor(
-				rkmidtimeout.ToOptions(&element.Middleware.Timeout, element.Name, GrpcEntryType)...))
-		}
+		builtin[InterceptorTimeout] = policies.interceptors(InterceptorTimeout)
 
 		// ratelimit middleware
-		if element.Middleware.RateLimit.Enabled {
-			entry.AddUnaryInterceptors(rkgrpclimit.UnaryServerInterceptor(
-				rkmidlimit.ToOptions(&element.Middleware.RateLimit, element.Name, GrpcEntryType)...))
-			entry.AddStreamInterceptors(rkgrpclimit.StreamServerInterceptor(
-				rkmidlimit.ToOptions(&element.Middleware.RateLimit, element.Name, GrpcEntryType)...))
-		}
+		builtin[strings.ToLower(InterceptorRateLimit)] = policies.interceptors(InterceptorRateLimit)
+
+		// add interceptors in order of middleware.order
+		entry.addInterceptorsInOrder(build.order, builtin)
+
+		policies.add(element.Middleware.Policies...)
 
 		res[element.Name] = entry
 	}
-	return res
+
+	return res, nil
 }
 
+// ephemeralEntrySeq is sequence of default names of entries with port 0.
+var ephemeralEntrySeq uint64
+
 // RegisterGrpcEntry Register GrpcEntry with options.
 func RegisterGrpcEntry(opts ...GrpcEntryOption) *GrpcEntry {
 	entry := &GrpcEntry{
@@ -433,6 +578,7 @@ func RegisterGrpcEntry(opts ...GrpcEntryOption) *GrpcEntry {
 		StreamInterceptors: make([]grpc.StreamServerInterceptor, 0),
 		GrpcRegF:           make([]GrpcRegFunc, 0),
 		EnableReflection:   true,
+		inFlight:           &inFlightHandler{},
 		// grpc-gateway related
 		GwMuxOptions:    make([]gwruntime.ServeMuxOption, 0),
 		GwRegF:          make([]GwRegFunc, 0),
@@ -447,20 +593,55 @@ func RegisterGrpcEntry(opts ...GrpcEntryOption) *GrpcEntry {
 		opts[i](entry)
 	}
 
+	// port 0 is assigned while listening, which is after entry is registered with name, so that sequence is used
+	// in default name instead
 	if len(entry.entryName) < 1 {
 		entry.entryName = "grpc-" + strconv.FormatUint(entry.Port, 10)
+		if entry.Port == 0 {
+			entry.entryName += "-" + strconv.FormatUint(atomic.AddUint64(&ephemeralEntrySeq, 1), 10)
+		}
+	}
+
+	// gateway listener follows grpc listener if missing
+	if len(entry.GrpcListen.Network) < 1 {
+		entry.GrpcListen.Network = NetworkTcp4
+	}
+	if entry.GwListen == (ListenConfig{}) {
+		entry.GwListen = entry.GrpcListen
 	}
+	if len(entry.GwListen.Network) < 1 {
+		entry.GwListen.Network = NetworkTcp4
+	}
+
+	// grpc.health.v1.Health service is served with or without common service, so that entry is marked as
+	// NOT_SERVING while shutting down
+	entry.HealthServer = health.NewServer()
 
 	// Init TLS config
 	if entry.IsTlsEnabled() {
 		entry.TlsConfig = &tls.Config{
 			InsecureSkipVerify: true,
 			Certificates:       []tls.Certificate{*entry.CertEntry.Certificate},
+			ClientAuth:         entry.ClientAuth,
+			ClientCAs:          entry.ClientCAs,
 		}
 		entry.TlsConfigInsecure = &tls.Config{
 			InsecureSkipVerify: true,
 			Certificates:       []tls.Certificate{*entry.CertEntry.Certificate},
 		}
+
+		// serve certificates from reloader, so that rotated certificates are used without restarting
+		if entry.certReload {
+			entry.CertReloader = NewCertReloader(entry.CertEntry, entry.certReloadCA, entry.certReloadTick, entry.LoggerEntry, entry.EventEntry)
+			entry.TlsConfig.GetCertificate = entry.CertReloader.GetCertificate
+			entry.TlsConfig.GetConfigForClient = entry.CertReloader.GetConfigForClient(entry.TlsConfig)
+			entry.TlsConfigInsecure.GetClientCertificate = entry.CertReloader.GetClientCertificate
+		}
+	}
+
+	// watch boot file and reload middleware config once modified
+	if len(entry.configReloadPath) > 0 {
+		entry.ConfigReloader = NewConfigReloader(entry.configReloadPath, entry.configReloadTick, entry.ReloadYAML, entry.LoggerEntry)
 	}
 
 	// add entry name and entry type into loki syncer if enabled
@@ -496,23 +677,51 @@ func (entry *GrpcEntry) GetDescription() string {
 }
 
 // Bootstrap GrpcEntry.
+//
+// Process will shutdown with rkentry.ShutdownWithError function if any errors occur while bootstrapping or serving,
+// use BootstrapE in order to handle errors.
 func (entry *GrpcEntry) Bootstrap(ctx context.Context) {
+	if err := entry.bootstrap(ctx); err != nil {
+		rkentry.ShutdownWithError(err)
+	}
+}
+
+// BootstrapE Bootstrap GrpcEntry and return error instead of shutting down process.
+//
+// Listeners are closed if error returned. Errors occur while serving afterwards are logged and available from ServeErr.
+func (entry *GrpcEntry) BootstrapE(ctx context.Context) error {
+	entry.returnServeErr = true
+	return entry.bootstrap(ctx)
+}
+
+func (entry *GrpcEntry) bootstrap(ctx context.Context) error {
 	event, logger := entry.logBasicInfo("Bootstrap", ctx)
 
 	// 1: Create grpc server
 	// 1.1: Make unary and stream interceptors into server opts
 	// Important! Do not add tls as options since we already enable tls in listener
+	if entry.IsTlsEnabled() {
+		// expose TLS state of listener to grpc, and inject verified peer identity before other interceptors
+		entry.ServerOpts = append(entry.ServerOpts, grpc.Creds(newListenerCreds()))
+		entry.UnaryInterceptors = append([]grpc.UnaryServerInterceptor{peerIdentityUnaryInterceptor}, entry.UnaryInterceptors...)
+		entry.StreamInterceptors = append([]grpc.StreamServerInterceptor{peerIdentityStreamInterceptor}, entry.StreamInterceptors...)
+	}
+
 	entry.ServerOpts = append(entry.ServerOpts,
 		grpc.ChainUnaryInterceptor(entry.UnaryInterceptors...),
 		grpc.ChainStreamInterceptor(entry.StreamInterceptors...))
 
+	// 1.2: Count in-flight RPCs which would be reported while draining
+	if entry.inFlight != nil {
+		entry.ServerOpts = append(entry.ServerOpts, grpc.StatsHandler(entry.inFlight))
+	}
+
 	// 2: Add proxy entry
 	if entry.IsProxyEnabled() {
 		entry.ServerOpts = append(entry.ServerOpts,
 			grpc.ForceServerCodec(Codec()),
-			grpc.UnknownServiceHandler(TransparentHandler(entry.ProxyEntry.r.GetDirector())),
+			grpc.UnknownServiceHandler(TransparentHandler(entry.ProxyEntry.GetDirector())),
 		)
-		entry.ProxyEntry.Bootstrap(ctx)
 	}
 
 	// 3: Create grpc server
@@ -523,11 +732,24 @@ func (entry *GrpcEntry) Bootstrap(ctx context.Context) {
 		regFunc(entry.Server)
 	}
 
+	// 4.1: Read rk.api.v1.method options of registered methods, which override middlewares from boot config
+	entry.MethodOptions = NewMethodOptionsResolver(entry.Server)
+	entry.applyMethodOptions(entry.MethodOptions)
+
 	// 5: Enable grpc reflection
 	if entry.EnableReflection {
 		reflection.Register(entry.Server)
 	}
 
+	// 5.1: Register grpc.health.v1.Health service unless it is registered by user, registered services are SERVING
+	// unless status was set before
+	if entry.HealthServer != nil {
+		if _, ok := entry.Server.GetServiceInfo()[healthpb.Health_ServiceDesc.ServiceName]; !ok {
+			healthpb.RegisterHealthServer(entry.Server, entry.HealthServer)
+		}
+		entry.initServingStatus()
+	}
+
 	// 6: Create http server based on grpc gateway
 	// 6.1: Create gateway mux
 	entry.GwMux = gwruntime.NewServeMux(entry.GwMuxOptions...)
@@ -540,15 +762,39 @@ func (entry *GrpcEntry) Bootstrap(ctx context.Context) {
 		entry.GwDialOptions = append(entry.GwDialOptions, grpc.WithInsecure())
 	}
 
+	// 7.1: Create listeners before registering gateway, so that errors are reported synchronously and
+	// gateway dials to the bound port while port is 0
+	if err := entry.listen(); err != nil {
+		entry.EventEntry.FinishWithError(event, err)
+		return err
+	}
+
 	// 8: Register grpc gateway function into GwMux
 	for i := range entry.GwRegF {
-		err := entry.GwRegF[i](context.Background(), entry.GwMux, "0.0.0.0:"+strconv.FormatUint(entry.Port, 10), entry.GwDialOptions)
+		err := entry.GwRegF[i](context.Background(), entry.GwMux, entry.GrpcListen.DialTarget(entry.Port), entry.GwDialOptions)
 		if err != nil {
+			entry.closeListeners()
 			entry.EventEntry.FinishWithError(event, err)
-			rkentry.ShutdownWithError(err)
+			return err
 		}
 	}
 
+	// 8.1: Start background goroutines after listeners and gateway are ready, so that nothing leaks if bootstrap failed.
+	// Evict idle connections and check health of proxy destinations
+	if entry.IsProxyEnabled() {
+		entry.ProxyEntry.Bootstrap(ctx)
+	}
+
+	// 8.2: Watch certificates on disk
+	if entry.CertReloader != nil {
+		entry.CertReloader.Start()
+	}
+
+	// 8.3: Watch boot file on disk, after options of methods are resolved since they are applied while reloading
+	if entry.ConfigReloader != nil {
+		entry.ConfigReloader.Start()
+	}
+
 	// 9: Make http mux listen on path of / and configure TV, swagger, prometheus path
 	entry.HttpMux.Handle("/", entry.GwMux)
 
@@ -573,16 +819,19 @@ func (entry *GrpcEntry) Bootstrap(ctx context.Context) {
 	// 13: prometheus
 	if entry.IsPromEnabled() {
 		// Register prom path into Router.
-		entry.HttpMux.Handle(entry.PromEntry.Path, promhttp.HandlerFor(entry.PromEntry.Gatherer, promhttp.HandlerOpts{}))
+		entry.HttpMux.Handle(entry.PromEntry.Path, promhttp.HandlerFor(prometheus.GathererFunc(entry.gatherPromMetrics), promhttp.HandlerOpts{}))
 		entry.PromEntry.Bootstrap(ctx)
 	}
 
 	// 14: common service
 	if entry.IsCommonServiceEnabled() {
-		entry.HttpMux.HandleFunc(entry.CommonServiceEntry.ReadyPath, entry.CommonServiceEntry.Ready)
+		entry.HttpMux.HandleFunc(entry.CommonServiceEntry.ReadyPath, entry.ready)
 		entry.HttpMux.HandleFunc(entry.CommonServiceEntry.GcPath, entry.CommonServiceEntry.Gc)
 		entry.HttpMux.HandleFunc(entry.CommonServiceEntry.InfoPath, entry.CommonServiceEntry.Info)
 		entry.HttpMux.HandleFunc(entry.CommonServiceEntry.AlivePath, entry.CommonServiceEntry.Alive)
+		if entry.ConfigReloader != nil && entry.reloadEndpoint {
+			entry.HttpMux.HandleFunc(entry.reloadPath(), entry.reload)
+		}
 
 		// Bootstrap common service entry.
 		entry.CommonServiceEntry.Bootstrap(ctx)
@@ -606,12 +855,14 @@ func (entry *GrpcEntry) Bootstrap(ctx context.Context) {
 	// 16: Create http server
 	var httpHandler http.Handler
 	httpHandler = entry.HttpMux
-
-	// 17: If CORS enabled, then add interceptor for grpc-gateway
-	if len(entry.gwCorsOptions) > 0 {
-		httpHandler = rkgrpccors.Interceptor(httpHandler, entry.gwCorsOptions...)
+	if entry.inFlight != nil {
+		httpHandler = entry.inFlight.wrapMux(entry.HttpMux)
 	}
 
+	// 17: Add CORS interceptor for grpc-gateway, which could be enabled or replaced while reloading config
+	entry.gwCors = newGwCorsHandler(httpHandler, entry.gwCorsOptions...)
+	httpHandler = entry.gwCors
+
 	// 18: If Secure enabled, then add interceptor for grpc-gateway
 	if len(entry.gwSecureOptions) > 0 {
 		httpHandler = rkgrpcsec.Interceptor(httpHandler, entry.gwSecureOptions...)
@@ -622,16 +873,15 @@ func (entry *GrpcEntry) Bootstrap(ctx context.Context) {
 		httpHandler = rkgrpccsrf.Interceptor(httpHandler, entry.gwCsrfOptions...)
 	}
 
-	// 20: set http port if missing
-	if entry.GwPort < 1 {
-		entry.GwPort = entry.Port
-	}
-
 	entry.HttpServer = &http.Server{
-		Addr:    "0.0.0.0:" + strconv.FormatUint(entry.GwPort, 10),
+		Addr:    entry.GwListen.Addr(entry.GwPort),
 		Handler: h2c.NewHandler(httpHandler, &http2.Server{}),
 	}
 
+	for i := range entry.HttpServerOpts {
+		entry.HttpServerOpts[i](entry.HttpServer)
+	}
+
 	if len(entry.GrpcWebOptions) > 0 {
 		grpcWebServer := grpcweb.WrapServer(entry.Server, entry.GrpcWebOptions...)
 		entry.HttpServer.Handler = http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
@@ -647,17 +897,10 @@ func (entry *GrpcEntry) Bootstrap(ctx context.Context) {
 	}
 
 	// 20: Start http server
-	if entry.Port == entry.GwPort {
-		// same port, using cmux
+	if entry.gwLis == nil {
+		// same listener, using cmux
 		go func(*GrpcEntry) {
-			// Create inner listener
-			conn, err := net.Listen("tcp4", ":"+strconv.FormatUint(entry.Port, 10))
-			if err != nil {
-				entry.bootstrapLogOnce.Do(func() {
-					entry.EventEntry.FinishWithError(event, err)
-				})
-				rkentry.ShutdownWithError(err)
-			}
+			conn := entry.grpcLis
 
 			// We will use cmux to make grpc and grpc gateway on the same port.
 			// With cmux, we can init one listener but routes connection based on some rules.
@@ -678,13 +921,13 @@ func (entry *GrpcEntry) Bootstrap(ctx context.Context) {
 				go entry.startHttpServer(httpL, logger)
 
 				// 5: Start listener
-				if err := tcpL.Serve(); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+				if err := tcpL.Serve(); err != nil && !entry.isListenerClosed() && !strings.Contains(err.Error(), "use of closed network connection") {
 					if err != cmux.ErrListenerClosed {
 						entry.bootstrapLogOnce.Do(func() {
 							entry.EventEntry.FinishWithError(event, err)
 						})
 						logger.Error("Error occurs while serving TCP listener.", zap.Error(err))
-						rkentry.ShutdownWithError(err)
+						entry.serveFailed(err)
 					}
 				}
 			} else {
@@ -705,34 +948,20 @@ func (entry *GrpcEntry) Bootstrap(ctx context.Context) {
 				go entry.startHttpServer(httpL, logger)
 
 				// 5: Start listener
-				if err := tlsL.Serve(); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+				if err := tlsL.Serve(); err != nil && !entry.isListenerClosed() && !strings.Contains(err.Error(), "use of closed network connection") {
 					if err != cmux.ErrListenerClosed {
 						entry.bootstrapLogOnce.Do(func() {
 							entry.EventEntry.FinishWithError(event, err)
 						})
 						logger.Error("Error occurs while serving TLS listener.", zap.Error(err))
-						rkentry.ShutdownWithError(err)
+						entry.serveFailed(err)
 					}
 				}
 			}
 		}(entry)
 	} else {
 		go func(*GrpcEntry) {
-			// Create inner listener
-			grpcLis, err := net.Listen("tcp4", ":"+strconv.FormatUint(entry.Port, 10))
-			if err != nil {
-				entry.bootstrapLogOnce.Do(func() {
-					entry.EventEntry.FinishWithError(event, err)
-				})
-				rkentry.ShutdownWithError(err)
-			}
-			gwLis, err := net.Listen("tcp4", ":"+strconv.FormatUint(entry.GwPort, 10))
-			if err != nil {
-				entry.bootstrapLogOnce.Do(func() {
-					entry.EventEntry.FinishWithError(event, err)
-				})
-				rkentry.ShutdownWithError(err)
-			}
+			grpcLis, gwLis := entry.grpcLis, entry.gwLis
 
 			// We will use cmux to make grpc and grpc gateway on the same port.
 			// With cmux, we can init one listener but routes connection based on some rules.
@@ -787,18 +1016,33 @@ func (entry *GrpcEntry) Bootstrap(ctx context.Context) {
 		}
 		entry.EventEntry.Finish(event)
 	})
+
+	return nil
 }
 
 func (entry *GrpcEntry) startGrpcServer(lis net.Listener, logger *zap.Logger) {
-	if err := entry.Server.Serve(lis); err != nil && !strings.Contains(err.Error(), "mux: server closed") {
+	if err := entry.Server.Serve(lis); err != nil && err != grpc.ErrServerStopped && !entry.isListenerClosed() && !strings.Contains(err.Error(), "mux: server closed") {
 		logger.Error("Error occurs while serving grpc-server.", zap.Error(err))
-		rkentry.ShutdownWithError(err)
+		entry.serveFailed(err)
 	}
 }
 
 func (entry *GrpcEntry) startHttpServer(lis net.Listener, logger *zap.Logger) {
-	if err := entry.HttpServer.Serve(lis); err != nil && !strings.Contains(err.Error(), "http: Server closed") {
+	if err := entry.HttpServer.Serve(lis); err != nil && !entry.isListenerClosed() && !strings.Contains(err.Error(), "http: Server closed") {
 		logger.Error("Error occurs while serving gateway-server.", zap.Error(err))
+		entry.serveFailed(err)
+	}
+}
+
+// Record first error occurs while serving, process will shutdown unless entry was bootstrapped with BootstrapE.
+func (entry *GrpcEntry) serveFailed(err error) {
+	entry.serveErrLock.Lock()
+	if entry.serveErr == nil {
+		entry.serveErr = err
+	}
+	entry.serveErrLock.Unlock()
+
+	if !entry.returnServeErr {
 		rkentry.ShutdownWithError(err)
 	}
 }
@@ -807,6 +1051,17 @@ func (entry *GrpcEntry) startHttpServer(lis net.Listener, logger *zap.Logger) {
 func (entry *GrpcEntry) Interrupt(ctx context.Context) {
 	event, logger := entry.logBasicInfo("Interrupt", ctx)
 
+	// Mark entry as not ready and wait for load balancers
+	entry.preStop(ctx, event, logger)
+
+	if entry.CertReloader != nil {
+		entry.CertReloader.Stop()
+	}
+
+	if entry.ConfigReloader != nil {
+		entry.ConfigReloader.Stop()
+	}
+
 	// Interrupt CommonServiceEntry, SwEntry, TvEntry, PromEntry
 	if entry.IsCommonServiceEnabled() {
 		entry.CommonServiceEntry.Interrupt(ctx)
@@ -832,15 +1087,15 @@ func (entry *GrpcEntry) Interrupt(ctx context.Context) {
 		entry.PProfEntry.Interrupt(ctx)
 	}
 
-	if entry.HttpServer != nil {
-		if err := entry.HttpServer.Shutdown(context.Background()); err != nil {
-			event.AddErr(err)
-			logger.Warn("Error occurs while stopping http server")
-		}
-	}
+	// Stop gateway and grpc server within drain timeout
+	entry.drain(event, logger)
+
+	// Close root listener which is shared by grpc and gateway with cmux
+	entry.closeListeners()
 
-	if entry.Server != nil {
-		entry.Server.GracefulStop()
+	// Close connections of proxy after grpc server stopped
+	if entry.IsProxyEnabled() {
+		entry.ProxyEntry.Interrupt(ctx)
 	}
 
 	entry.EventEntry.Finish(event)
@@ -850,6 +1105,69 @@ func (entry *GrpcEntry) Interrupt(ctx context.Context) {
 
 // ************* public function *************
 
+// GrpcAddr Get address of grpc listener which is able to be dialed, empty string will be returned before Bootstrap.
+//
+// Unspecified address is converted to loopback address, and unix socket is returned as unix:path.
+func (entry *GrpcEntry) GrpcAddr() string {
+	if entry.grpcLis == nil {
+		return ""
+	}
+
+	if entry.customLis != nil {
+		return entry.customLis.Addr().String()
+	}
+
+	return entry.GrpcListen.DialTarget(entry.Port)
+}
+
+// GatewayAddr Get address of gateway listener which is able to be dialed, empty string will be returned before Bootstrap.
+//
+// Unspecified address is converted to loopback address, and unix socket is returned as unix:path.
+func (entry *GrpcEntry) GatewayAddr() string {
+	if entry.grpcLis == nil {
+		return ""
+	}
+
+	if entry.customLis != nil {
+		return entry.customLis.Addr().String()
+	}
+
+	return entry.GwListen.DialTarget(entry.GwPort)
+}
+
+// ServeErr Get first error occurs while serving after BootstrapE, nil will be returned if servers are serving
+// or stopped by Interrupt.
+func (entry *GrpcEntry) ServeErr() error {
+	entry.serveErrLock.Lock()
+	defer entry.serveErrLock.Unlock()
+
+	return entry.serveErr
+}
+
+// SetServingStatus Set serving status of service in grpc.health.v1.Health service, empty service stands for
+// overall status of server. Status won't be changed once entry is interrupted.
+//
+// Status is not served if grpc.health.v1.Health service is registered by user.
+func (entry *GrpcEntry) SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus) {
+	if entry.HealthServer != nil {
+		entry.HealthServer.SetServingStatus(service, status)
+	}
+}
+
+// GetServingStatus Get serving status of service, SERVICE_UNKNOWN will be returned if service was not found.
+func (entry *GrpcEntry) GetServingStatus(service string) healthpb.HealthCheckResponse_ServingStatus {
+	if entry.HealthServer == nil {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+
+	resp, err := entry.HealthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+
+	return resp.GetStatus()
+}
+
 // AddServerOptions Add grpc server options.
 func (entry *GrpcEntry) AddServerOptions(opts ...grpc.ServerOption) {
 	entry.ServerOpts = append(entry.ServerOpts, opts...)
@@ -900,6 +1218,16 @@ func (entry *GrpcEntry) AddGwDialOptions(opts ...grpc.DialOption) {
 	entry.GwDialOptions = append(entry.GwDialOptions, opts...)
 }
 
+// AddPromGatherers Add prometheus.Gatherer whose metrics would be exposed together with PromEntry.
+//
+// Usually, registry of GrpcClientEntry would be added, so that metrics of outgoing calls are visible.
+func (entry *GrpcEntry) AddPromGatherers(gatherers ...prometheus.Gatherer) {
+	entry.promGatherersLock.Lock()
+	defer entry.promGatherersLock.Unlock()
+
+	entry.promGatherers = append(entry.promGatherers, gatherers...)
+}
+
 // MarshalJSON Marshal entry.
 func (entry *GrpcEntry) MarshalJSON() ([]byte, error) {
 	m := map[string]interface{}{
@@ -933,6 +1261,65 @@ func (entry *GrpcEntry) IsTlsEnabled() bool {
 	return entry.CertEntry != nil && entry.CertEntry.Certificate != nil
 }
 
+// Mark overall status and registered services as SERVING if status was not set by user.
+func (entry *GrpcEntry) initServingStatus() {
+	services := []string{""}
+	for name := range entry.Server.GetServiceInfo() {
+		services = append(services, name)
+	}
+
+	for _, service := range services {
+		if entry.GetServingStatus(service) == healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+			entry.HealthServer.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+		}
+	}
+}
+
+// Readiness handler, returns 503 if overall status of health service is not SERVING.
+func (entry *GrpcEntry) ready(writer http.ResponseWriter, request *http.Request) {
+	if entry.HealthServer != nil && entry.GetServingStatus("") != healthpb.HealthCheckResponse_SERVING {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		bytes, _ := json.MarshalIndent(map[string]bool{
+			"ready": false,
+		}, "", "  ")
+		writer.Write(bytes)
+		return
+	}
+
+	entry.CommonServiceEntry.Ready(writer, request)
+}
+
+// Path of config reload endpoint, next to paths of common service like /rk/v1/reload.
+func (entry *GrpcEntry) reloadPath() string {
+	return path.Join(path.Dir(entry.CommonServiceEntry.GcPath), "reload")
+}
+
+// Reload boot file watched by ConfigReloader, only POST is allowed.
+func (entry *GrpcEntry) reload(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"reloaded": true,
+	}
+	if err := entry.ConfigReloader.Reload(); err != nil {
+		resp["reloaded"] = false
+		resp["error"] = err.Error()
+		writer.WriteHeader(http.StatusInternalServerError)
+	} else {
+		writer.WriteHeader(http.StatusOK)
+	}
+
+	bytes, _ := json.MarshalIndent(resp, "", "  ")
+	writer.Write(bytes)
+}
+
 // IsCommonServiceEnabled Is common service enabled?
 func (entry *GrpcEntry) IsCommonServiceEnabled() bool {
 	return entry.CommonServiceEntry != nil
@@ -973,6 +1360,16 @@ func (entry *GrpcEntry) IsPromEnabled() bool {
 	return entry.PromEntry != nil
 }
 
+// Gather metrics from PromEntry and gatherers added with AddPromGatherers.
+func (entry *GrpcEntry) gatherPromMetrics() ([]*dto.MetricFamily, error) {
+	entry.promGatherersLock.Lock()
+	gatherers := prometheus.Gatherers{entry.PromEntry.Gatherer}
+	gatherers = append(gatherers, entry.promGatherers...)
+	entry.promGatherersLock.Unlock()
+
+	return gatherers.Gather()
+}
+
 // Add basic fields into event.
 func (entry *GrpcEntry) logBasicInfo(operation string, ctx context.Context) (rkquery.Event, *zap.Logger) {
 	event := entry.EventEntry.Start(
@@ -1048,7 +1445,8 @@ func (entry *GrpcEntry) logBasicInfo(operation string, ctx context.Context) (rkq
 	// add tls info
 	if entry.IsTlsEnabled() {
 		event.AddPayloads(
-			zap.Bool("tlsEnabled", true))
+			zap.Bool("tlsEnabled", true),
+			zap.String("clientAuth", entry.ClientAuth.String()))
 	}
 
 	// add proxy info
@@ -1112,7 +1510,8 @@ func WithEventEntry(logger *rkentry.EventEntry) GrpcEntryOption {
 	}
 }
 
-// WithPort Provide grpc port, gateway will use same port if not provided
+// WithPort Provide grpc port, gateway will use same port if not provided.
+// Port 0 picks a free port while listening, default name of entry is grpc-0-<sequence> if WithName is not provided.
 func WithPort(port uint64) GrpcEntryOption {
 	return func(entry *GrpcEntry) {
 		entry.Port = port
@@ -1126,6 +1525,24 @@ func WithGwPort(gwPort uint64) GrpcEntryOption {
 	}
 }
 
+// WithListen Provide ListenConfig of grpc and gateway listeners, gateway listener follows grpc listener if empty.
+func WithListen(grpcListen, gwListen ListenConfig) GrpcEntryOption {
+	return func(entry *GrpcEntry) {
+		entry.GrpcListen = grpcListen
+		entry.GwListen = gwListen
+	}
+}
+
+// WithListener Provide listener shared by grpc and gateway, ListenConfig is ignored and listener is closed in Interrupt.
+//
+// It is mainly used for testing with in-memory listener, gateway should be provided with grpc.WithContextDialer
+// in order to dial to the listener.
+func WithListener(lis net.Listener) GrpcEntryOption {
+	return func(entry *GrpcEntry) {
+		entry.customLis = lis
+	}
+}
+
 // WithServerOptions Provide grpc.ServerOption.
 func WithServerOptions(opts ...grpc.ServerOption) GrpcEntryOption {
 	return func(entry *GrpcEntry) {
@@ -1133,6 +1550,13 @@ func WithServerOptions(opts ...grpc.ServerOption) GrpcEntryOption {
 	}
 }
 
+// WithHttpServerOptions Provide HttpServerOption which modifies http.Server of grpc-gateway.
+func WithHttpServerOptions(opts ...HttpServerOption) GrpcEntryOption {
+	return func(entry *GrpcEntry) {
+		entry.HttpServerOpts = append(entry.HttpServerOpts, opts...)
+	}
+}
+
 // WithUnaryInterceptors Provide grpc.UnaryServerInterceptor.
 func WithUnaryInterceptors(opts ...grpc.UnaryServerInterceptor) GrpcEntryOption {
 	return func(entry *GrpcEntry) {
@@ -1154,6 +1578,59 @@ func WithGrpcRegF(f ...GrpcRegFunc) GrpcEntryOption {
 	}
 }
 
+// WithMtls Provide client auth type and CA pool which verifies client certificates, works with WithCertEntry.
+//
+// Verified peer identity could be read with rkgrpcctx.GetPeerIdentity. Since grpc-gateway dials grpc server with
+// certificate of CertEntry, the certificate should be signed by CA in clientCAs and allowed for client auth.
+func WithMtls(clientAuth tls.ClientAuthType, clientCAs *x509.CertPool) GrpcEntryOption {
+	return func(entry *GrpcEntry) {
+		entry.ClientAuth = clientAuth
+		entry.ClientCAs = clientCAs
+	}
+}
+
+// WithCertReload Reload key pair and CA bundle of CertEntry from disk once modified, works with WithCertEntry.
+//
+// Files are checked every interval, 10 seconds would be used if not positive.
+// CA bundle which verifies client certificates is read from caEntry, CertEntry is used if caEntry is nil.
+func WithCertReload(enabled bool, interval time.Duration, caEntry *rkentry.CertEntry) GrpcEntryOption {
+	return func(entry *GrpcEntry) {
+		entry.certReload = enabled
+		entry.certReloadTick = interval
+		entry.certReloadCA = caEntry
+	}
+}
+
+// WithConfigReload Watch boot file at path and reload middleware config of entry once modified.
+//
+// Interval would be 10 seconds if not positive, watching is disabled if path is empty.
+func WithConfigReload(path string, interval time.Duration) GrpcEntryOption {
+	return func(entry *GrpcEntry) {
+		entry.configReloadPath = path
+		entry.configReloadTick = interval
+	}
+}
+
+// WithConfigReloadEndpoint Serve POST /rk/v1/reload with common service to reload boot file of WithConfigReload.
+//
+// Endpoint is not authenticated, enable it only if gateway port is not exposed to untrusted clients.
+func WithConfigReloadEndpoint(enabled bool) GrpcEntryOption {
+	return func(entry *GrpcEntry) {
+		entry.reloadEndpoint = enabled
+	}
+}
+
+// WithShutdown Provide pre-stop delay and drain timeout used in Interrupt.
+//
+// Entry is marked as not ready and waits for preStopDelay, then gateway and grpc server are stopped gracefully
+// within drainTimeout, and remaining streams are cut once exceeded. Zero drainTimeout means no deadline.
+func WithShutdown(preStopDelay, drainTimeout time.Duration) GrpcEntryOption {
+	return func(entry *GrpcEntry) {
+		entry.PreStopDelay = preStopDelay
+		entry.DrainTimeout = drainTimeout
+	}
+}
+
 // WithCertEntry Provide rkentry.CertEntry.
 func WithCertEntry(certEntry *rkentry.CertEntry) GrpcEntryOption {
 	return func(entry *GrpcEntry) {
@@ -27,9 +27,14 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"math/big"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -348,6 +353,79 @@ func TestRegisterGrpcEntry_EntryFunc(t *testing.T) {
 	entry.Interrupt(context.TODO())
 }
 
+func TestGrpcEntry_HealthService(t *testing.T) {
+	defer assertNotPanic(t)
+
+	// without common service, entry is marked as NOT_SERVING while shutting down as well
+	entry := RegisterGrpcEntry(WithName("ut-health"), WithPort(0))
+	assert.NotNil(t, entry.HealthServer)
+	assert.Nil(t, entry.BootstrapE(context.TODO()))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, entry.GetServingStatus(""))
+	entry.Interrupt(context.TODO())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, entry.GetServingStatus(""))
+
+	// with health service registered by user
+	entry = RegisterGrpcEntry(WithName("ut-health"), WithPort(0))
+	entry.AddRegFuncGrpc(func(server *grpc.Server) {
+		healthpb.RegisterHealthServer(server, health.NewServer())
+	})
+	assert.Nil(t, entry.BootstrapE(context.TODO()))
+	entry.Interrupt(context.TODO())
+
+	// with common service
+	entry = RegisterGrpcEntry(
+		WithPort(1960),
+		WithCommonServiceEntry(rkentry.RegisterCommonServiceEntry(&rkentry.BootCommonService{
+			Enabled: true,
+		})))
+	entry.AddRegFuncGrpc(func(server *grpc.Server) {
+		testdata.RegisterGreeterServer(server, &GreeterServer{})
+	})
+	assert.NotNil(t, entry.HealthServer)
+
+	// status set before bootstrap should be kept
+	entry.SetServingStatus("Chat", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	entry.Bootstrap(context.TODO())
+	time.Sleep(time.Second)
+	validateServerIsUp(t, entry.Port)
+
+	conn, err := grpc.Dial("localhost:1960", grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	// overall and registered services
+	resp, err := client.Check(context.TODO(), &healthpb.HealthCheckRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	resp, err = client.Check(context.TODO(), &healthpb.HealthCheckRequest{Service: "Greeter"})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, entry.GetServingStatus("Chat"))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, entry.GetServingStatus("Unknown"))
+
+	// readiness follows overall status
+	httpResp, err := http.Get("http://localhost:1960/rk/v1/ready")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	httpResp.Body.Close()
+
+	entry.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	httpResp, err = http.Get("http://localhost:1960/rk/v1/ready")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, httpResp.StatusCode)
+	httpResp.Body.Close()
+	entry.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	// everything is NOT_SERVING after interrupted
+	entry.Interrupt(context.TODO())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, entry.GetServingStatus(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, entry.GetServingStatus("Greeter"))
+	entry.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, entry.GetServingStatus(""))
+}
+
 func TestGrpcEntry_startGrpcServer_Panic(t *testing.T) {
 	// without stopped error
 	defer assertPanic(t)
@@ -439,3 +517,176 @@ func validateServerIsUp(t *testing.T, port uint64) {
 		assert.Nil(t, conn.Close())
 	}
 }
+
+func TestGrpcEntry_ListenOnUnixSocket(t *testing.T) {
+	defer assertNotPanic(t)
+
+	path := filepath.Join(t.TempDir(), "ut-grpc.sock")
+	target := ""
+
+	entry := RegisterGrpcEntry(
+		WithName("ut-unix"),
+		WithPort(1968),
+		WithGwPort(1969),
+		WithListen(
+			ListenConfig{Network: NetworkUnix, Address: path, SocketFileMode: 0660},
+			ListenConfig{Network: NetworkTcp4, Address: "127.0.0.1"}),
+		WithCommonServiceEntry(rkentry.RegisterCommonServiceEntry(&rkentry.BootCommonService{Enabled: true})))
+	entry.AddRegFuncGrpc(func(server *grpc.Server) {
+		testdata.RegisterGreeterServer(server, &GreeterServer{})
+	})
+	entry.AddRegFuncGw(func(ctx context.Context, mux *gwruntime.ServeMux, s string, options []grpc.DialOption) error {
+		target = s
+		return nil
+	})
+
+	entry.Bootstrap(context.TODO())
+	defer entry.Interrupt(context.TODO())
+	time.Sleep(time.Second)
+
+	// gateway dials grpc server over unix socket
+	assert.Equal(t, "unix:"+path, target)
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0660), info.Mode().Perm())
+
+	// grpc over unix socket
+	conn, err := grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	resp, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "unix"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello unix!", resp.GetMessage())
+
+	// gateway over tcp on localhost
+	httpResp, err := http.Get("http://127.0.0.1:1969/rk/v1/ready")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	httpResp.Body.Close()
+}
+
+func TestRegisterGrpcEntry_WithPortZeroWithoutName(t *testing.T) {
+	defer assertNotPanic(t)
+
+	first, second := RegisterGrpcEntry(WithPort(0)), RegisterGrpcEntry(WithPort(0))
+	defer rkentry.GlobalAppCtx.RemoveEntry(first)
+	defer rkentry.GlobalAppCtx.RemoveEntry(second)
+
+	assert.Regexp(t, `^grpc-0-\d+$`, first.GetName())
+	assert.NotEqual(t, first.GetName(), second.GetName())
+}
+
+func TestGrpcEntry_EphemeralPort(t *testing.T) {
+	defer assertNotPanic(t)
+
+	entries := make([]*GrpcEntry, 0)
+	for _, name := range []string{"ut-ephemeral-1", "ut-ephemeral-2"} {
+		entry := RegisterGrpcEntry(
+			WithName(name),
+			WithPort(0),
+			WithListen(ListenConfig{Network: NetworkTcp4, Address: "127.0.0.1"}, ListenConfig{}),
+			WithCommonServiceEntry(rkentry.RegisterCommonServiceEntry(&rkentry.BootCommonService{Enabled: true})))
+		entry.AddRegFuncGrpc(func(server *grpc.Server) {
+			testdata.RegisterGreeterServer(server, &GreeterServer{})
+		})
+		assert.Empty(t, entry.GrpcAddr())
+		assert.Empty(t, entry.GatewayAddr())
+
+		entry.Bootstrap(context.TODO())
+		defer entry.Interrupt(context.TODO())
+		entries = append(entries, entry)
+	}
+
+	assert.NotEqual(t, entries[0].GrpcAddr(), entries[1].GrpcAddr())
+
+	for _, entry := range entries {
+		assert.NotZero(t, entry.Port)
+		assert.Equal(t, entry.Port, entry.GwPort)
+		assert.Equal(t, "127.0.0.1:"+strconv.FormatUint(entry.Port, 10), entry.GrpcAddr())
+		assert.Equal(t, entry.GrpcAddr(), entry.GatewayAddr())
+
+		// listener is ready once Bootstrap returns
+		conn, err := grpc.Dial(entry.GrpcAddr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		assert.Nil(t, err)
+
+		resp, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "ephemeral"})
+		assert.Nil(t, err)
+		assert.Equal(t, "Hello ephemeral!", resp.GetMessage())
+		conn.Close()
+
+		httpResp, err := http.Get("http://" + entry.GatewayAddr() + "/rk/v1/ready")
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		httpResp.Body.Close()
+	}
+}
+
+func TestGrpcEntry_BootstrapWithPortInUse(t *testing.T) {
+	lis, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer lis.Close()
+
+	entry := RegisterGrpcEntry(
+		WithName("ut-port-in-use"),
+		WithPort(uint64(lis.Addr().(*net.TCPAddr).Port)),
+		WithListen(ListenConfig{Network: NetworkTcp4, Address: "127.0.0.1"}, ListenConfig{}))
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	// listen error is reported synchronously
+	defer assertPanic(t)
+	entry.Bootstrap(context.TODO())
+}
+
+func TestGrpcEntry_BootstrapE(t *testing.T) {
+	defer assertNotPanic(t)
+
+	// with port in use
+	lis, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer lis.Close()
+
+	entry := RegisterGrpcEntry(
+		WithName("ut-bootstrap-e"),
+		WithPort(uint64(lis.Addr().(*net.TCPAddr).Port)),
+		WithListen(ListenConfig{Network: NetworkTcp4, Address: "127.0.0.1"}, ListenConfig{}))
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+	assert.NotNil(t, entry.BootstrapE(context.TODO()))
+
+	// with gateway registration error, listener is closed and background goroutines are not started
+	entry = RegisterGrpcEntry(
+		WithName("ut-bootstrap-e"),
+		WithPort(1970),
+		WithProxyEntry(NewProxyEntry(WithOutlierProxy(1, time.Minute))),
+		WithConfigReload(filepath.Join(t.TempDir(), "boot.yaml"), time.Minute),
+		WithGwRegF(func(context.Context, *gwruntime.ServeMux, string, []grpc.DialOption) error {
+			return errors.New("ut-error")
+		}))
+	assert.EqualError(t, entry.BootstrapE(context.TODO()), "ut-error")
+
+	started := true
+	entry.ConfigReloader.startOnce.Do(func() { started = false })
+	entry.ProxyEntry.health.startOnce.Do(func() { started = false })
+	entry.ProxyEntry.pool.startOnce.Do(func() { started = false })
+	assert.False(t, started)
+
+	lis, err = net.Listen("tcp4", ":1970")
+	assert.Nil(t, err)
+	lis.Close()
+}
+
+func TestGrpcEntry_ServeErr(t *testing.T) {
+	defer assertNotPanic(t)
+
+	entry := RegisterGrpcEntry(WithName("ut-serve-err"))
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+	entry.returnServeErr = true
+	assert.Nil(t, entry.ServeErr())
+
+	// serving errors are recorded instead of shutting down process
+	logger, _ := zap.NewDevelopment()
+	entry.Server = grpc.NewServer()
+	entry.startGrpcServer(&ErrListener{}, logger)
+	assert.NotNil(t, entry.ServeErr())
+}
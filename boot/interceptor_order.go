@@ -0,0 +1,164 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+)
+
+// Names of built-in interceptors which could be referenced in middleware.order of boot config.
+const (
+	InterceptorLogging   = "logging"
+	InterceptorPanic     = "panic"
+	InterceptorProm      = "prom"
+	InterceptorTrace     = "trace"
+	InterceptorJwt       = "jwt"
+	InterceptorMeta      = "meta"
+	InterceptorAuth      = "auth"
+	InterceptorTimeout   = "timeout"
+	InterceptorRateLimit = "rateLimit"
+)
+
+// Default order of built-in interceptors.
+var defaultInterceptorOrder = []string{
+	InterceptorLogging,
+	InterceptorPanic,
+	InterceptorProm,
+	InterceptorTrace,
+	InterceptorJwt,
+	InterceptorMeta,
+	InterceptorAuth,
+	InterceptorTimeout,
+	InterceptorRateLimit,
+}
+
+var (
+	interceptorRegistry     = make(map[string]*namedInterceptor)
+	interceptorRegistryLock sync.RWMutex
+)
+
+// namedInterceptor is a pair of unary and stream interceptors, either of them could be nil.
+type namedInterceptor struct {
+	unary  grpc.UnaryServerInterceptor
+	stream grpc.StreamServerInterceptor
+}
+
+// RegisterInterceptor Register user interceptors with name, which could be referenced in middleware.order of
+// boot config. Either of unary and stream could be nil.
+//
+// Names of built-in interceptors are reserved, interceptors registered with the same name are replaced.
+func RegisterInterceptor(name string, unary grpc.UnaryServerInterceptor, stream grpc.StreamServerInterceptor) error {
+	if len(name) < 1 {
+		return errors.New("name of interceptor is empty")
+	}
+
+	if isBuiltinInterceptor(name) {
+		return fmt.Errorf("name of interceptor %s is reserved by built-in interceptor", name)
+	}
+
+	interceptorRegistryLock.Lock()
+	defer interceptorRegistryLock.Unlock()
+
+	interceptorRegistry[name] = &namedInterceptor{
+		unary:  unary,
+		stream: stream,
+	}
+
+	return nil
+}
+
+// UnregisterInterceptor Remove user interceptors registered with name.
+func UnregisterInterceptor(name string) {
+	interceptorRegistryLock.Lock()
+	defer interceptorRegistryLock.Unlock()
+
+	delete(interceptorRegistry, name)
+}
+
+// Get user interceptors registered with name.
+func getRegisteredInterceptor(name string) (*namedInterceptor, bool) {
+	interceptorRegistryLock.RLock()
+	defer interceptorRegistryLock.RUnlock()
+
+	res, ok := interceptorRegistry[name]
+	return res, ok
+}
+
+// Name of built-in interceptor is case-insensitive, since keys of boot config are case-insensitive as well.
+func isBuiltinInterceptor(name string) bool {
+	for _, builtin := range defaultInterceptorOrder {
+		if strings.EqualFold(builtin, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolve order of interceptors, built-in interceptors which are missing in order are appended in default order.
+//
+// Unknown names and duplicate names are rejected, all of them are combined in returned error.
+func resolveInterceptorOrder(order []string) ([]string, error) {
+	res := make([]string, 0, len(order)+len(defaultInterceptorOrder))
+	seen := make(map[string]bool)
+	var errs error
+
+	for _, name := range order {
+		if isBuiltinInterceptor(name) {
+			name = strings.ToLower(name)
+		} else if _, ok := getRegisteredInterceptor(name); !ok {
+			errs = multierr.Append(errs, fmt.Errorf("unknown interceptor %s in middleware.order", name))
+			continue
+		}
+
+		if seen[name] {
+			errs = multierr.Append(errs, fmt.Errorf("duplicate interceptor %s in middleware.order", name))
+			continue
+		}
+		seen[name] = true
+		res = append(res, name)
+	}
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	for _, name := range defaultInterceptorOrder {
+		if !seen[strings.ToLower(name)] {
+			res = append(res, strings.ToLower(name))
+		}
+	}
+
+	return res, nil
+}
+
+// Add interceptors in resolved order, built-in interceptors are keyed with lower cased names,
+// disabled ones are either missing or nil.
+func (entry *GrpcEntry) addInterceptorsInOrder(order []string, builtin map[string]*namedInterceptor) {
+	for _, name := range order {
+		inter, ok := builtin[name]
+		if !ok {
+			inter, _ = getRegisteredInterceptor(name)
+		}
+
+		if inter == nil {
+			continue
+		}
+
+		if inter.unary != nil {
+			entry.AddUnaryInterceptors(inter.unary)
+		}
+		if inter.stream != nil {
+			entry.AddStreamInterceptors(inter.stream)
+		}
+	}
+}
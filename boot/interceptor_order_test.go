@@ -0,0 +1,119 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestRegisterInterceptor(t *testing.T) {
+	defer UnregisterInterceptor("ut-tenant")
+
+	// with empty name
+	assert.NotNil(t, RegisterInterceptor("", nil, nil))
+
+	// with name of built-in interceptor
+	assert.NotNil(t, RegisterInterceptor(InterceptorLogging, nil, nil))
+	assert.NotNil(t, RegisterInterceptor("RateLimit", nil, nil))
+
+	// happy case
+	assert.Nil(t, RegisterInterceptor("ut-tenant", nil, nil))
+	_, ok := getRegisteredInterceptor("ut-tenant")
+	assert.True(t, ok)
+
+	UnregisterInterceptor("ut-tenant")
+	_, ok = getRegisteredInterceptor("ut-tenant")
+	assert.False(t, ok)
+}
+
+func TestResolveInterceptorOrder(t *testing.T) {
+	defer UnregisterInterceptor("ut-tenant")
+	assert.Nil(t, RegisterInterceptor("ut-tenant", nil, nil))
+
+	// without order
+	order, err := resolveInterceptorOrder(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"logging", "panic", "prom", "trace", "jwt", "meta", "auth", "timeout", "ratelimit",
+	}, order)
+
+	// with partial order
+	order, err = resolveInterceptorOrder([]string{"logging", "rateLimit", "ut-tenant", "auth"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"logging", "ratelimit", "ut-tenant", "auth", "panic", "prom", "trace", "jwt", "meta", "timeout",
+	}, order)
+
+	// with unknown interceptor
+	_, err = resolveInterceptorOrder([]string{"logging", "not-exist"})
+	assert.EqualError(t, err, "unknown interceptor not-exist in middleware.order")
+
+	// with duplicate interceptor
+	_, err = resolveInterceptorOrder([]string{"rateLimit", "ratelimit"})
+	assert.EqualError(t, err, "duplicate interceptor ratelimit in middleware.order")
+
+	// with every problem combined
+	_, err = resolveInterceptorOrder([]string{"not-exist", "auth", "Auth"})
+	assert.EqualError(t, err, "unknown interceptor not-exist in middleware.order; duplicate interceptor auth in middleware.order")
+}
+
+func TestRegisterGrpcEntryYAML_WithOrder(t *testing.T) {
+	called := make([]string, 0)
+	record := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			called = append(called, name)
+			return handler(ctx, req)
+		}
+	}
+
+	defer UnregisterInterceptor("ut-first")
+	defer UnregisterInterceptor("ut-second")
+	assert.Nil(t, RegisterInterceptor("ut-first", record("ut-first"), nil))
+	assert.Nil(t, RegisterInterceptor("ut-second", record("ut-second"), nil))
+
+	// with unknown interceptor
+	_, err := NewGrpcEntriesFromYAML([]byte(`
+---
+grpc:
+  - name: ut-order
+    enabled: true
+    middleware:
+      order: ["ut-second", "not-exist"]
+`))
+	assert.NotNil(t, err)
+	assert.Nil(t, rkentry.GlobalAppCtx.GetEntry(GrpcEntryType, "ut-order"))
+
+	// happy case
+	entries, err := NewGrpcEntriesFromYAML([]byte(`
+---
+grpc:
+  - name: ut-order
+    enabled: true
+    middleware:
+      order: ["ut-second", "logging", "ut-first"]
+`))
+	assert.Nil(t, err)
+	entry := entries["ut-order"]
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	// ut-second, logging, ut-first, panic, trace, jwt, auth, timeout and rate limit
+	assert.Len(t, entry.UnaryInterceptors, 9)
+	// stream interceptors of user are nil
+	assert.Len(t, entry.StreamInterceptors, 7)
+
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/ut.v1.Greeter/Greeter"}
+	for _, inter := range []grpc.UnaryServerInterceptor{entry.UnaryInterceptors[0], entry.UnaryInterceptors[2]} {
+		_, err := inter(context.TODO(), nil, info, handler)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, []string{"ut-second", "ut-first"}, called)
+}
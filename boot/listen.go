@@ -0,0 +1,222 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// NetworkTcp listens on both of IPv4 and IPv6 addresses
+	NetworkTcp = "tcp"
+	// NetworkTcp4 listens on IPv4 addresses only, default network
+	NetworkTcp4 = "tcp4"
+	// NetworkTcp6 listens on IPv6 addresses only
+	NetworkTcp6 = "tcp6"
+	// NetworkUnix listens on unix domain socket
+	NetworkUnix = "unix"
+)
+
+// BootConfigListen Boot config of grpc and gateway listeners.
+//
+// Gateway listener follows grpc listener if missing, and they share the same listener if network, address and port
+// are the same.
+type BootConfigListen struct {
+	Grpc    BootConfigListener `yaml:"grpc" json:"grpc"`
+	Gateway BootConfigListener `yaml:"gateway" json:"gateway"`
+}
+
+// BootConfigListener Boot config of listener.
+type BootConfigListener struct {
+	Network        string `yaml:"network" json:"network"`
+	Address        string `yaml:"address" json:"address"`
+	SocketFileMode string `yaml:"socketFileMode" json:"socketFileMode"`
+}
+
+// ListenConfig defines network and address of listener.
+type ListenConfig struct {
+	// Network would be one of tcp, tcp4, tcp6 and unix, tcp4 would be used if empty
+	Network string
+	// Address is host to bind for tcp networks, all interfaces would be used if empty,
+	// and path of socket file for unix network
+	Address string
+	// SocketFileMode is file mode of socket file, only used for unix network
+	SocketFileMode os.FileMode
+}
+
+// ToListenConfig Convert BootConfigListener to ListenConfig.
+func ToListenConfig(conf *BootConfigListener) (ListenConfig, error) {
+	res := ListenConfig{
+		Network: strings.ToLower(conf.Network),
+		Address: conf.Address,
+	}
+
+	switch res.Network {
+	case "", NetworkTcp, NetworkTcp4, NetworkTcp6:
+	case NetworkUnix:
+		if len(res.Address) < 1 {
+			return res, fmt.Errorf("address of unix listener is missing")
+		}
+	default:
+		return res, fmt.Errorf("invalid network of listener, network:%s", conf.Network)
+	}
+
+	if len(conf.SocketFileMode) > 0 {
+		mode, err := strconv.ParseUint(conf.SocketFileMode, 8, 32)
+		if err != nil {
+			return res, fmt.Errorf("invalid socketFileMode of listener, socketFileMode:%s", conf.SocketFileMode)
+		}
+		res.SocketFileMode = os.FileMode(mode)
+	}
+
+	return res, nil
+}
+
+// IsUnix is listener on unix domain socket?
+func (l ListenConfig) IsUnix() bool {
+	return l.Network == NetworkUnix
+}
+
+// Addr returns address to listen on.
+func (l ListenConfig) Addr(port uint64) string {
+	if l.IsUnix() {
+		return l.Address
+	}
+
+	return net.JoinHostPort(l.Address, strconv.FormatUint(port, 10))
+}
+
+// DialTarget returns grpc target which dials to listener, unspecified address is converted to loopback address.
+func (l ListenConfig) DialTarget(port uint64) string {
+	if l.IsUnix() {
+		return NetworkUnix + ":" + l.Address
+	}
+
+	host := l.Address
+	if ip := net.ParseIP(host); len(host) < 1 || (ip != nil && ip.IsUnspecified()) {
+		switch {
+		case l.Network == NetworkTcp6 || (ip != nil && ip.To4() == nil):
+			host = "::1"
+		case l.Network == NetworkTcp:
+			host = "localhost"
+		default:
+			host = "127.0.0.1"
+		}
+	}
+
+	return net.JoinHostPort(host, strconv.FormatUint(port, 10))
+}
+
+// Listen on network and address, stale socket file would be removed for unix network.
+func (l ListenConfig) Listen(port uint64) (net.Listener, error) {
+	if !l.IsUnix() {
+		return net.Listen(l.Network, l.Addr(port))
+	}
+
+	// remove socket file left by previous process
+	if info, err := os.Stat(l.Address); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(l.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	lis, err := net.Listen(NetworkUnix, l.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.SocketFileMode != 0 {
+		if err := os.Chmod(l.Address, l.SocketFileMode); err != nil {
+			lis.Close()
+			return nil, err
+		}
+	}
+
+	return lis, nil
+}
+
+// Is listener same as other one? Port is ignored for unix network.
+func (l ListenConfig) sameAs(port uint64, other ListenConfig, otherPort uint64) bool {
+	if l.Network != other.Network || l.Address != other.Address {
+		return false
+	}
+
+	return l.IsUnix() || port == otherPort
+}
+
+// Create listeners of grpc and gateway, gateway listener is nil if it is shared with grpc.
+//
+// Port and GwPort are replaced with bound ports, so that port 0 picks up ephemeral ports.
+func (entry *GrpcEntry) listen() error {
+	// gateway uses the same port if missing
+	if entry.GwPort < 1 {
+		entry.GwPort = entry.Port
+	}
+
+	// listener provided by user is shared by grpc and gateway, port is 0 unless it is a tcp listener
+	if entry.customLis != nil {
+		entry.grpcLis = entry.customLis
+		entry.Port = boundPort(entry.customLis, 0)
+		entry.GwPort = entry.Port
+		return nil
+	}
+
+	shared := entry.GrpcListen.sameAs(entry.Port, entry.GwListen, entry.GwPort)
+
+	grpcLis, err := entry.GrpcListen.Listen(entry.Port)
+	if err != nil {
+		return err
+	}
+	entry.grpcLis = grpcLis
+	entry.Port = boundPort(grpcLis, entry.Port)
+
+	if shared {
+		entry.GwPort = entry.Port
+		return nil
+	}
+
+	gwLis, err := entry.GwListen.Listen(entry.GwPort)
+	if err != nil {
+		entry.closeListeners()
+		return err
+	}
+	entry.gwLis = gwLis
+	entry.GwPort = boundPort(gwLis, entry.GwPort)
+
+	return nil
+}
+
+// Close listeners created by listen, serving errors are ignored afterwards.
+func (entry *GrpcEntry) closeListeners() {
+	atomic.StoreInt32(&entry.lisClosed, 1)
+
+	if entry.grpcLis != nil {
+		entry.grpcLis.Close()
+	}
+
+	if entry.gwLis != nil {
+		entry.gwLis.Close()
+	}
+}
+
+// Are listeners closed?
+func (entry *GrpcEntry) isListenerClosed() bool {
+	return atomic.LoadInt32(&entry.lisClosed) == 1
+}
+
+// Port of tcp listener, original port is returned for other listeners.
+func boundPort(lis net.Listener, port uint64) uint64 {
+	if addr, ok := lis.Addr().(*net.TCPAddr); ok {
+		return uint64(addr.Port)
+	}
+
+	return port
+}
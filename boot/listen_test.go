@@ -0,0 +1,94 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToListenConfig(t *testing.T) {
+	// with empty config
+	l, err := ToListenConfig(&BootConfigListener{})
+	assert.Nil(t, err)
+	assert.Equal(t, ListenConfig{}, l)
+
+	// with unix network
+	l, err = ToListenConfig(&BootConfigListener{
+		Network:        "UNIX",
+		Address:        "/tmp/ut.sock",
+		SocketFileMode: "0660",
+	})
+	assert.Nil(t, err)
+	assert.True(t, l.IsUnix())
+	assert.Equal(t, os.FileMode(0660), l.SocketFileMode)
+
+	// unix network without address
+	_, err = ToListenConfig(&BootConfigListener{Network: "unix"})
+	assert.NotNil(t, err)
+
+	// invalid network
+	_, err = ToListenConfig(&BootConfigListener{Network: "udp"})
+	assert.NotNil(t, err)
+
+	// invalid file mode
+	_, err = ToListenConfig(&BootConfigListener{Network: "unix", Address: "ut.sock", SocketFileMode: "rw"})
+	assert.NotNil(t, err)
+}
+
+func TestListenConfig_Addr(t *testing.T) {
+	assert.Equal(t, ":8080", ListenConfig{Network: NetworkTcp4}.Addr(8080))
+	assert.Equal(t, "127.0.0.1:8080", ListenConfig{Network: NetworkTcp4, Address: "127.0.0.1"}.Addr(8080))
+	assert.Equal(t, "[::1]:8080", ListenConfig{Network: NetworkTcp6, Address: "::1"}.Addr(8080))
+	assert.Equal(t, "/tmp/ut.sock", ListenConfig{Network: NetworkUnix, Address: "/tmp/ut.sock"}.Addr(8080))
+}
+
+func TestListenConfig_DialTarget(t *testing.T) {
+	assert.Equal(t, "127.0.0.1:8080", ListenConfig{Network: NetworkTcp4}.DialTarget(8080))
+	assert.Equal(t, "127.0.0.1:8080", ListenConfig{Network: NetworkTcp4, Address: "0.0.0.0"}.DialTarget(8080))
+	assert.Equal(t, "localhost:8080", ListenConfig{Network: NetworkTcp}.DialTarget(8080))
+	assert.Equal(t, "[::1]:8080", ListenConfig{Network: NetworkTcp6}.DialTarget(8080))
+	assert.Equal(t, "[::1]:8080", ListenConfig{Network: NetworkTcp, Address: "::"}.DialTarget(8080))
+	assert.Equal(t, "10.0.0.1:8080", ListenConfig{Network: NetworkTcp4, Address: "10.0.0.1"}.DialTarget(8080))
+	assert.Equal(t, "unix:/tmp/ut.sock", ListenConfig{Network: NetworkUnix, Address: "/tmp/ut.sock"}.DialTarget(8080))
+}
+
+func TestListenConfig_Listen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ut.sock")
+	l := ListenConfig{Network: NetworkUnix, Address: path, SocketFileMode: 0600}
+
+	// stale socket file left by previous listener
+	stale, err := l.Listen(0)
+	assert.Nil(t, err)
+	stale.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+	assert.Nil(t, stale.Close())
+
+	lis, err := l.Listen(0)
+	assert.Nil(t, err)
+	defer lis.Close()
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// tcp
+	lis, err = ListenConfig{Network: NetworkTcp4, Address: "127.0.0.1"}.Listen(0)
+	assert.Nil(t, err)
+	assert.Nil(t, lis.Close())
+}
+
+func TestListenConfig_SameAs(t *testing.T) {
+	tcp := ListenConfig{Network: NetworkTcp4}
+	unix := ListenConfig{Network: NetworkUnix, Address: "ut.sock"}
+
+	assert.True(t, tcp.sameAs(8080, tcp, 8080))
+	assert.False(t, tcp.sameAs(8080, tcp, 8081))
+	assert.False(t, tcp.sameAs(8080, unix, 8080))
+	assert.True(t, unix.sameAs(8080, unix, 8081))
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"sort"
+
+	"github.com/rookie-ninja/rk-grpc/v2/boot/api/third_party/gen/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// MethodOptionsResolver resolves rk.api.v1.method options of methods registered in grpc server.
+//
+// Options are declared in .proto files with option (rk.api.v1.method) = {auth: JWT, timeout_ms: 500}
+// and read from descriptors of services at Bootstrap.
+type MethodOptionsResolver struct {
+	options map[string]*util.MethodOptions
+}
+
+// NewMethodOptionsResolver Read options of services registered in server from protoregistry.GlobalFiles.
+func NewMethodOptionsResolver(server *grpc.Server) *MethodOptionsResolver {
+	if server == nil {
+		return newMethodOptionsResolver(nil, protoregistry.GlobalFiles)
+	}
+
+	return newMethodOptionsResolver(server.GetServiceInfo(), protoregistry.GlobalFiles)
+}
+
+// Read options of services from files, services which are missing in files are skipped.
+func newMethodOptionsResolver(services map[string]grpc.ServiceInfo, files *protoregistry.Files) *MethodOptionsResolver {
+	resolver := &MethodOptionsResolver{
+		options: make(map[string]*util.MethodOptions),
+	}
+
+	for name, info := range services {
+		desc, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			continue
+		}
+
+		serviceDesc, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+
+		for _, method := range info.Methods {
+			methodDesc := serviceDesc.Methods().ByName(protoreflect.Name(method.Name))
+			if methodDesc == nil || methodDesc.Options() == nil {
+				continue
+			}
+
+			if !proto.HasExtension(methodDesc.Options(), util.E_Method) {
+				continue
+			}
+
+			if opts, ok := proto.GetExtension(methodDesc.Options(), util.E_Method).(*util.MethodOptions); ok && opts != nil {
+				resolver.options["/"+name+"/"+method.Name] = opts
+			}
+		}
+	}
+
+	return resolver
+}
+
+// Get options of method with full method name like /pkg.Service/Method, nil if method is not annotated.
+func (resolver *MethodOptionsResolver) Get(fullMethod string) *util.MethodOptions {
+	if resolver == nil {
+		return nil
+	}
+
+	return resolver.options[fullMethod]
+}
+
+// Methods returns sorted full method names which are annotated.
+func (resolver *MethodOptionsResolver) Methods() []string {
+	res := make([]string, 0)
+	if resolver == nil {
+		return res
+	}
+
+	for k := range resolver.options {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+
+	return res
+}
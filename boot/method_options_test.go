@@ -0,0 +1,122 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-entry/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/boot/api/third_party/gen/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Files with service ut.v1.Admin, method Delete is annotated with opts.
+func newMethodOptionsFiles(t *testing.T, opts *util.MethodOptions) *protoregistry.Files {
+	methodOpts := &descriptorpb.MethodOptions{}
+	proto.SetExtension(methodOpts, util.E_Method, opts)
+
+	empty := proto.String(".google.protobuf.Empty")
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("ut/v1/admin.proto"),
+		Package:    proto.String("ut.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/empty.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Admin"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Delete"), InputType: empty, OutputType: empty, Options: methodOpts},
+				{Name: proto.String("Get"), InputType: empty, OutputType: empty},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	assert.Nil(t, err)
+
+	files := &protoregistry.Files{}
+	assert.Nil(t, files.RegisterFile(file))
+
+	return files
+}
+
+func TestMethodOptionsResolver(t *testing.T) {
+	files := newMethodOptionsFiles(t, &util.MethodOptions{Auth: util.Auth_JWT, TimeoutMs: 500})
+	services := map[string]grpc.ServiceInfo{
+		"ut.v1.Admin": {
+			Methods: []grpc.MethodInfo{{Name: "Delete"}, {Name: "Get"}},
+		},
+		"ut.v1.NotExist": {
+			Methods: []grpc.MethodInfo{{Name: "Get"}},
+		},
+	}
+
+	resolver := newMethodOptionsResolver(services, files)
+	assert.Equal(t, []string{"/ut.v1.Admin/Delete"}, resolver.Methods())
+	assert.Equal(t, util.Auth_JWT, resolver.Get("/ut.v1.Admin/Delete").GetAuth())
+	assert.Equal(t, int64(500), resolver.Get("/ut.v1.Admin/Delete").GetTimeoutMs())
+	assert.Nil(t, resolver.Get("/ut.v1.Admin/Get"))
+
+	// with nil resolver
+	resolver = nil
+	assert.Empty(t, resolver.Methods())
+	assert.Nil(t, resolver.Get("/ut.v1.Admin/Delete"))
+
+	// with server without services
+	assert.Empty(t, NewMethodOptionsResolver(grpc.NewServer()).Methods())
+}
+
+func TestGrpcEntry_applyMethodOptions(t *testing.T) {
+	entries, err := NewGrpcEntriesFromYAML([]byte(`
+---
+grpc:
+  - name: ut-method-options
+    enabled: true
+    middleware:
+      auth:
+        enabled: false
+        apiKey: ["ut-key"]
+      policies:
+        - methods: ["/ut.v1.Admin/Get"]
+          auth:
+            enabled: true
+            apiKey: ["ut-key"]
+`))
+	assert.Nil(t, err)
+	entry := entries["ut-method-options"]
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	// annotated with options which conflicts policies in boot config
+	files := newMethodOptionsFiles(t, &util.MethodOptions{Auth: util.Auth_BASIC})
+	entry.applyMethodOptions(newMethodOptionsResolver(map[string]grpc.ServiceInfo{
+		"ut.v1.Admin": {
+			Methods: []grpc.MethodInfo{{Name: "Delete"}, {Name: "Get"}},
+		},
+	}, files))
+
+	call := func(method, apiKey string) error {
+		ctx := context.TODO()
+		if len(apiKey) > 0 {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(rkmid.HeaderApiKey, apiKey))
+		}
+		// logging, panic, trace, jwt and auth
+		_, err := entry.UnaryInterceptors[4](ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		return err
+	}
+
+	// auth is enabled by options
+	assert.NotNil(t, call("/ut.v1.Admin/Delete", ""))
+	assert.Nil(t, call("/ut.v1.Admin/Delete", "ut-key"))
+	// auth is disabled in middleware section
+	assert.Nil(t, call("/ut.v1.Greeter/Greeter", ""))
+}
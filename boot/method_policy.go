@@ -0,0 +1,474 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/auth"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/jwt"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/log"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/ratelimit"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/timeout"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/tracing"
+	"github.com/rookie-ninja/rk-grpc/v2/boot/api/third_party/gen/v1"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/auth"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/context"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/jwt"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/log"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/ratelimit"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/timeout"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/tracing"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// BootConfigMethodPolicy Boot config which overrides middlewares for methods.
+//
+// Methods are matched with rkgrpcmid.MatchMethod, like /pkg.Service/Method, /pkg.Service/* or pkg.Service.
+// Middleware which is missing in policy inherits config in middleware section. Middleware present in policy of
+// YAML is enabled unless enabled: false is set explicitly, which skips middleware for matched methods, config of
+// enabled middleware in policy replaces config in middleware section.
+// Policies are evaluated for each middleware in order, first matched policy which contains the middleware wins.
+//
+// LogPayload adds request and response of unary methods into event of logging middleware.
+type BootConfigMethodPolicy struct {
+	Methods    []string                 `yaml:"methods" json:"methods"`
+	Logging    *rkmidlog.BootConfig     `yaml:"logging" json:"logging"`
+	LogPayload bool                     `yaml:"logPayload" json:"logPayload"`
+	Trace      *rkmidtrace.BootConfig   `yaml:"trace" json:"trace"`
+	Jwt        *rkmidjwt.BootConfig     `yaml:"jwt" json:"jwt"`
+	Auth       *rkmidauth.BootConfig    `yaml:"auth" json:"auth"`
+	Timeout    *rkmidtimeout.BootConfig `yaml:"timeout" json:"timeout"`
+	RateLimit  *rkmidlimit.BootConfig   `yaml:"rateLimit" json:"rateLimit"`
+}
+
+// Validate policy, methods must not be empty and must be valid patterns.
+func (policy *BootConfigMethodPolicy) validate() error {
+	if len(policy.Methods) < 1 {
+		return errors.New("methods is missing")
+	}
+
+	for _, method := range policy.Methods {
+		if _, err := path.Match(method, ""); err != nil {
+			return fmt.Errorf("invalid method pattern %s", method)
+		}
+	}
+
+	return nil
+}
+
+// Set enabled of middlewares which are present in decoded policy map without enabled.
+func (policy *BootConfigMethodPolicy) enableMissing(decoded map[string]interface{}) {
+	missing := func(key string) bool {
+		block, ok := decoded[key].(map[interface{}]interface{})
+		if !ok {
+			return false
+		}
+		_, ok = block["enabled"]
+		return !ok
+	}
+
+	if policy.Logging != nil && missing("logging") {
+		policy.Logging.Enabled = true
+	}
+	if policy.Trace != nil && missing("trace") {
+		policy.Trace.Enabled = true
+	}
+	if policy.Jwt != nil && missing("jwt") {
+		policy.Jwt.Enabled = true
+	}
+	if policy.Auth != nil && missing("auth") {
+		policy.Auth.Enabled = true
+	}
+	if policy.Timeout != nil && missing("timeout") {
+		policy.Timeout.Enabled = true
+	}
+	if policy.RateLimit != nil && missing("ratelimit") {
+		policy.RateLimit.Enabled = true
+	}
+}
+
+// bootConfigPolicies is method policies of boot config decoded as maps, which tells whether enabled is present.
+type bootConfigPolicies struct {
+	Grpc []struct {
+		Middleware struct {
+			Policies []map[string]interface{} `yaml:"policies" json:"policies"`
+		} `yaml:"middleware" json:"middleware"`
+	} `yaml:"grpc" json:"grpc"`
+}
+
+// Enable middlewares of method policies in decoded config which are present without enabled, since enabled is
+// false if missing. Policies are decoded as maps with rkentry as well, so that overrides of env and flags are kept.
+func enableMethodPolicies(raw []byte, config *BootConfig) {
+	decoded := &bootConfigPolicies{}
+	rkentry.UnmarshalBootYAML(raw, decoded)
+
+	for i := range config.Grpc {
+		if i >= len(decoded.Grpc) {
+			return
+		}
+
+		policies := decoded.Grpc[i].Middleware.Policies
+		for j := range config.Grpc[i].Middleware.Policies {
+			if j < len(policies) {
+				config.Grpc[i].Middleware.Policies[j].enableMissing(policies[j])
+			}
+		}
+	}
+}
+
+// Convert rk.api.v1.method options of method into policy, zero values inherit config of global policy.
+func toMethodPolicy(fullMethod string, opts *util.MethodOptions, global *BootConfigMethodPolicy) BootConfigMethodPolicy {
+	policy := BootConfigMethodPolicy{
+		Methods:    []string{fullMethod},
+		LogPayload: opts.GetLogPayload(),
+	}
+
+	switch opts.GetAuth() {
+	case util.Auth_NONE:
+		policy.Jwt = &rkmidjwt.BootConfig{}
+		policy.Auth = &rkmidauth.BootConfig{}
+	case util.Auth_JWT:
+		jwt := *global.Jwt
+		jwt.Enabled = true
+		policy.Jwt = &jwt
+		policy.Auth = &rkmidauth.BootConfig{}
+	case util.Auth_BASIC:
+		auth := *global.Auth
+		auth.Enabled = true
+		policy.Jwt = &rkmidjwt.BootConfig{}
+		policy.Auth = &auth
+	}
+
+	if opts.GetTimeoutMs() > 0 {
+		timeout := *global.Timeout
+		timeout.Enabled = true
+		timeout.TimeoutMs = int(opts.GetTimeoutMs())
+		timeout.Paths = nil
+		policy.Timeout = &timeout
+	}
+
+	if opts.GetRateLimit() > 0 {
+		reqPerSec := int(opts.GetRateLimit())
+		limit := *global.RateLimit
+		limit.Enabled = true
+		limit.ReqPerSec = &reqPerSec
+		limit.Paths = nil
+		policy.RateLimit = &limit
+	}
+
+	return policy
+}
+
+// methodPolicyRule is interceptor of middleware for matched methods, nil interceptor means middleware is skipped.
+type methodPolicyRule struct {
+	methods []string
+	unary   grpc.UnaryServerInterceptor
+	stream  grpc.StreamServerInterceptor
+}
+
+// Match method with rule, rule without methods matches all methods.
+func (rule *methodPolicyRule) match(method string) bool {
+	if len(rule.methods) < 1 {
+		return true
+	}
+
+	for i := range rule.methods {
+		if rkgrpcmid.MatchMethod(rule.methods[i], method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// methodPolicySlot chooses interceptor of one middleware for each method.
+//
+// Rules of policies are matched in order, rule of global policy is used if none of them matches.
+type methodPolicySlot struct {
+	build  func(*BootConfigMethodPolicy) *methodPolicyRule
+	global *methodPolicyRule
+	rules  []*methodPolicyRule
+	cache  map[string]*methodPolicyRule
+	lock   sync.RWMutex
+}
+
+// Create slot with builder of rules, builder returns nil if policy doesn't contain the middleware.
+func newMethodPolicySlot(global *BootConfigMethodPolicy, build func(*BootConfigMethodPolicy) *methodPolicyRule) *methodPolicySlot {
+	slot := &methodPolicySlot{
+		build: build,
+		cache: make(map[string]*methodPolicyRule),
+	}
+
+	if slot.global = build(global); slot.global == nil {
+		slot.global = &methodPolicyRule{}
+	}
+	slot.global.methods = nil
+
+	return slot
+}
+
+// Add rules of policies after existing ones.
+func (slot *methodPolicySlot) add(policies ...BootConfigMethodPolicy) {
+	slot.lock.Lock()
+	defer slot.lock.Unlock()
+
+	for i := range policies {
+		if rule := slot.build(&policies[i]); rule != nil {
+			slot.rules = append(slot.rules, rule)
+		}
+	}
+
+	slot.cache = make(map[string]*methodPolicyRule)
+}
+
+// Return first matched rule.
+func (slot *methodPolicySlot) get(method string) *methodPolicyRule {
+	slot.lock.RLock()
+	rule, ok := slot.cache[method]
+	slot.lock.RUnlock()
+	if ok {
+		return rule
+	}
+
+	slot.lock.Lock()
+	defer slot.lock.Unlock()
+
+	rule = slot.global
+	for i := range slot.rules {
+		if slot.rules[i].match(method) {
+			rule = slot.rules[i]
+			break
+		}
+	}
+	slot.cache[method] = rule
+
+	return rule
+}
+
+// Call interceptor of matched rule, handler is called directly if middleware is skipped.
+func (slot *methodPolicySlot) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if rule := slot.get(info.FullMethod); rule.unary != nil {
+		return rule.unary(ctx, req, info, handler)
+	}
+
+	return handler(ctx, req)
+}
+
+// Call interceptor of matched rule, handler is called directly if middleware is skipped.
+func (slot *methodPolicySlot) stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if rule := slot.get(info.FullMethod); rule.stream != nil {
+		return rule.stream(srv, stream, info, handler)
+	}
+
+	return handler(srv, stream)
+}
+
+// methodPolicySet is slots of middlewares built from one boot config, keyed with lower cased names of interceptors.
+type methodPolicySet struct {
+	global BootConfigMethodPolicy
+	slots  map[string]*methodPolicySlot
+}
+
+// methodPolicies holds slots of middlewares which are able to be overridden for methods.
+//
+// Interceptors of slots are added into entry even if middleware is disabled, since it could be enabled by
+// rk.api.v1.method options at Bootstrap or by reloaded boot config. Reloaded slots are published as a whole,
+// interceptors never see slots of different configs at the same time.
+type methodPolicies struct {
+	current atomic.Value
+}
+
+// Create slots of all middlewares with config in middleware section.
+func newMethodPolicies(global BootConfigMethodPolicy, entryName string,
+	loggerEntry *rkentry.LoggerEntry, eventEntry *rkentry.EventEntry) *methodPolicies {
+	set := &methodPolicySet{
+		global: global,
+		slots:  make(map[string]*methodPolicySlot),
+	}
+
+	// LogPayload of policy enables logging middleware with global config
+	set.slots[InterceptorLogging] = newMethodPolicySlot(&set.global, func(policy *BootConfigMethodPolicy) *methodPolicyRule {
+		config := policy.Logging
+		if config == nil && policy.LogPayload {
+			config = set.global.Logging
+		}
+		if config == nil {
+			return nil
+		}
+
+		rule := &methodPolicyRule{methods: policy.Methods}
+		if config.Enabled {
+			rule.unary = rkgrpclog.UnaryServerInterceptor(
+				rkmidlog.ToOptions(config, entryName, GrpcEntryType, loggerEntry, eventEntry)...)
+			rule.stream = rkgrpclog.StreamServerInterceptor(
+				rkmidlog.ToOptions(config, entryName, GrpcEntryType, loggerEntry, eventEntry)...)
+			if policy.LogPayload {
+				rule.unary = logPayload(rule.unary)
+			}
+		}
+		return rule
+	})
+
+	set.slots[InterceptorTrace] = newMethodPolicySlot(&set.global, func(policy *BootConfigMethodPolicy) *methodPolicyRule {
+		if policy.Trace == nil {
+			return nil
+		}
+
+		rule := &methodPolicyRule{methods: policy.Methods}
+		if policy.Trace.Enabled {
+			rule.unary = rkgrpctrace.UnaryServerInterceptor(
+				rkmidtrace.ToOptions(policy.Trace, entryName, GrpcEntryType)...)
+			rule.stream = rkgrpctrace.StreamServerInterceptor(
+				rkmidtrace.ToOptions(policy.Trace, entryName, GrpcEntryType)...)
+		}
+		return rule
+	})
+
+	set.slots[InterceptorJwt] = newMethodPolicySlot(&set.global, func(policy *BootConfigMethodPolicy) *methodPolicyRule {
+		if policy.Jwt == nil {
+			return nil
+		}
+
+		rule := &methodPolicyRule{methods: policy.Methods}
+		if policy.Jwt.Enabled {
+			rule.unary = rkgrpcjwt.UnaryServerInterceptor(
+				rkmidjwt.ToOptions(policy.Jwt, entryName, GrpcEntryType)...)
+			rule.stream = rkgrpcjwt.StreamServerInterceptor(
+				rkmidjwt.ToOptions(policy.Jwt, entryName, GrpcEntryType)...)
+		}
+		return rule
+	})
+
+	set.slots[InterceptorAuth] = newMethodPolicySlot(&set.global, func(policy *BootConfigMethodPolicy) *methodPolicyRule {
+		if policy.Auth == nil {
+			return nil
+		}
+
+		rule := &methodPolicyRule{methods: policy.Methods}
+		if policy.Auth.Enabled {
+			rule.unary = rkgrpcauth.UnaryServerInterceptor(
+				rkmidauth.ToOptions(policy.Auth, entryName, GrpcEntryType)...)
+			rule.stream = rkgrpcauth.StreamServerInterceptor(
+				rkmidauth.ToOptions(policy.Auth, entryName, GrpcEntryType)...)
+		}
+		return rule
+	})
+
+	set.slots[InterceptorTimeout] = newMethodPolicySlot(&set.global, func(policy *BootConfigMethodPolicy) *methodPolicyRule {
+		if policy.Timeout == nil {
+			return nil
+		}
+
+		rule := &methodPolicyRule{methods: policy.Methods}
+		if policy.Timeout.Enabled {
+			rule.unary = rkgrpctimeout.UnaryServerInterceptor(
+				rkmidtimeout.ToOptions(policy.Timeout, entryName, GrpcEntryType)...)
+			rule.stream = rkgrpctimeout.StreamServerInterceptor(
+				rkmidtimeout.ToOptions(policy.Timeout, entryName, GrpcEntryType)...)
+		}
+		return rule
+	})
+
+	// limiters are not shared between policies
+	set.slots[strings.ToLower(InterceptorRateLimit)] = newMethodPolicySlot(&set.global, func(policy *BootConfigMethodPolicy) *methodPolicyRule {
+		if policy.RateLimit == nil {
+			return nil
+		}
+
+		rule := &methodPolicyRule{methods: policy.Methods}
+		if policy.RateLimit.Enabled {
+			rule.unary = rkgrpclimit.UnaryServerInterceptor(
+				rkmidlimit.ToOptions(policy.RateLimit, entryName, GrpcEntryType)...)
+			rule.stream = rkgrpclimit.StreamServerInterceptor(
+				rkmidlimit.ToOptions(policy.RateLimit, entryName, GrpcEntryType)...)
+		}
+		return rule
+	})
+
+	policies := &methodPolicies{}
+	policies.current.Store(set)
+
+	return policies
+}
+
+// Return current slots.
+func (policies *methodPolicies) load() *methodPolicySet {
+	return policies.current.Load().(*methodPolicySet)
+}
+
+// Return interceptors which call slot of middleware in current slots, name is one of built-in interceptors.
+func (policies *methodPolicies) interceptors(name string) *namedInterceptor {
+	name = strings.ToLower(name)
+
+	return &namedInterceptor{
+		unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return policies.load().slots[name].unary(ctx, req, info, handler)
+		},
+		stream: func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return policies.load().slots[name].stream(srv, stream, info, handler)
+		},
+	}
+}
+
+// Add rules of policies into all slots.
+func (policies *methodPolicies) add(elements ...BootConfigMethodPolicy) {
+	for _, slot := range policies.load().slots {
+		slot.add(elements...)
+	}
+}
+
+// Add policies converted from rk.api.v1.method options, which override middleware section but not policies
+// in boot config.
+func (policies *methodPolicies) addMethodOptions(resolver *MethodOptionsResolver) {
+	if resolver == nil {
+		return
+	}
+
+	set := policies.load()
+	elements := make([]BootConfigMethodPolicy, 0)
+	for _, method := range resolver.Methods() {
+		elements = append(elements, toMethodPolicy(method, resolver.Get(method), &set.global))
+	}
+
+	policies.add(elements...)
+}
+
+// Replace slots with ones in next at once, interceptors which are already added into server are kept.
+func (policies *methodPolicies) swap(next *methodPolicies) {
+	policies.current.Store(next.load())
+}
+
+// Apply rk.api.v1.method options on slots of entry.
+func (entry *GrpcEntry) applyMethodOptions(resolver *MethodOptionsResolver) {
+	if entry.methodPolicies == nil {
+		return
+	}
+
+	entry.methodPolicies.addMethodOptions(resolver)
+}
+
+// Add request and response of unary methods into event of logging middleware.
+func logPayload(logging grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return logging(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			event := rkgrpcctx.GetEvent(ctx)
+			event.AddPayloads(zap.Any("request", req))
+			resp, err := handler(ctx, req)
+			event.AddPayloads(zap.Any("response", resp))
+			return resp, err
+		})
+	}
+}
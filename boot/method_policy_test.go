@@ -0,0 +1,273 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-entry/v2/middleware"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/auth"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/jwt"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/log"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/ratelimit"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/timeout"
+	"github.com/rookie-ninja/rk-grpc/v2/boot/api/third_party/gen/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestMethodPolicySlot(t *testing.T) {
+	called := ""
+	unary := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			called = name
+			return handler(ctx, req)
+		}
+	}
+	stream := func(name string) grpc.StreamServerInterceptor {
+		return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			called = name
+			return handler(srv, ss)
+		}
+	}
+	unaryHandler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	streamHandler := func(interface{}, grpc.ServerStream) error { return nil }
+
+	// policies with api key of auth middleware as name of interceptor
+	build := func(policy *BootConfigMethodPolicy) *methodPolicyRule {
+		switch {
+		case policy.Auth == nil:
+			return nil
+		case !policy.Auth.Enabled:
+			return &methodPolicyRule{methods: policy.Methods}
+		default:
+			return &methodPolicyRule{
+				methods: policy.Methods,
+				unary:   unary(policy.Auth.ApiKey[0]),
+				stream:  stream(policy.Auth.ApiKey[0]),
+			}
+		}
+	}
+
+	slot := newMethodPolicySlot(&BootConfigMethodPolicy{
+		Auth: &rkmidauth.BootConfig{Enabled: true, ApiKey: []string{"global"}},
+	}, build)
+	slot.add(
+		BootConfigMethodPolicy{Methods: []string{"/grpc.health.v1.Health/*"}, Auth: &rkmidauth.BootConfig{}},
+		BootConfigMethodPolicy{Methods: []string{"api.v1.Admin"}, Timeout: &rkmidtimeout.BootConfig{}},
+		BootConfigMethodPolicy{Methods: []string{"api.v1.Admin"}, Auth: &rkmidauth.BootConfig{Enabled: true, ApiKey: []string{"admin"}}})
+
+	cases := map[string]string{
+		"/grpc.health.v1.Health/Check": "",
+		"/api.v1.Admin/Delete":         "admin",
+		"/api.v1.Greeter/Greeter":      "global",
+	}
+
+	for method, expect := range cases {
+		called = ""
+		slot.unary(context.TODO(), nil, &grpc.UnaryServerInfo{FullMethod: method}, unaryHandler)
+		assert.Equal(t, expect, called, method)
+
+		called = ""
+		slot.stream(nil, nil, &grpc.StreamServerInfo{FullMethod: method}, streamHandler)
+		assert.Equal(t, expect, called, method)
+	}
+
+	// with middleware disabled in middleware section
+	slot = newMethodPolicySlot(&BootConfigMethodPolicy{}, build)
+	called = ""
+	slot.unary(context.TODO(), nil, &grpc.UnaryServerInfo{FullMethod: "/api.v1.Admin/Delete"}, unaryHandler)
+	assert.Empty(t, called)
+}
+
+func TestToMethodPolicy(t *testing.T) {
+	reqPerSec := 10
+	global := &BootConfigMethodPolicy{
+		Jwt:       &rkmidjwt.BootConfig{SignerEntry: "ut-signer"},
+		Auth:      &rkmidauth.BootConfig{ApiKey: []string{"ut-key"}},
+		Timeout:   &rkmidtimeout.BootConfig{Enabled: true, TimeoutMs: 1000},
+		RateLimit: &rkmidlimit.BootConfig{Algorithm: "leakyBucket", ReqPerSec: &reqPerSec},
+	}
+
+	// with zero values
+	policy := toMethodPolicy("/api.v1.Admin/Delete", &util.MethodOptions{}, global)
+	assert.Equal(t, []string{"/api.v1.Admin/Delete"}, policy.Methods)
+	assert.Nil(t, policy.Jwt)
+	assert.Nil(t, policy.Auth)
+	assert.Nil(t, policy.Timeout)
+	assert.Nil(t, policy.RateLimit)
+	assert.False(t, policy.LogPayload)
+
+	// with jwt
+	policy = toMethodPolicy("/api.v1.Admin/Delete", &util.MethodOptions{
+		Auth:       util.Auth_JWT,
+		TimeoutMs:  500,
+		RateLimit:  100,
+		LogPayload: true,
+	}, global)
+	assert.True(t, policy.Jwt.Enabled)
+	assert.Equal(t, "ut-signer", policy.Jwt.SignerEntry)
+	assert.False(t, policy.Auth.Enabled)
+	assert.Equal(t, 500, policy.Timeout.TimeoutMs)
+	assert.Equal(t, 100, *policy.RateLimit.ReqPerSec)
+	assert.Equal(t, "leakyBucket", policy.RateLimit.Algorithm)
+	assert.True(t, policy.LogPayload)
+	// global config is not modified
+	assert.False(t, global.Jwt.Enabled)
+	assert.Equal(t, 10, *global.RateLimit.ReqPerSec)
+
+	// with basic auth
+	policy = toMethodPolicy("/api.v1.Admin/Delete", &util.MethodOptions{Auth: util.Auth_BASIC}, global)
+	assert.False(t, policy.Jwt.Enabled)
+	assert.True(t, policy.Auth.Enabled)
+	assert.Equal(t, []string{"ut-key"}, policy.Auth.ApiKey)
+
+	// without auth
+	policy = toMethodPolicy("/api.v1.Admin/Delete", &util.MethodOptions{Auth: util.Auth_NONE}, global)
+	assert.False(t, policy.Jwt.Enabled)
+	assert.False(t, policy.Auth.Enabled)
+}
+
+func TestRegisterGrpcEntryYAML_WithMethodPolicies(t *testing.T) {
+	entries, err := NewGrpcEntriesFromYAML([]byte(`
+---
+grpc:
+  - name: ut-method-policy
+    enabled: true
+    middleware:
+      auth:
+        enabled: true
+        apiKey: ["global-key"]
+      policies:
+        - methods: ["/grpc.health.v1.Health/*"]
+          auth:
+            enabled: false
+        - methods: ["api.v1.Admin"]
+          auth:
+            apiKey: ["admin-key"]
+        - methods: ["/api.v1.Upload/Upload"]
+          timeout:
+            timeoutMs: 60000
+`))
+	assert.Nil(t, err)
+	entry := entries["ut-method-policy"]
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	// logging, panic, trace, jwt, auth, timeout and rate limit
+	assert.Len(t, entry.UnaryInterceptors, 7)
+	assert.Len(t, entry.StreamInterceptors, 7)
+
+	call := func(method, apiKey string) error {
+		ctx := context.TODO()
+		if len(apiKey) > 0 {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(rkmid.HeaderApiKey, apiKey))
+		}
+		_, err := entry.UnaryInterceptors[4](ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		return err
+	}
+
+	// auth is skipped
+	assert.Nil(t, call("/grpc.health.v1.Health/Check", ""))
+	// auth is re-parameterised
+	assert.Nil(t, call("/api.v1.Admin/Delete", "admin-key"))
+	assert.NotNil(t, call("/api.v1.Admin/Delete", "global-key"))
+	// auth of middleware section
+	assert.Nil(t, call("/api.v1.Greeter/Greeter", "global-key"))
+	assert.NotNil(t, call("/api.v1.Greeter/Greeter", ""))
+}
+
+func TestEnableMethodPolicies(t *testing.T) {
+	config := &BootConfig{}
+	assert.Nil(t, unmarshalBootYAML([]byte(`
+---
+grpc:
+  - name: ut-enable-policy
+    enabled: true
+    middleware:
+      timeout:
+        timeoutMs: 1000
+      policies:
+        - methods: ["/api.v1.Upload/Upload"]
+          timeout:
+            timeoutMs: 60000
+          rateLimit:
+            enabled: false
+`), config))
+
+	// middleware section is not changed
+	assert.False(t, config.Grpc[0].Middleware.Timeout.Enabled)
+
+	policy := config.Grpc[0].Middleware.Policies[0]
+	assert.True(t, policy.Timeout.Enabled)
+	assert.Equal(t, 60000, policy.Timeout.TimeoutMs)
+	assert.False(t, policy.RateLimit.Enabled)
+	assert.Nil(t, policy.Auth)
+
+	// policies which are not in YAML are not changed
+	config.Grpc[0].Middleware.Policies = append(config.Grpc[0].Middleware.Policies, BootConfigMethodPolicy{
+		Methods: []string{"/api.v1.Admin/*"},
+		Auth:    &rkmidauth.BootConfig{},
+	})
+	enableMethodPolicies([]byte("grpc: []\n"), config)
+	assert.False(t, config.Grpc[0].Middleware.Policies[1].Auth.Enabled)
+}
+
+func TestMethodPolicies_WithLogPayload(t *testing.T) {
+	slot := newMethodPolicies(BootConfigMethodPolicy{
+		Logging: &rkmidlog.BootConfig{Enabled: true},
+	}, "ut-log-payload", rkentry.GlobalAppCtx.GetLoggerEntryDefault(), rkentry.GlobalAppCtx.GetEventEntryDefault()).load().slots[InterceptorLogging]
+	slot.add(BootConfigMethodPolicy{Methods: []string{"/api.v1.Admin/Delete"}, LogPayload: true})
+
+	rule := slot.get("/api.v1.Admin/Delete")
+	assert.NotNil(t, rule.unary)
+	assert.NotEqual(t, slot.global, rule)
+
+	resp, err := slot.unary(context.TODO(), "request", &grpc.UnaryServerInfo{FullMethod: "/api.v1.Admin/Delete"},
+		func(context.Context, interface{}) (interface{}, error) { return "response", nil })
+	assert.Nil(t, err)
+	assert.Equal(t, "response", resp)
+
+	// without log payload
+	assert.Equal(t, slot.global, slot.get("/api.v1.Greeter/Greeter"))
+}
+
+func TestMethodPolicies_Swap(t *testing.T) {
+	loggerEntry, eventEntry := rkentry.GlobalAppCtx.GetLoggerEntryDefault(), rkentry.GlobalAppCtx.GetEventEntryDefault()
+	policies := newMethodPolicies(BootConfigMethodPolicy{
+		Auth: &rkmidauth.BootConfig{},
+	}, "ut-swap", loggerEntry, eventEntry)
+	next := newMethodPolicies(BootConfigMethodPolicy{
+		Auth: &rkmidauth.BootConfig{Enabled: true, ApiKey: []string{"ut-key"}},
+	}, "ut-swap", loggerEntry, eventEntry)
+
+	inter := policies.interceptors(InterceptorAuth)
+	call := func() error {
+		_, err := inter.unary(context.TODO(), nil, &grpc.UnaryServerInfo{FullMethod: "/api.v1.Admin/Delete"},
+			func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		return err
+	}
+
+	// auth is disabled before swapping
+	assert.Nil(t, call())
+
+	// interceptors which are already added see swapped slots while serving
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			call()
+		}
+	}()
+	policies.swap(next)
+	<-done
+
+	assert.NotNil(t, call())
+	assert.Equal(t, next.load(), policies.load())
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/context"
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// BootConfigMtls Boot config which is for mutual TLS of grpc entry.
+type BootConfigMtls struct {
+	Enabled    bool   `yaml:"enabled" json:"enabled"`
+	ClientAuth string `yaml:"clientAuth" json:"clientAuth"`
+	CertEntry  string `yaml:"certEntry" json:"certEntry"`
+}
+
+// Convert BootConfigMtls into client auth type and CA pool, CA is read from RootCA of CertEntry.
+func toMtlsOption(config *BootConfigMtls, certEntry *rkentry.CertEntry) (tls.ClientAuthType, *x509.CertPool, error) {
+	clientAuth, err := parseClientAuth(config.ClientAuth)
+	if err != nil {
+		return tls.NoClientCert, nil, err
+	}
+
+	if len(config.CertEntry) > 0 {
+		certEntry = rkentry.GlobalAppCtx.GetCertEntry(config.CertEntry)
+	}
+
+	var clientCAs *x509.CertPool
+	if certEntry != nil && certEntry.RootCA != nil {
+		clientCAs = x509.NewCertPool()
+		clientCAs.AddCert(certEntry.RootCA)
+	}
+
+	if clientCAs == nil && (clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert) {
+		return tls.NoClientCert, nil, errors.New("CA is missing in cert entry for mtls")
+	}
+
+	return clientAuth, clientCAs, nil
+}
+
+// Parse client auth type, RequireAndVerifyClientCert will be returned if empty.
+func parseClientAuth(str string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(str) {
+	case "", "requireandverify":
+		return tls.RequireAndVerifyClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verifyifgiven":
+		return tls.VerifyClientCertIfGiven, nil
+	case "none":
+		return tls.NoClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("invalid clientAuth %s, expect one of none, request, require, verifyIfGiven and requireAndVerify", str)
+	}
+}
+
+// listenerCreds exposes state of TLS terminated by listener as credentials.TLSInfo, it does not do handshake by itself.
+//
+// TLS of GrpcEntry is terminated by listener in order to share port with grpc-gateway,
+// without it, peer.AuthInfo would be empty in grpc context.
+type listenerCreds struct {
+	info credentials.ProtocolInfo
+}
+
+func newListenerCreds() credentials.TransportCredentials {
+	return &listenerCreds{
+		info: credentials.ProtocolInfo{
+			SecurityProtocol: "tls",
+			SecurityVersion:  "1.2",
+		},
+	}
+}
+
+// ClientHandshake is not supported.
+func (c *listenerCreds) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("client handshake is not supported")
+}
+
+// ServerHandshake returns state of TLS connection accepted by listener.
+func (c *listenerCreds) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	tlsConn := unwrapTlsConn(conn)
+	if tlsConn == nil {
+		return conn, nil, nil
+	}
+
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, nil, err
+	}
+
+	return conn, credentials.TLSInfo{
+		State: tlsConn.ConnectionState(),
+		CommonAuthInfo: credentials.CommonAuthInfo{
+			SecurityLevel: credentials.PrivacyAndIntegrity,
+		},
+	}, nil
+}
+
+// Info returns protocol info.
+func (c *listenerCreds) Info() credentials.ProtocolInfo {
+	return c.info
+}
+
+// Clone returns a copy.
+func (c *listenerCreds) Clone() credentials.TransportCredentials {
+	return &listenerCreds{info: c.info}
+}
+
+// OverrideServerName is deprecated and ignored.
+func (c *listenerCreds) OverrideServerName(string) error {
+	return nil
+}
+
+// Unwrap *tls.Conn from connection accepted by tls listener or cmux.
+func unwrapTlsConn(conn net.Conn) *tls.Conn {
+	for {
+		switch v := conn.(type) {
+		case *tls.Conn:
+			return v
+		case *cmux.MuxConn:
+			conn = v.Conn
+		default:
+			return nil
+		}
+	}
+}
+
+// Inject verified peer identity into server context payload.
+func peerIdentityUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx = rkgrpcmid.WrapContextForServer(ctx)
+	if identity := rkgrpcctx.GetPeerIdentity(ctx); identity != nil {
+		rkgrpcmid.AddToServerContextPayload(ctx, rkgrpcmid.PeerIdentityKey, identity)
+	}
+
+	return handler(ctx, req)
+}
+
+// Inject verified peer identity into server context payload.
+func peerIdentityStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	wrappedStream := rkgrpcctx.WrapServerStream(stream)
+	wrappedStream.WrappedContext = rkgrpcmid.WrapContextForServer(wrappedStream.WrappedContext)
+	if identity := rkgrpcctx.GetPeerIdentity(wrappedStream.WrappedContext); identity != nil {
+		rkgrpcmid.AddToServerContextPayload(wrappedStream.WrappedContext, rkgrpcmid.PeerIdentityKey, identity)
+	}
+
+	return handler(srv, wrappedStream)
+}
@@ -0,0 +1,225 @@
+//go:build !race
+// +build !race
+
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+package rkgrpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	testdata "github.com/rookie-ninja/rk-grpc/v2/example/middleware/proto/testdata"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestParseClientAuth(t *testing.T) {
+	cases := map[string]tls.ClientAuthType{
+		"":                 tls.RequireAndVerifyClientCert,
+		"requireAndVerify": tls.RequireAndVerifyClientCert,
+		"request":          tls.RequestClientCert,
+		"require":          tls.RequireAnyClientCert,
+		"verifyIfGiven":    tls.VerifyClientCertIfGiven,
+		"none":             tls.NoClientCert,
+	}
+
+	for str, expect := range cases {
+		clientAuth, err := parseClientAuth(str)
+		assert.Nil(t, err)
+		assert.Equal(t, expect, clientAuth)
+	}
+
+	_, err := parseClientAuth("invalid")
+	assert.NotNil(t, err)
+}
+
+func TestToMtlsOption(t *testing.T) {
+	ca, _ := newTestCA()
+	certEntry := &rkentry.CertEntry{RootCA: ca}
+
+	// happy case
+	clientAuth, clientCAs, err := toMtlsOption(&BootConfigMtls{Enabled: true}, certEntry)
+	assert.Nil(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, clientAuth)
+	assert.NotNil(t, clientCAs)
+
+	// without CA
+	_, _, err = toMtlsOption(&BootConfigMtls{Enabled: true}, nil)
+	assert.NotNil(t, err)
+
+	// without CA, but no need to verify
+	clientAuth, clientCAs, err = toMtlsOption(&BootConfigMtls{Enabled: true, ClientAuth: "require"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, tls.RequireAnyClientCert, clientAuth)
+	assert.Nil(t, clientCAs)
+
+	// invalid client auth
+	_, _, err = toMtlsOption(&BootConfigMtls{Enabled: true, ClientAuth: "invalid"}, certEntry)
+	assert.NotNil(t, err)
+}
+
+func TestRegisterGrpcEntryYAML_WithInvalidMtls(t *testing.T) {
+	defer assertPanic(t)
+
+	configFile := `
+---
+grpc:
+  - name: ut-grpc-mtls
+    port: 1962
+    enabled: true
+    mtls:
+      enabled: true
+`
+	RegisterGrpcEntryYAML([]byte(configFile))
+}
+
+func TestGrpcEntry_Mtls(t *testing.T) {
+	defer assertNotPanic(t)
+
+	ca, caKey := newTestCA()
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	serverCert := newTestLeaf(ca, caKey, "ut-server", nil)
+	clientCert := newTestLeaf(ca, caKey, "ut-client", &url.URL{Scheme: "spiffe", Host: "ut.org", Path: "/ns/ut/sa/client"})
+
+	certEntry := rkentry.RegisterCertEntry(&rkentry.BootCert{
+		Cert: []*rkentry.BootCertE{{Name: "ut-mtls-cert"}},
+	})[0]
+	certEntry.Certificate = &serverCert
+	certEntry.RootCA = ca
+	defer rkentry.GlobalAppCtx.RemoveEntry(certEntry)
+
+	entry := RegisterGrpcEntry(
+		WithName("ut-mtls"),
+		WithPort(1961),
+		WithCertEntry(certEntry),
+		WithMtls(tls.RequireAndVerifyClientCert, pool))
+	entry.AddRegFuncGrpc(func(server *grpc.Server) {
+		testdata.RegisterGreeterServer(server, &PeerGreeterServer{})
+	})
+	assert.Equal(t, tls.RequireAndVerifyClientCert, entry.TlsConfig.ClientAuth)
+
+	entry.Bootstrap(context.TODO())
+	defer entry.Interrupt(context.TODO())
+	time.Sleep(time.Second)
+
+	// with client certificate
+	conn, err := grpc.Dial("localhost:1961", grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		Certificates:       []tls.Certificate{clientCert},
+		InsecureSkipVerify: true,
+	})))
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	resp, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "spiffe://ut.org/ns/ut/sa/client", resp.GetMessage())
+
+	// without client certificate
+	connNoCert, err := grpc.Dial("localhost:1961", grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		InsecureSkipVerify: true,
+	})))
+	assert.Nil(t, err)
+	defer connNoCert.Close()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	_, err = testdata.NewGreeterClient(connNoCert).SayHello(ctx, &testdata.HelloRequest{})
+	assert.NotNil(t, err)
+}
+
+func TestListenerCreds(t *testing.T) {
+	creds := newListenerCreds()
+	assert.Equal(t, "tls", creds.Info().SecurityProtocol)
+	assert.Equal(t, creds.Info(), creds.Clone().Info())
+	assert.Nil(t, creds.OverrideServerName(""))
+
+	_, _, err := creds.ClientHandshake(context.TODO(), "", nil)
+	assert.NotNil(t, err)
+
+	// plain connection
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	conn, info, err := creds.ServerHandshake(server)
+	assert.Nil(t, err)
+	assert.Nil(t, info)
+	assert.Equal(t, server, conn)
+}
+
+// ************ Test utility ************
+
+// PeerGreeterServer returns SPIFFE ID of peer as message.
+type PeerGreeterServer struct{}
+
+// SayHello Handle SayHello method.
+func (server *PeerGreeterServer) SayHello(ctx context.Context, request *testdata.HelloRequest) (*testdata.HelloResponse, error) {
+	res := &testdata.HelloResponse{}
+	if identity := rkgrpcctx.GetPeerIdentity(ctx); identity != nil {
+		res.Message = identity.SpiffeId
+	}
+
+	return res, nil
+}
+
+func newTestCA() (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ut-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	cert, _ := x509.ParseCertificate(der)
+
+	return cert, key
+}
+
+func newTestLeaf(ca *x509.Certificate, caKey *ecdsa.PrivateKey, cn string, uri *url.URL) tls.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if uri != nil {
+		template.URIs = []*url.URL{uri}
+	}
+
+	der, _ := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+
+	cert, _ := tls.X509KeyPair(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+
+	return cert
+}
@@ -0,0 +1,332 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// BalancerRandom picks destination randomly
+	BalancerRandom = "random"
+	// BalancerRoundRobin picks destinations in turn
+	BalancerRoundRobin = "roundRobin"
+	// BalancerLeastRequest picks destination with the least in-flight calls
+	BalancerLeastRequest = "leastRequest"
+	// BalancerWeighted picks destinations in turn with weight like host:port;weight=3
+	BalancerWeighted = "weighted"
+	// BalancerConsistentHash picks destination with hash of metadata value, calls with the same value stick to
+	// the same destination
+	BalancerConsistentHash = "consistentHash"
+)
+
+// Number of virtual nodes of each weight in hash ring.
+const hashRingReplicas = 100
+
+// Balancer picks one of destinations of proxy pattern for each call.
+//
+// Destinations could be provided with weight like host:port;weight=3, address without weight is returned.
+// done is called once call finished.
+type Balancer interface {
+	Pick(ctx context.Context, dests []string) (dest string, done func())
+}
+
+// NewBalancer Create Balancer with name, random balancer is returned if name is empty.
+//
+// hashKey is key of metadata which is hashed by consistentHash balancer.
+func NewBalancer(name, hashKey string) (Balancer, error) {
+	switch name {
+	case "", BalancerRandom:
+		return NewRandomBalancer(), nil
+	case BalancerRoundRobin:
+		return NewRoundRobinBalancer(), nil
+	case BalancerLeastRequest:
+		return NewLeastRequestBalancer(), nil
+	case BalancerWeighted:
+		return NewWeightedBalancer(), nil
+	case BalancerConsistentHash:
+		if len(hashKey) < 1 {
+			return nil, errors.New("hashKey is missing for consistentHash balancer")
+		}
+		return NewConsistentHashBalancer(hashKey), nil
+	}
+
+	return nil, fmt.Errorf("unknown balancer %s, expect one of %s, %s, %s, %s and %s", name,
+		BalancerRandom, BalancerRoundRobin, BalancerLeastRequest, BalancerWeighted, BalancerConsistentHash)
+}
+
+// Balancer used by patterns without balancer.
+var defaultBalancer = NewRandomBalancer()
+
+// Pick destination with balancer, default balancer is used if nil.
+func pickDest(ctx context.Context, balancer Balancer, dests []string) (string, func()) {
+	if len(dests) < 1 {
+		return "", noopDone
+	}
+
+	if balancer == nil {
+		balancer = defaultBalancer
+	}
+
+	// skip unhealthy destinations, consistentHash balancer skips them by itself with hash ring of all destinations
+	if _, ok := balancer.(*consistentHashBalancer); !ok && ctx != nil {
+		dests = filterDests(ctx, dests)
+	}
+
+	dest, done := balancer.Pick(ctx, dests)
+	if done == nil {
+		done = noopDone
+	}
+
+	return dest, done
+}
+
+func noopDone() {}
+
+// parseProxyDest Parse destination like host:port;weight=3, weight is 1 if missing.
+func parseProxyDest(dest string) (addr string, weight int, err error) {
+	tokens := strings.Split(dest, ";")
+	addr, weight = strings.TrimSpace(tokens[0]), 1
+
+	for _, token := range tokens[1:] {
+		kv := strings.SplitN(strings.TrimSpace(token), "=", 2)
+		if len(kv) != 2 || kv[0] != "weight" {
+			return addr, 1, fmt.Errorf("unknown parameter %s in dest %s", token, dest)
+		}
+
+		weight, err = strconv.Atoi(kv[1])
+		if err != nil || weight < 1 {
+			return addr, 1, fmt.Errorf("invalid weight %s in dest %s, expect positive integer", kv[1], dest)
+		}
+	}
+
+	return addr, weight, nil
+}
+
+// Address of destination, weight is ignored.
+func proxyDestAddr(dest string) string {
+	addr, _, _ := parseProxyDest(dest)
+	return addr
+}
+
+// ***************** random *****************
+
+// NewRandomBalancer Create Balancer which picks destination randomly, weight is ignored.
+func NewRandomBalancer() Balancer {
+	return &randomBalancer{
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+type randomBalancer struct {
+	rand *rand.Rand
+	lock sync.Mutex
+}
+
+// Pick destination randomly.
+func (b *randomBalancer) Pick(ctx context.Context, dests []string) (string, func()) {
+	b.lock.Lock()
+	i := b.rand.Intn(len(dests))
+	b.lock.Unlock()
+
+	return proxyDestAddr(dests[i]), noopDone
+}
+
+// ***************** round robin *****************
+
+// NewRoundRobinBalancer Create Balancer which picks destinations in turn, weight is ignored.
+func NewRoundRobinBalancer() Balancer {
+	return &roundRobinBalancer{}
+}
+
+type roundRobinBalancer struct {
+	next uint64
+}
+
+// Pick next destination.
+func (b *roundRobinBalancer) Pick(ctx context.Context, dests []string) (string, func()) {
+	i := atomic.AddUint64(&b.next, 1) - 1
+	return proxyDestAddr(dests[i%uint64(len(dests))]), noopDone
+}
+
+// ***************** least request *****************
+
+// NewLeastRequestBalancer Create Balancer which picks destination with the least in-flight calls,
+// destinations with the same number of calls are picked in turn. Weight is ignored.
+func NewLeastRequestBalancer() Balancer {
+	return &leastRequestBalancer{
+		outstanding: make(map[string]int),
+	}
+}
+
+type leastRequestBalancer struct {
+	outstanding map[string]int
+	next        int
+	lock        sync.Mutex
+}
+
+// Pick destination with the least in-flight calls.
+func (b *leastRequestBalancer) Pick(ctx context.Context, dests []string) (string, func()) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	offset := b.next % len(dests)
+	b.next++
+
+	res := ""
+	for i := range dests {
+		addr := proxyDestAddr(dests[(offset+i)%len(dests)])
+		if len(res) < 1 || b.outstanding[addr] < b.outstanding[res] {
+			res = addr
+		}
+	}
+	b.outstanding[res]++
+
+	var once sync.Once
+	return res, func() {
+		once.Do(func() {
+			b.lock.Lock()
+			defer b.lock.Unlock()
+
+			if b.outstanding[res]--; b.outstanding[res] < 1 {
+				delete(b.outstanding, res)
+			}
+		})
+	}
+}
+
+// ***************** weighted *****************
+
+// NewWeightedBalancer Create Balancer which picks destinations in turn with weight like host:port;weight=3,
+// picks are spread with smooth weighted round-robin.
+func NewWeightedBalancer() Balancer {
+	return &weightedBalancer{
+		current: make(map[string]int),
+	}
+}
+
+type weightedBalancer struct {
+	current map[string]int
+	lock    sync.Mutex
+}
+
+// Pick destination with the highest current weight.
+func (b *weightedBalancer) Pick(ctx context.Context, dests []string) (string, func()) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	res, total := "", 0
+	for i := range dests {
+		addr, weight, _ := parseProxyDest(dests[i])
+		b.current[addr] += weight
+		total += weight
+
+		if len(res) < 1 || b.current[addr] > b.current[res] {
+			res = addr
+		}
+	}
+	b.current[res] -= total
+
+	return res, noopDone
+}
+
+// ***************** consistent hash *****************
+
+// NewConsistentHashBalancer Create Balancer which picks destination with hash of metadata value of key,
+// destination with higher weight owns more virtual nodes in hash ring. Calls without the key are picked randomly.
+func NewConsistentHashBalancer(key string) Balancer {
+	return &consistentHashBalancer{
+		key:      strings.ToLower(key),
+		fallback: NewRandomBalancer(),
+	}
+}
+
+type consistentHashBalancer struct {
+	key      string
+	fallback Balancer
+	dests    string
+	ring     []uint32
+	nodes    map[uint32]string
+	lock     sync.Mutex
+}
+
+// Pick destination which owns the first virtual node after hash of metadata value.
+//
+// Hash ring is built with all destinations, virtual nodes of unhealthy destinations are skipped while looking up,
+// so that calls of healthy destinations stick to them while others are ejected or recovered.
+func (b *consistentHashBalancer) Pick(ctx context.Context, dests []string) (string, func()) {
+	healthy := dests
+	if ctx != nil {
+		healthy = filterDests(ctx, dests)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(b.key)
+	if len(values) < 1 {
+		return b.fallback.Pick(ctx, healthy)
+	}
+
+	allowed := make(map[string]bool, len(healthy))
+	for i := range healthy {
+		allowed[proxyDestAddr(healthy[i])] = true
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// rebuild ring once destinations changed
+	if joined := strings.Join(dests, ","); joined != b.dests {
+		b.dests = joined
+		b.build(dests)
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(values[0]))
+	i := sort.Search(len(b.ring), func(i int) bool {
+		return b.ring[i] >= hash
+	})
+
+	for j := 0; j < len(b.ring); j++ {
+		if addr := b.nodes[b.ring[(i+j)%len(b.ring)]]; allowed[addr] {
+			return addr, noopDone
+		}
+	}
+
+	return proxyDestAddr(healthy[0]), noopDone
+}
+
+// Build hash ring with virtual nodes of destinations.
+func (b *consistentHashBalancer) build(dests []string) {
+	b.ring = make([]uint32, 0)
+	b.nodes = make(map[uint32]string)
+
+	for i := range dests {
+		addr, weight, _ := parseProxyDest(dests[i])
+		for j := 0; j < hashRingReplicas*weight; j++ {
+			hash := crc32.ChecksumIEEE([]byte(addr + "#" + strconv.Itoa(j)))
+			if _, ok := b.nodes[hash]; ok {
+				continue
+			}
+			b.nodes[hash] = addr
+			b.ring = append(b.ring, hash)
+		}
+	}
+
+	sort.Slice(b.ring, func(i, j int) bool {
+		return b.ring[i] < b.ring[j]
+	})
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestNewBalancer(t *testing.T) {
+	for name, expect := range map[string]interface{}{
+		"":                     &randomBalancer{},
+		BalancerRandom:         &randomBalancer{},
+		BalancerRoundRobin:     &roundRobinBalancer{},
+		BalancerLeastRequest:   &leastRequestBalancer{},
+		BalancerWeighted:       &weightedBalancer{},
+		BalancerConsistentHash: &consistentHashBalancer{},
+	} {
+		b, err := NewBalancer(name, "x-user-id")
+		assert.Nil(t, err)
+		assert.IsType(t, expect, b)
+	}
+
+	// without hash key
+	_, err := NewBalancer(BalancerConsistentHash, "")
+	assert.NotNil(t, err)
+
+	// with unknown balancer
+	_, err = NewBalancer("unknown", "")
+	assert.NotNil(t, err)
+}
+
+func TestParseProxyDest(t *testing.T) {
+	addr, weight, err := parseProxyDest("localhost:8081")
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost:8081", addr)
+	assert.Equal(t, 1, weight)
+
+	addr, weight, err = parseProxyDest("localhost:8081; weight=3")
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost:8081", addr)
+	assert.Equal(t, 3, weight)
+
+	// with invalid weight
+	_, _, err = parseProxyDest("localhost:8081;weight=0")
+	assert.NotNil(t, err)
+
+	// with unknown parameter
+	_, _, err = parseProxyDest("localhost:8081;zone=a")
+	assert.NotNil(t, err)
+}
+
+func TestRandomBalancer_Pick(t *testing.T) {
+	b := NewRandomBalancer()
+	for i := 0; i < 10; i++ {
+		dest, done := b.Pick(context.TODO(), []string{"a:1;weight=2", "b:1"})
+		assert.Contains(t, []string{"a:1", "b:1"}, dest)
+		done()
+	}
+}
+
+func TestRoundRobinBalancer_Pick(t *testing.T) {
+	b := NewRoundRobinBalancer()
+	dests := []string{"a:1", "b:1", "c:1;weight=3"}
+
+	res := make([]string, 0)
+	for i := 0; i < 6; i++ {
+		dest, _ := b.Pick(context.TODO(), dests)
+		res = append(res, dest)
+	}
+	assert.Equal(t, []string{"a:1", "b:1", "c:1", "a:1", "b:1", "c:1"}, res)
+}
+
+func TestLeastRequestBalancer_Pick(t *testing.T) {
+	b := NewLeastRequestBalancer()
+	dests := []string{"a:1", "b:1"}
+
+	dest1, done1 := b.Pick(context.TODO(), dests)
+	dest2, done2 := b.Pick(context.TODO(), dests)
+	assert.NotEqual(t, dest1, dest2)
+
+	// destination with the least in-flight calls is picked
+	done1()
+	done1()
+	dest3, done3 := b.Pick(context.TODO(), dests)
+	assert.Equal(t, dest1, dest3)
+	dest4, done4 := b.Pick(context.TODO(), dests)
+
+	done2()
+	done3()
+	done4()
+	assert.Empty(t, b.(*leastRequestBalancer).outstanding)
+	assert.Contains(t, dests, dest4)
+}
+
+func TestWeightedBalancer_Pick(t *testing.T) {
+	b := NewWeightedBalancer()
+	dests := []string{"a:1;weight=3", "b:1", "c:1"}
+
+	res := make([]string, 0)
+	for i := 0; i < 5; i++ {
+		dest, _ := b.Pick(context.TODO(), dests)
+		res = append(res, dest)
+	}
+
+	// picks are spread with smooth weighted round-robin
+	assert.Equal(t, []string{"a:1", "b:1", "a:1", "c:1", "a:1"}, res)
+}
+
+func TestConsistentHashBalancer_Pick(t *testing.T) {
+	b := NewConsistentHashBalancer("X-User-Id")
+	dests := []string{"a:1", "b:1", "c:1;weight=2"}
+
+	pick := func(user string, dests []string) string {
+		ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("x-user-id", user))
+		dest, _ := b.Pick(ctx, dests)
+		return dest
+	}
+
+	// calls with the same value stick to the same destination
+	picked := make(map[string]bool)
+	for _, user := range []string{"u-1", "u-2", "u-3", "u-4", "u-5", "u-6", "u-7", "u-8"} {
+		dest := pick(user, dests)
+		assert.Equal(t, dest, pick(user, dests))
+		picked[dest] = true
+	}
+	assert.True(t, len(picked) > 1)
+
+	// only calls of removed destination are moved
+	for _, user := range []string{"u-1", "u-2", "u-3", "u-4", "u-5", "u-6", "u-7", "u-8"} {
+		if dest := pick(user, dests); dest != "b:1" {
+			assert.Equal(t, dest, pick(user, []string{"a:1", "c:1;weight=2"}))
+		}
+	}
+
+	// without metadata
+	dest, done := b.Pick(context.TODO(), dests)
+	assert.Contains(t, []string{"a:1", "b:1", "c:1"}, dest)
+	done()
+}
+
+func TestConsistentHashBalancer_PickWithUnhealthy(t *testing.T) {
+	b := NewConsistentHashBalancer("X-User-Id")
+	dests := []string{"a:1", "b:1", "c:1;weight=2"}
+
+	pick := func(user string, unhealthy string) string {
+		ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("x-user-id", user))
+		ctx = withDestFilter(ctx, func(dests []string) []string {
+			res := make([]string, 0)
+			for i := range dests {
+				if proxyDestAddr(dests[i]) != unhealthy {
+					res = append(res, dests[i])
+				}
+			}
+			return res
+		})
+		dest, _ := pickDest(ctx, b, dests)
+		return dest
+	}
+
+	users := []string{"u-1", "u-2", "u-3", "u-4", "u-5", "u-6", "u-7", "u-8"}
+	healthy := make(map[string]string)
+	for _, user := range users {
+		healthy[user] = pick(user, "")
+	}
+
+	// calls of unhealthy destination are moved, the others stick to their destinations
+	for _, user := range users {
+		dest := pick(user, "b:1")
+		assert.NotEqual(t, "b:1", dest)
+		if healthy[user] != "b:1" {
+			assert.Equal(t, healthy[user], dest)
+		}
+	}
+
+	// ring is built with all destinations
+	assert.Equal(t, strings.Join(dests, ","), b.(*consistentHashBalancer).dests)
+
+	// calls are moved back once destination recovered
+	for _, user := range users {
+		assert.Equal(t, healthy[user], pick(user, ""))
+	}
+}
+
+func TestRule_WithBalancer(t *testing.T) {
+	r := NewRule(WithHeaderPatterns(&HeaderPattern{
+		Headers:  map[string]string{"key": "val"},
+		Dest:     []string{"a:1", "b:1"},
+		Balancer: NewRoundRobinBalancer(),
+	}))
+
+	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("key", "val"))
+	res := make([]string, 0)
+	for i := 0; i < 4; i++ {
+		dest, _, done, ok := r.match(ctx)
+		assert.True(t, ok)
+		res = append(res, dest)
+		done()
+	}
+	assert.Equal(t, []string{"a:1", "b:1", "a:1", "b:1"}, res)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// BootConfigProxyDial Boot config which is for dialing proxy destinations.
+//
+// 1: CertEntry: Name of cert entry, TLS is enabled if provided. RootCA verifies destination and certificate is sent
+// as client certificate for mTLS.
+// 2: ServerName: Override server name for SNI and certificate verification, default: host of destination.
+// 3: Authority: Override :authority header.
+// 4: Keepalive: Send keepalive pings to destination.
+// 5: MaxRecvMsgSize: Max size of message received from destination, default: 4MB.
+// 6: MaxSendMsgSize: Max size of message sent to destination, default: unlimited.
+type BootConfigProxyDial struct {
+	CertEntry  string `yaml:"certEntry" json:"certEntry"`
+	ServerName string `yaml:"serverName" json:"serverName"`
+	Authority  string `yaml:"authority" json:"authority"`
+	Keepalive  struct {
+		TimeMs              int64 `yaml:"timeMs" json:"timeMs"`
+		TimeoutMs           int64 `yaml:"timeoutMs" json:"timeoutMs"`
+		PermitWithoutStream bool  `yaml:"permitWithoutStream" json:"permitWithoutStream"`
+	} `yaml:"keepalive" json:"keepalive"`
+	MaxRecvMsgSize int `yaml:"maxRecvMsgSize" json:"maxRecvMsgSize"`
+	MaxSendMsgSize int `yaml:"maxSendMsgSize" json:"maxSendMsgSize"`
+}
+
+// ProxyDial defines how proxy dials destinations, insecure connection is used if nil.
+//
+// TLS is enabled if CertEntry is provided, RootCA in CertEntry verifies destination, system cert pool is used
+// if missing. Certificate in CertEntry is sent to destination for mTLS.
+type ProxyDial struct {
+	CertEntry      *rkentry.CertEntry
+	ServerName     string
+	Authority      string
+	Keepalive      *keepalive.ClientParameters
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+}
+
+// Convert BootConfigProxyDial into ProxyDial, nil if nothing configured.
+func toProxyDial(config *BootConfigProxyDial) (*ProxyDial, error) {
+	if *config == (BootConfigProxyDial{}) {
+		return nil, nil
+	}
+
+	dial := &ProxyDial{
+		ServerName:     config.ServerName,
+		Authority:      config.Authority,
+		MaxRecvMsgSize: config.MaxRecvMsgSize,
+		MaxSendMsgSize: config.MaxSendMsgSize,
+	}
+
+	if len(config.CertEntry) > 0 {
+		if dial.CertEntry = rkentry.GlobalAppCtx.GetCertEntry(config.CertEntry); dial.CertEntry == nil {
+			return nil, fmt.Errorf("cert entry %s not found", config.CertEntry)
+		}
+	}
+
+	if config.Keepalive.TimeMs > 0 || config.Keepalive.TimeoutMs > 0 || config.Keepalive.PermitWithoutStream {
+		dial.Keepalive = &keepalive.ClientParameters{
+			Time:                time.Duration(config.Keepalive.TimeMs) * time.Millisecond,
+			Timeout:             time.Duration(config.Keepalive.TimeoutMs) * time.Millisecond,
+			PermitWithoutStream: config.Keepalive.PermitWithoutStream,
+		}
+	}
+
+	return dial, nil
+}
+
+// Identity of options, connection is dialed again once options of destination changed.
+func (dial *ProxyDial) key() string {
+	if dial == nil {
+		return ""
+	}
+
+	certEntry := ""
+	if dial.CertEntry != nil {
+		certEntry = dial.CertEntry.GetName()
+	}
+
+	keepaliveParams := keepalive.ClientParameters{}
+	if dial.Keepalive != nil {
+		keepaliveParams = *dial.Keepalive
+	}
+
+	return fmt.Sprintf("%s|%s|%s|%v|%d|%d", certEntry, dial.ServerName, dial.Authority,
+		keepaliveParams, dial.MaxRecvMsgSize, dial.MaxSendMsgSize)
+}
+
+// Convert into dial options.
+func (dial *ProxyDial) dialOptions() []grpc.DialOption {
+	if dial == nil || dial.CertEntry == nil {
+		return append([]grpc.DialOption{grpc.WithInsecure()}, dial.commonDialOptions()...)
+	}
+
+	conf := &tls.Config{
+		ServerName: dial.ServerName,
+	}
+
+	if dial.CertEntry.RootCA != nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(dial.CertEntry.RootCA)
+		conf.RootCAs = pool
+	}
+
+	if dial.CertEntry.Certificate != nil {
+		conf.Certificates = []tls.Certificate{*dial.CertEntry.Certificate}
+	}
+
+	return append([]grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(conf))}, dial.commonDialOptions()...)
+}
+
+// Dial options other than transport credentials.
+func (dial *ProxyDial) commonDialOptions() []grpc.DialOption {
+	res := make([]grpc.DialOption, 0)
+	if dial == nil {
+		return res
+	}
+
+	if len(dial.Authority) > 0 {
+		res = append(res, grpc.WithAuthority(dial.Authority))
+	}
+
+	if dial.Keepalive != nil {
+		res = append(res, grpc.WithKeepaliveParams(*dial.Keepalive))
+	}
+
+	if dial.MaxRecvMsgSize > 0 {
+		res = append(res, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(dial.MaxRecvMsgSize)))
+	}
+
+	if dial.MaxSendMsgSize > 0 {
+		res = append(res, grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(dial.MaxSendMsgSize)))
+	}
+
+	return res
+}
@@ -0,0 +1,206 @@
+//go:build !race
+// +build !race
+
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+package rkgrpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+)
+
+func TestToProxyDial(t *testing.T) {
+	// without options
+	dial, err := toProxyDial(&BootConfigProxyDial{})
+	assert.Nil(t, err)
+	assert.Nil(t, dial)
+
+	// with missing cert entry
+	_, err = toProxyDial(&BootConfigProxyDial{CertEntry: "not-exist"})
+	assert.NotNil(t, err)
+
+	// happy case
+	ca, caKey := newTestCA()
+	dir := t.TempDir()
+	writeTestCerts(t, dir, ca, newTestLeaf(ca, caKey, "ut-server", nil))
+	certEntry := registerTestCertEntry(t, "ut-proxy-cert", dir)
+
+	config := &BootConfigProxyDial{
+		CertEntry:      "ut-proxy-cert",
+		ServerName:     "backend.internal",
+		Authority:      "backend",
+		MaxRecvMsgSize: 1024,
+		MaxSendMsgSize: 2048,
+	}
+	config.Keepalive.TimeMs = 1000
+	config.Keepalive.PermitWithoutStream = true
+
+	dial, err = toProxyDial(config)
+	assert.Nil(t, err)
+	assert.Equal(t, certEntry, dial.CertEntry)
+	assert.Equal(t, "backend.internal", dial.ServerName)
+	assert.Equal(t, "backend", dial.Authority)
+	assert.Equal(t, &keepalive.ClientParameters{Time: time.Second, PermitWithoutStream: true}, dial.Keepalive)
+	assert.Equal(t, 1024, dial.MaxRecvMsgSize)
+	assert.Equal(t, 2048, dial.MaxSendMsgSize)
+	assert.Len(t, dial.dialOptions(), 5)
+}
+
+func TestProxyDial_Key(t *testing.T) {
+	var dial *ProxyDial
+	assert.Empty(t, dial.key())
+	assert.Len(t, dial.dialOptions(), 1)
+
+	dial = &ProxyDial{Authority: "backend"}
+	assert.NotEqual(t, dial.key(), (&ProxyDial{Authority: "other"}).key())
+	assert.Equal(t, dial.key(), (&ProxyDial{Authority: "backend"}).key())
+}
+
+func TestRule_DialOf(t *testing.T) {
+	ruleDial := &ProxyDial{Authority: "rule"}
+	destDial := &ProxyDial{Authority: "dest"}
+
+	r := NewRule(
+		WithPathPatterns(&PathPattern{
+			Paths: []string{"ut-path"},
+			Dest:  []string{"localhost:1972;weight=2", "localhost:1973"},
+			Dial:  ruleDial,
+		}),
+		WithDestDial(destDial, "localhost:1973"))
+
+	route := r.pathRoutes[0]
+	assert.Equal(t, ruleDial, r.dialOf("localhost:1972", route))
+	assert.Equal(t, destDial, r.dialOf("localhost:1973", route))
+	assert.Nil(t, r.dialOf("localhost:1974", nil))
+}
+
+func TestRule_MatchDial(t *testing.T) {
+	r := NewRule(
+		WithPathPatterns(&PathPattern{
+			Paths: []string{"/billing.*"},
+			Dest:  []string{"localhost:1972"},
+			Dial:  &ProxyDial{Authority: "billing"},
+		}),
+		WithPathPatterns(&PathPattern{
+			Paths: []string{"/order.*"},
+			Dest:  []string{"localhost:1972"},
+			Dial:  &ProxyDial{Authority: "order"},
+		}))
+
+	// dial options of matched route are used for the same destination
+	for _, method := range []string{"/billing.v1.Pay/Pay", "/order.v1.Order/Get"} {
+		dest, dial, done, ok := r.match(newRouteTestCtx(method, ""))
+		assert.True(t, ok)
+		assert.Equal(t, "localhost:1972", dest)
+		assert.Equal(t, strings.Split(method[1:], ".")[0], dial.Authority)
+		done()
+	}
+
+	// destination is probed with dial options of each route
+	assert.Len(t, r.targets(), 2)
+}
+
+func TestToProxyRule_WithDial(t *testing.T) {
+	config := &BootConfigProxy{}
+	assert.Nil(t, unmarshalBootYAML([]byte(`
+destinations:
+  - dest: "localhost:1973"
+    dial:
+      authority: dest
+rules:
+  - type: pathBased
+    paths: ["ut-path"]
+    dest: ["localhost:1972", "localhost:1973"]
+    dial:
+      authority: rule
+`), config))
+
+	r, err := toProxyRule(config)
+	assert.Nil(t, err)
+	assert.Equal(t, "rule", r.dialOf("localhost:1972", r.pathRoutes[0]).Authority)
+	assert.Equal(t, "dest", r.dialOf("localhost:1973", r.pathRoutes[0]).Authority)
+
+	// with missing cert entry
+	config.Destinations[0].Dial.CertEntry = "not-exist"
+	_, err = toProxyRule(config)
+	assert.NotNil(t, err)
+}
+
+func TestProxyDial_Tls(t *testing.T) {
+	ca, caKey := newTestCA()
+	leaf := newTestLeaf(ca, caKey, "ut-server", nil)
+
+	// TLS backend with grpc.health.v1.Health service
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	server := grpc.NewServer(grpc.Creds(credentials.NewServerTLSFromCert(&leaf)))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(lis)
+	defer server.Stop()
+
+	addr := lis.Addr().String()
+	certEntry := &rkentry.CertEntry{RootCA: ca}
+	probe := func(dial *ProxyDial) error {
+		checker := newHealthChecker("ut-proxy", nil, nil)
+		defer checker.close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		return checker.probeGrpc(ctx, addr, dial)
+	}
+
+	// without TLS
+	assert.NotNil(t, probe(nil))
+
+	// with TLS and server name in certificate
+	assert.Nil(t, probe(&ProxyDial{CertEntry: certEntry, ServerName: "localhost"}))
+
+	// with TLS and server name not in certificate
+	assert.NotNil(t, probe(&ProxyDial{CertEntry: certEntry, ServerName: "backend.internal"}))
+}
+
+func TestConnPool_DialChanged(t *testing.T) {
+	pool, dials := newCountingConnPool(10, time.Minute, nil)
+	defer pool.close()
+
+	// destination dialed with different options uses different connection
+	conn1, release1, err := pool.get("localhost:1972", nil)
+	assert.Nil(t, err)
+	conn2, release2, err := pool.get("localhost:1972", &ProxyDial{Authority: "backend"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, conn1, conn2)
+	assert.Len(t, pool.conns, 2)
+	release1()
+	release2()
+
+	// connections are reused by alternating callers
+	for i := 0; i < 4; i++ {
+		conn, release, err := pool.get("localhost:1972", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, conn1, conn)
+		release()
+
+		conn, release, err = pool.get("localhost:1972", &ProxyDial{Authority: "backend"})
+		assert.Nil(t, err)
+		assert.Equal(t, conn2, conn)
+		release()
+	}
+	assert.Equal(t, 2, *dials)
+	assert.NotEqual(t, connectivity.Shutdown, conn1.GetState())
+	assert.NotEqual(t, connectivity.Shutdown, conn2.GetState())
+}
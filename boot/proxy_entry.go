@@ -10,9 +10,10 @@ package rkgrpc
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rookie-ninja/rk-entry/v2/entry"
 	"github.com/rookie-ninja/rk-entry/v2/middleware"
-	"github.com/rookie-ninja/rk-grpc/v2/middleware"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/encoding"
@@ -20,9 +21,8 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"io"
-	"math/rand"
-	"net"
-	"regexp"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -42,15 +42,31 @@ const (
 // BootConfigProxy Boot config which is for proxy entry.
 //
 // 1: Enabled: Enable prom entry.
-// 2: Rules: Provide rules for proxying.
+// 2: Pool: Connection pool of destinations.
+// 3: HealthCheck: Active health checking of destinations.
+// 4: Outlier: Passive outlier detection of destinations.
+// 5: Destinations: Dial options of destinations, which take precedence over dial options of rules.
+// 6: Routes: Routes which combine methods, headers and CIDRs, evaluated in order of priority before rules.
+// 7: Rules: Provide rules for proxying.
 type BootConfigProxy struct {
-	Enabled bool `yaml:"enabled" json:"enabled"`
-	Rules   []struct {
-		Type        string   `yaml:"type" json:"type"`
-		HeaderPairs []string `yaml:"headerPairs" json:"headerPairs"`
-		Dest        []string `yaml:"dest" json:"dest"`
-		Paths       []string `yaml:"paths" json:"paths"`
-		Ips         []string `yaml:"ips" json:"ips"`
+	Enabled      bool                       `yaml:"enabled" json:"enabled"`
+	Pool         BootConfigProxyPool        `yaml:"pool" json:"pool"`
+	HealthCheck  BootConfigProxyHealthCheck `yaml:"healthCheck" json:"healthCheck"`
+	Outlier      BootConfigProxyOutlier     `yaml:"outlier" json:"outlier"`
+	Destinations []struct {
+		Dest string              `yaml:"dest" json:"dest"`
+		Dial BootConfigProxyDial `yaml:"dial" json:"dial"`
+	} `yaml:"destinations" json:"destinations"`
+	Routes []BootConfigProxyRoute `yaml:"routes" json:"routes"`
+	Rules  []struct {
+		Type        string              `yaml:"type" json:"type"`
+		HeaderPairs []string            `yaml:"headerPairs" json:"headerPairs"`
+		Dest        []string            `yaml:"dest" json:"dest"`
+		Paths       []string            `yaml:"paths" json:"paths"`
+		Ips         []string            `yaml:"ips" json:"ips"`
+		Balancer    string              `yaml:"balancer" json:"balancer"`
+		HashKey     string              `yaml:"hashKey" json:"hashKey"`
+		Dial        BootConfigProxyDial `yaml:"dial" json:"dial"`
 	} `yaml:"rules" json:"rules"`
 }
 
@@ -58,27 +74,187 @@ type rule struct {
 	HeaderPattern []*HeaderPattern
 	PathPattern   []*PathPattern
 	IpPattern     []*IpPattern
-	rand          *rand.Rand
+	Routes        []*Route
+	Dials         map[string]*ProxyDial
+	routes        []*compiledRoute
+	ipRoutes      []*compiledRoute
+	pathRoutes    []*compiledRoute
+	headerRoutes  []*compiledRoute
+	defaultRoute  *compiledRoute
 }
 
 // NewRule create a new proxy rules with options.
+//
+// Routes and patterns are compiled once, changes of them after NewRule are not applied.
 func NewRule(opts ...ruleOption) *rule {
 	r := &rule{
 		HeaderPattern: make([]*HeaderPattern, 0),
 		PathPattern:   make([]*PathPattern, 0),
 		IpPattern:     make([]*IpPattern, 0),
-		rand:          rand.New(rand.NewSource(time.Now().Unix())),
+		Routes:        make([]*Route, 0),
+		Dials:         make(map[string]*ProxyDial),
 	}
 
 	for i := range opts {
 		opts[i](r)
 	}
 
+	r.compile()
+
 	return r
 }
 
+// Compile routes and patterns, invalid regex and CIDRs never match.
+//
+// Ip and path patterns without CIDRs or paths are skipped since empty dimensions of route match every call.
+func (r *rule) compile() {
+	r.routes = make([]*compiledRoute, 0)
+	for i := range r.Routes {
+		route, _ := compileRoute(r.Routes[i])
+		if !route.Default {
+			r.routes = append(r.routes, route)
+		} else if r.defaultRoute == nil {
+			r.defaultRoute = route
+		}
+	}
+	sortRoutes(r.routes)
+
+	r.ipRoutes = make([]*compiledRoute, 0)
+	for _, pattern := range r.IpPattern {
+		if len(pattern.Cidrs) < 1 {
+			continue
+		}
+
+		route, _ := compileRoute(&Route{
+			Cidrs:    pattern.Cidrs,
+			Dest:     pattern.Dest,
+			Balancer: pattern.Balancer,
+			Dial:     pattern.Dial,
+		})
+		r.ipRoutes = append(r.ipRoutes, route)
+	}
+
+	r.pathRoutes = make([]*compiledRoute, 0)
+	for _, pattern := range r.PathPattern {
+		if len(pattern.Paths) < 1 {
+			continue
+		}
+
+		route, _ := compileRoute(&Route{
+			Methods:  pattern.Paths,
+			Dest:     pattern.Dest,
+			Balancer: pattern.Balancer,
+			Dial:     pattern.Dial,
+		})
+		r.pathRoutes = append(r.pathRoutes, route)
+	}
+
+	r.headerRoutes = make([]*compiledRoute, 0)
+	for _, pattern := range r.HeaderPattern {
+		headers := make([]HeaderMatcher, 0, len(pattern.Headers))
+		for k, v := range pattern.Headers {
+			headers = append(headers, HeaderMatcher{Name: k, Exact: v})
+		}
+
+		route, _ := compileRoute(&Route{
+			Headers:  headers,
+			Dest:     pattern.Dest,
+			Balancer: pattern.Balancer,
+			Dial:     pattern.Dial,
+		})
+		r.headerRoutes = append(r.headerRoutes, route)
+	}
+}
+
 type ruleOption func(*rule)
 
+// Convert rules in boot config into rule, malformed header pairs are skipped.
+func toProxyRule(config *BootConfigProxy) (*rule, error) {
+	opts := make([]ruleOption, 0)
+	for i := range config.Destinations {
+		dial, err := toProxyDial(&config.Destinations[i].Dial)
+		if err != nil {
+			return nil, fmt.Errorf("proxy.destinations[%d]: %v", i, err)
+		}
+		opts = append(opts, WithDestDial(dial, config.Destinations[i].Dest))
+	}
+
+	for i := range config.Rules {
+		rule := config.Rules[i]
+
+		balancer, err := NewBalancer(rule.Balancer, rule.HashKey)
+		if err != nil {
+			return nil, fmt.Errorf("proxy.rules[%d]: %v", i, err)
+		}
+
+		dial, err := toProxyDial(&rule.Dial)
+		if err != nil {
+			return nil, fmt.Errorf("proxy.rules[%d]: %v", i, err)
+		}
+
+		switch rule.Type {
+		case HeaderBased:
+			headers := make(map[string]string, 0)
+
+			for i := range rule.HeaderPairs {
+				tokens := strings.SplitN(rule.HeaderPairs[i], ":", 2)
+				if len(tokens) != 2 {
+					continue
+				}
+				headers[tokens[0]] = tokens[1]
+			}
+
+			opts = append(opts, WithHeaderPatterns(&HeaderPattern{
+				Headers:  headers,
+				Dest:     rule.Dest,
+				Balancer: balancer,
+				Dial:     dial,
+			}))
+
+		case PathBased:
+			opts = append(opts, WithPathPatterns(&PathPattern{
+				Paths:    rule.Paths,
+				Dest:     rule.Dest,
+				Balancer: balancer,
+				Dial:     dial,
+			}))
+		case IpBased:
+			opts = append(opts, WithIpPatterns(&IpPattern{
+				Cidrs:    rule.Ips,
+				Dest:     rule.Dest,
+				Balancer: balancer,
+				Dial:     dial,
+			}))
+		}
+	}
+
+	for i := range config.Routes {
+		route, err := toProxyRoute(&config.Routes[i])
+		if err != nil {
+			return nil, fmt.Errorf("proxy.routes[%d]: %v", i, err)
+		}
+		opts = append(opts, WithRoutes(route))
+	}
+
+	return NewRule(opts...), nil
+}
+
+// WithDestDial provide dial options of destinations, which take precedence over dial options of patterns.
+func WithDestDial(dial *ProxyDial, dests ...string) ruleOption {
+	return func(r *rule) {
+		for i := range dests {
+			r.Dials[proxyDestAddr(dests[i])] = dial
+		}
+	}
+}
+
+// WithRoutes provide routes which combine methods, headers and CIDRs, routes are evaluated before patterns.
+func WithRoutes(route ...*Route) ruleOption {
+	return func(r *rule) {
+		r.Routes = append(r.Routes, route...)
+	}
+}
+
 // WithHeaderPatterns provide header based patterns.
 func WithHeaderPatterns(pattern ...*HeaderPattern) ruleOption {
 	return func(r *rule) {
@@ -103,165 +279,184 @@ func WithIpPatterns(pattern ...*IpPattern) ruleOption {
 // HeaderPattern defines proxy rules based on header.
 //
 // Proxy will validate headers in metadata with provided rules.
+// Destination is picked with Balancer, random balancer is used if nil.
+// Destination is dialed with Dial, insecure connection is used if nil.
 type HeaderPattern struct {
-	Headers map[string]string
-	Dest    []string
+	Headers  map[string]string
+	Dest     []string
+	Balancer Balancer
+	Dial     *ProxyDial
 }
 
 // PathPattern defines proxy rules based on path.
 //
 // The incoming path should match with rules.
 // Path rule support regex.
+// Destination is picked with Balancer, random balancer is used if nil.
+// Destination is dialed with Dial, insecure connection is used if nil.
 type PathPattern struct {
-	Paths []string
-	Dest  []string
+	Paths    []string
+	Dest     []string
+	Balancer Balancer
+	Dial     *ProxyDial
 }
 
 // IpPattern defines proxy rules based on remote IPs.
 //
 // Ip rule support CIDR.
+// Destination is picked with Balancer, random balancer is used if nil.
+// Destination is dialed with Dial, insecure connection is used if nil.
 type IpPattern struct {
-	Cidrs []string
-	Dest  []string
+	Cidrs    []string
+	Dest     []string
+	Balancer Balancer
+	Dial     *ProxyDial
 }
 
 // Incoming remote IP should match user defined CIDR.
-func (r *rule) matchIpPattern(ctx context.Context) (bool, string) {
-	remoteIp, _, _ := rkgrpcmid.GetRemoteAddressSet(ctx)
-
-	// iterate pattern slice
-	for i := range r.IpPattern {
-		pattern := r.IpPattern[i]
-
-		// iterate CIDR
-		for j := range pattern.Cidrs {
-			cidr := pattern.Cidrs[j]
-			_, subnet, err := net.ParseCIDR(cidr)
-			if err != nil {
-				continue
-			}
-
-			// match CIDR
-			if subnet.Contains(net.ParseIP(remoteIp)) {
-				return true, pattern.Dest[r.rand.Intn(len(pattern.Dest))]
-			}
-		}
-	}
-
-	return false, ""
+func (r *rule) matchIpPattern(ctx context.Context) (bool, string, func()) {
+	route, dest, done := matchRoutes(ctx, newRouteInput(ctx), r.ipRoutes)
+	return route != nil, dest, done
 }
 
 // Incoming path should match user defined regex.
-func (r *rule) matchPathPattern(ctx context.Context) (bool, string) {
-	method, ok := grpc.Method(ctx)
+func (r *rule) matchPathPattern(ctx context.Context) (bool, string, func()) {
+	route, dest, done := matchRoutes(ctx, newRouteInput(ctx), r.pathRoutes)
+	return route != nil, dest, done
+}
 
-	if !ok {
-		return false, ""
-	}
+// Incoming header should match user defined rule.
+func (r *rule) matchHeaderPattern(ctx context.Context) (bool, string, func()) {
+	route, dest, done := matchRoutes(ctx, newRouteInput(ctx), r.headerRoutes)
+	return route != nil, dest, done
+}
 
-	// iterate pattern slice
-	for i := range r.PathPattern {
-		pattern := r.PathPattern[i]
+// Return destination of the first matched route and dial options of it, routes are checked in order of priority,
+// followed by patterns in order of ip, path and header. Default route is used if none of them matched.
+//
+// done must be called once call finished.
+func (r *rule) match(ctx context.Context) (string, *ProxyDial, func(), bool) {
+	input := newRouteInput(ctx)
 
-		// iterate path
-		for j := range pattern.Paths {
-			pathRegex := pattern.Paths[j]
+	for _, routes := range [][]*compiledRoute{r.routes, r.ipRoutes, r.pathRoutes, r.headerRoutes} {
+		if route, dest, done := matchRoutes(ctx, input, routes); route != nil && len(dest) > 0 {
+			return dest, r.dialOf(dest, route), done, true
+		}
+	}
 
-			// match regex
-			if matched, err := regexp.MatchString(pathRegex, method); err == nil && matched {
-				return true, pattern.Dest[r.rand.Intn(len(pattern.Dest))]
-			}
+	if r.defaultRoute != nil {
+		if dest, done := pickDest(ctx, r.defaultRoute.Balancer, r.defaultRoute.Dest); len(dest) > 0 {
+			return dest, r.dialOf(dest, r.defaultRoute), done, true
 		}
 	}
 
-	return false, ""
+	return "", nil, noopDone, false
 }
 
-// Incoming header should match user defined rule.
-func (r *rule) matchHeaderPattern(ctx context.Context) (bool, string) {
-	md, ok := metadata.FromIncomingContext(ctx)
+// Return compiled routes and patterns in order of evaluation, default route is the last one.
+func (r *rule) allRoutes() []*compiledRoute {
+	res := make([]*compiledRoute, 0)
+	for _, routes := range [][]*compiledRoute{r.routes, r.ipRoutes, r.pathRoutes, r.headerRoutes} {
+		res = append(res, routes...)
+	}
 
-	if !ok {
-		return false, ""
+	if r.defaultRoute != nil {
+		res = append(res, r.defaultRoute)
 	}
 
-	// iterate pattern slice
-	for i := range r.HeaderPattern {
-		pattern := r.HeaderPattern[i]
+	return res
+}
 
-		matched := true
-		// iterate header
-		for k, v1 := range pattern.Headers {
-			// all the headers must be exists in metadata
-			v2, _ := md[k]
-			matched = containsSlice(v2, v1)
+// proxyTarget is address of destination with dial options of route which contains it.
+type proxyTarget struct {
+	addr string
+	dial *ProxyDial
+}
 
-			if !matched {
-				break
-			}
-		}
+// Return destinations in all routes and patterns with dial options, destination is returned once for each
+// distinct dial options.
+func (r *rule) targets() []proxyTarget {
+	res := make([]proxyTarget, 0)
+	seen := make(map[string]bool)
 
-		if matched {
-			return true, pattern.Dest[r.rand.Intn(len(pattern.Dest))]
+	for _, route := range r.allRoutes() {
+		for i := range route.Dest {
+			addr := proxyDestAddr(route.Dest[i])
+			dial := r.dialOf(addr, route)
+			if key := addr + "|" + dial.key(); !seen[key] {
+				seen[key] = true
+				res = append(res, proxyTarget{addr: addr, dial: dial})
+			}
 		}
-
 	}
 
-	return false, ""
+	return res
 }
 
-func containsSlice(src []string, target string) bool {
-	if src == nil {
-		return false
+// Return dial options of destination in route, options provided with WithDestDial take precedence over
+// options of route. Nil if missing.
+func (r *rule) dialOf(addr string, route *compiledRoute) *ProxyDial {
+	if dial, ok := r.Dials[addr]; ok && dial != nil {
+		return dial
 	}
 
-	for i := range src {
-		if src[i] == target {
-			return true
-		}
+	if route == nil {
+		return nil
 	}
-	return false
+
+	return route.Dial
 }
 
 // GetDirector creates a default Director based on rules.
+//
+// Connection is dialed for each call and closed by TransparentHandler once call finished,
+// use ProxyEntry.GetDirector for pooled connections.
 func (r *rule) GetDirector() Director {
 	return func(ctx context.Context) (context.Context, *grpc.ClientConn, error) {
-		// check ip pattern
-		if matched, dest := r.matchIpPattern(ctx); matched && len(dest) > 0 {
-			conn, err := grpc.DialContext(ctx, dest,
-				grpc.WithInsecure(),
-				grpc.WithDefaultCallOptions(grpc.ForceCodec(Codec())))
-
-			return ctx, conn, err
+		dest, dial, done, ok := r.match(ctx)
+		if !ok {
+			return nil, nil, status.Errorf(codes.Unimplemented, "Unknown method")
 		}
 
-		// check path pattern
-		if matched, dest := r.matchPathPattern(ctx); matched && len(dest) > 0 {
-			conn, err := grpc.DialContext(ctx, dest,
-				grpc.WithInsecure(),
-				grpc.WithDefaultCallOptions(grpc.ForceCodec(Codec())))
+		conn, err := dialProxyDest(dest, dial)
+		if err != nil {
+			done()
 			return ctx, conn, err
 		}
 
-		// check header pattern
-		if matched, dest := r.matchHeaderPattern(ctx); matched && len(dest) > 0 {
-			conn, err := grpc.DialContext(ctx, dest,
-				grpc.WithInsecure(),
-				grpc.WithDefaultCallOptions(grpc.ForceCodec(Codec())))
-			return ctx, conn, err
-		}
-
-		return nil, nil, status.Errorf(codes.Unimplemented, "Unknown method")
+		return withProxyRelease(ctx, func(error) {
+			conn.Close()
+			done()
+		}), conn, nil
 	}
 }
 
 type ProxyEntry struct {
-	entryName        string               `json:"-" yaml:"-"`
-	entryType        string               `json:"-" yaml:"-"`
-	entryDescription string               `json:"-" yaml:"-"`
-	LoggerEntry      *rkentry.LoggerEntry `json:"-" yaml:"-"`
-	EventEntry       *rkentry.EventEntry  `json:"-" yaml:"-"`
-	r                *rule                `json:"-" yaml:"-"`
+	entryName        string                `json:"-" yaml:"-"`
+	entryType        string                `json:"-" yaml:"-"`
+	entryDescription string                `json:"-" yaml:"-"`
+	LoggerEntry      *rkentry.LoggerEntry  `json:"-" yaml:"-"`
+	EventEntry       *rkentry.EventEntry   `json:"-" yaml:"-"`
+	r                *rule                 `json:"-" yaml:"-"`
+	lock             sync.RWMutex          `json:"-" yaml:"-"`
+	pool             *connPool             `json:"-" yaml:"-"`
+	maxConns         int                   `json:"-" yaml:"-"`
+	idleTimeout      time.Duration         `json:"-" yaml:"-"`
+	promRegistry     prometheus.Registerer `json:"-" yaml:"-"`
+	health           *healthChecker        `json:"-" yaml:"-"`
+	healthCheck      *proxyHealthCheck     `json:"-" yaml:"-"`
+	outlier          *proxyOutlier         `json:"-" yaml:"-"`
+}
+
+type proxyHealthCheck struct {
+	checkType string
+	interval  time.Duration
+	timeout   time.Duration
+}
+
+type proxyOutlier struct {
+	consecutiveErrors int
+	ejection          time.Duration
 }
 
 // ProxyEntryOption Proxy entry option used while initializing proxy entry via code
@@ -295,6 +490,44 @@ func WithRuleProxy(r *rule) ProxyEntryOption {
 	}
 }
 
+// WithPoolProxy Provide max connections and idle timeout of connection pool, default values are used if not positive.
+func WithPoolProxy(maxConns int, idleTimeout time.Duration) ProxyEntryOption {
+	return func(entry *ProxyEntry) {
+		entry.maxConns = maxConns
+		entry.idleTimeout = idleTimeout
+	}
+}
+
+// WithPromRegistryProxy Provide prometheus.Registerer which metrics of connection pool would be registered into.
+func WithPromRegistryProxy(registerer prometheus.Registerer) ProxyEntryOption {
+	return func(entry *ProxyEntry) {
+		entry.promRegistry = registerer
+	}
+}
+
+// WithHealthCheckProxy Enable active health checking of destinations with probe type of grpc or tcp,
+// default values are used if interval or timeout is not positive.
+func WithHealthCheckProxy(checkType string, interval, timeout time.Duration) ProxyEntryOption {
+	return func(entry *ProxyEntry) {
+		entry.healthCheck = &proxyHealthCheck{
+			checkType: checkType,
+			interval:  interval,
+			timeout:   timeout,
+		}
+	}
+}
+
+// WithOutlierProxy Enable ejecting destination after consecutive Unavailable errors,
+// default values are used if consecutiveErrors or ejection is not positive.
+func WithOutlierProxy(consecutiveErrors int, ejection time.Duration) ProxyEntryOption {
+	return func(entry *ProxyEntry) {
+		entry.outlier = &proxyOutlier{
+			consecutiveErrors: consecutiveErrors,
+			ejection:          ejection,
+		}
+	}
+}
+
 // NewProxyEntry Create a proxy entry with options
 func NewProxyEntry(opts ...ProxyEntryOption) *ProxyEntry {
 	entry := &ProxyEntry{
@@ -317,17 +550,57 @@ func NewProxyEntry(opts ...ProxyEntryOption) *ProxyEntry {
 		entry.EventEntry = rkentry.NewEventEntryStdout()
 	}
 
+	entry.pool = newConnPool(entry.entryName, entry.maxConns, entry.idleTimeout, entry.promRegistry)
+
+	entry.health = newHealthChecker(entry.entryName, entry.LoggerEntry.Logger, entry.promRegistry)
+	if entry.healthCheck != nil {
+		entry.health.enableCheck(entry.healthCheck.checkType, entry.healthCheck.interval, entry.healthCheck.timeout)
+	}
+	if entry.outlier != nil {
+		entry.health.enableOutlier(entry.outlier.consecutiveErrors, entry.outlier.ejection)
+	}
+
 	return entry
 }
 
-// Bootstrap Start prometheus client
+// GetDirector returns Director which routes with current rule and connections in pool of destinations,
+// rule could be replaced while reloading boot config. Unhealthy destinations are skipped.
+func (entry *ProxyEntry) GetDirector() Director {
+	return entry.pool.director(entry.getRule, entry.health)
+}
+
+// Return current rule, empty rule if missing.
+func (entry *ProxyEntry) getRule() *rule {
+	entry.lock.RLock()
+	defer entry.lock.RUnlock()
+
+	if entry.r == nil {
+		return NewRule()
+	}
+
+	return entry.r
+}
+
+// Replace rule, requests which are already routed are not affected.
+func (entry *ProxyEntry) setRule(r *rule) {
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	entry.r = r
+}
+
+// Bootstrap Start evicting idle connections and checking health of destinations.
 func (entry *ProxyEntry) Bootstrap(ctx context.Context) {
-	// Noop
+	entry.pool.start()
+	entry.health.start(func() []proxyTarget {
+		return entry.getRule().targets()
+	})
 }
 
-// Interrupt Shutdown prometheus client
+// Interrupt Stop checking health and close connections of destinations, in-flight calls are cancelled.
 func (entry *ProxyEntry) Interrupt(ctx context.Context) {
-	// Noop
+	entry.health.close()
+	entry.pool.close()
 }
 
 // GetName Return name of proxy entry
@@ -468,7 +741,7 @@ type handler struct {
 // handler is where the real magic of proxying happens.
 // It is invoked like any gRPC server stream and uses the gRPC server framing to get and receive bytes from the wire,
 // forwarding it to a ClientStream established against the relevant ClientConn.
-func (s *handler) handler(srv interface{}, serverStream grpc.ServerStream) error {
+func (s *handler) handler(srv interface{}, serverStream grpc.ServerStream) (err error) {
 	// little bit of gRPC internals never hurt anyone
 	fullMethodName, ok := grpc.MethodFromServerStream(serverStream)
 
@@ -480,8 +753,12 @@ func (s *handler) handler(srv interface{}, serverStream grpc.ServerStream) error
 	if err != nil {
 		return err
 	}
+	defer func() {
+		proxyRelease(outgoingCtx, err)
+	}()
 
 	clientCtx, clientCancel := context.WithCancel(outgoingCtx)
+	defer clientCancel()
 	clientCtx = metadata.AppendToOutgoingContext(clientCtx, "X-Forwarded-For", rkmid.LocalIp.String)
 
 	clientStream, err := grpc.NewClientStream(clientCtx, clientStreamDescForProxying, backendConn, fullMethodName)
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/metadata"
 	"testing"
 )
@@ -41,7 +42,6 @@ func TestNewRule(t *testing.T) {
 	assert.Empty(t, r.IpPattern)
 	assert.Empty(t, r.PathPattern)
 	assert.Empty(t, r.HeaderPattern)
-	assert.NotNil(t, r.rand)
 
 	// with options
 	r = NewRule(
@@ -63,13 +63,13 @@ func TestRule_MathIpPattern(t *testing.T) {
 
 	// match IP
 	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("x-forwarded-remote-addr", "192.168.0.1:1949"))
-	matched, dest := r.matchIpPattern(ctx)
+	matched, dest, _ := r.matchIpPattern(ctx)
 	assert.True(t, matched)
 	assert.Equal(t, ipPattern.Dest[0], dest)
 
 	// failed to match IP
 	ctx = metadata.NewIncomingContext(context.TODO(), metadata.Pairs("x-forwarded-remote-addr", "10.0.0.1:1949"))
-	matched, dest = r.matchIpPattern(ctx)
+	matched, dest, _ = r.matchIpPattern(ctx)
 	assert.False(t, matched)
 	assert.Empty(t, dest)
 
@@ -80,7 +80,13 @@ func TestRule_MathIpPattern(t *testing.T) {
 	}
 	r = NewRule(WithIpPatterns(invalidIpPattern))
 	ctx = metadata.NewIncomingContext(context.TODO(), metadata.Pairs("x-forwarded-remote-addr", "192.168.0.1:1949"))
-	matched, dest = r.matchIpPattern(ctx)
+	matched, dest, _ = r.matchIpPattern(ctx)
+	assert.False(t, matched)
+	assert.Empty(t, dest)
+
+	// without CIDR
+	r = NewRule(WithIpPatterns(&IpPattern{Dest: []string{"0.0.0.0"}}))
+	matched, dest, _ = r.matchIpPattern(ctx)
 	assert.False(t, matched)
 	assert.Empty(t, dest)
 }
@@ -97,7 +103,7 @@ func TestRule_MatchPathPattern(t *testing.T) {
 	ctx := grpc.NewContextWithServerTransportStream(context.TODO(), &MockServerTransportStream{
 		method: "ut-path",
 	})
-	matched, dest := r.matchPathPattern(ctx)
+	matched, dest, _ := r.matchPathPattern(ctx)
 	assert.True(t, matched)
 	assert.Equal(t, pathPattern.Dest[0], dest)
 
@@ -105,7 +111,13 @@ func TestRule_MatchPathPattern(t *testing.T) {
 	ctx = grpc.NewContextWithServerTransportStream(context.TODO(), &MockServerTransportStream{
 		method: "not-matched",
 	})
-	matched, dest = r.matchPathPattern(ctx)
+	matched, dest, _ = r.matchPathPattern(ctx)
+	assert.False(t, matched)
+	assert.Empty(t, dest)
+
+	// without path
+	r = NewRule(WithPathPatterns(&PathPattern{Dest: []string{"0.0.0.0"}}))
+	matched, dest, _ = r.matchPathPattern(ctx)
 	assert.False(t, matched)
 	assert.Empty(t, dest)
 }
@@ -122,19 +134,19 @@ func TestRule_MatchHeaderPattern(t *testing.T) {
 	r := NewRule(WithHeaderPatterns(headerPatter))
 
 	// without metadata
-	matched, dest := r.matchHeaderPattern(context.TODO())
+	matched, dest, _ := r.matchHeaderPattern(context.TODO())
 	assert.False(t, matched)
 	assert.Empty(t, dest)
 
 	// match header
 	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("key-1", "val-1", "key-2", "val-2", "key-3", "val-3"))
-	matched, dest = r.matchHeaderPattern(ctx)
+	matched, dest, _ = r.matchHeaderPattern(ctx)
 	assert.True(t, matched)
 	assert.Equal(t, headerPatter.Dest[0], dest)
 
 	// failed to match header
 	ctx = metadata.NewIncomingContext(context.TODO(), metadata.Pairs("key-1", "val-1"))
-	matched, dest = r.matchHeaderPattern(ctx)
+	matched, dest, _ = r.matchHeaderPattern(ctx)
 	assert.False(t, matched)
 	assert.Empty(t, dest)
 }
@@ -155,6 +167,10 @@ func TestRule_GetDirector(t *testing.T) {
 	assert.NotNil(t, conn)
 	assert.Nil(t, err)
 
+	// connection is closed once released
+	proxyRelease(ctx, nil)
+	assert.Equal(t, connectivity.Shutdown, conn.GetState())
+
 	// failed to match IP, match path
 	pathPattern := &PathPattern{
 		Paths: []string{"ut-path"},
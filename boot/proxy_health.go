@@ -0,0 +1,439 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// HealthCheckGrpc checks destinations with grpc.health.v1.Health service
+	HealthCheckGrpc = "grpc"
+	// HealthCheckTcp checks destinations by opening TCP connection
+	HealthCheckTcp = "tcp"
+
+	defaultHealthCheckInterval      = 10 * time.Second
+	defaultHealthCheckTimeout       = time.Second
+	defaultOutlierConsecutiveErrors = 5
+	defaultOutlierEjection          = 30 * time.Second
+)
+
+// BootConfigProxyHealthCheck Boot config which is for active health checking of proxy destinations.
+//
+// 1: Enabled: Enable health checking.
+// 2: Type: Type of probe, grpc or tcp, default: grpc.
+// 3: IntervalMs: Interval of probes, default: 10000.
+// 4: TimeoutMs: Timeout of each probe, default: 1000.
+type BootConfigProxyHealthCheck struct {
+	Enabled    bool   `yaml:"enabled" json:"enabled"`
+	Type       string `yaml:"type" json:"type"`
+	IntervalMs int64  `yaml:"intervalMs" json:"intervalMs"`
+	TimeoutMs  int64  `yaml:"timeoutMs" json:"timeoutMs"`
+}
+
+// BootConfigProxyOutlier Boot config which is for passive outlier detection of proxy destinations.
+//
+// 1: Enabled: Enable outlier detection.
+// 2: ConsecutiveErrors: Destination is ejected after number of consecutive Unavailable errors, default: 5.
+// 3: EjectionMs: Duration of ejection, default: 30000.
+type BootConfigProxyOutlier struct {
+	Enabled           bool  `yaml:"enabled" json:"enabled"`
+	ConsecutiveErrors int   `yaml:"consecutiveErrors" json:"consecutiveErrors"`
+	EjectionMs        int64 `yaml:"ejectionMs" json:"ejectionMs"`
+}
+
+// healthChecker tracks health of destinations, unhealthy destinations are skipped by balancers.
+//
+// Destination is unhealthy if the last probe failed or it is ejected after consecutive Unavailable errors.
+// All destinations of pattern are used if none of them is healthy.
+type healthChecker struct {
+	entryName         string
+	entryType         string
+	checkEnabled      bool
+	interval          time.Duration
+	timeout           time.Duration
+	probe             func(ctx context.Context, addr string, dial *ProxyDial) error
+	outlierEnabled    bool
+	consecutiveErrors int
+	ejection          time.Duration
+	states            map[string]*destHealth
+	conns             map[string]*probeConn
+	logger            *zap.Logger
+	lock              sync.Mutex
+	quit              chan struct{}
+	wg                sync.WaitGroup
+	startOnce         sync.Once
+	stopOnce          sync.Once
+	gauge             *prometheus.GaugeVec
+}
+
+// probeConn is connection of grpc probe, keyed by address and identity of dial options.
+type probeConn struct {
+	addr string
+	conn *grpc.ClientConn
+}
+
+// destHealth is health of one destination.
+type destHealth struct {
+	probeFailed  bool
+	errors       int
+	ejectedUntil time.Time
+}
+
+// Create health checker, default values are used if not positive.
+func newHealthChecker(entryName string, logger *zap.Logger, registerer prometheus.Registerer) *healthChecker {
+	checker := &healthChecker{
+		entryName:         entryName,
+		entryType:         ProxyEntryType,
+		interval:          defaultHealthCheckInterval,
+		timeout:           defaultHealthCheckTimeout,
+		consecutiveErrors: defaultOutlierConsecutiveErrors,
+		ejection:          defaultOutlierEjection,
+		states:            make(map[string]*destHealth),
+		conns:             make(map[string]*probeConn),
+		logger:            logger,
+		quit:              make(chan struct{}),
+	}
+	checker.probe = checker.probeGrpc
+
+	if checker.logger == nil {
+		checker.logger = zap.NewNop()
+	}
+
+	if registerer != nil {
+		checker.gauge = registerProxyGauge(registerer, "rk_grpc_proxy_backend_healthy",
+			"health of proxy destinations, 1: healthy, 0: probe failed or ejected")
+	}
+
+	return checker
+}
+
+// Enable active health checking with probe type.
+func (checker *healthChecker) enableCheck(checkType string, interval, timeout time.Duration) {
+	checker.checkEnabled = true
+
+	switch checkType {
+	case HealthCheckTcp:
+		checker.probe = checker.probeTcp
+	default:
+		checker.probe = checker.probeGrpc
+	}
+
+	if interval > 0 {
+		checker.interval = interval
+	}
+
+	if timeout > 0 {
+		checker.timeout = timeout
+	}
+}
+
+// Enable passive outlier detection.
+func (checker *healthChecker) enableOutlier(consecutiveErrors int, ejection time.Duration) {
+	checker.outlierEnabled = true
+
+	if consecutiveErrors > 0 {
+		checker.consecutiveErrors = consecutiveErrors
+	}
+
+	if ejection > 0 {
+		checker.ejection = ejection
+	}
+}
+
+// Is destination healthy at now, destination without state is healthy.
+func (checker *healthChecker) healthy(addr string, now time.Time) bool {
+	checker.lock.Lock()
+	defer checker.lock.Unlock()
+
+	return checker.healthyLocked(addr, now)
+}
+
+func (checker *healthChecker) healthyLocked(addr string, now time.Time) bool {
+	state, ok := checker.states[addr]
+	if !ok {
+		return true
+	}
+
+	return !state.probeFailed && !now.Before(state.ejectedUntil)
+}
+
+// Return healthy destinations, all destinations are returned if none of them is healthy.
+func (checker *healthChecker) filter(dests []string) []string {
+	checker.lock.Lock()
+	defer checker.lock.Unlock()
+
+	now := time.Now()
+	res := make([]string, 0, len(dests))
+	for i := range dests {
+		if checker.healthyLocked(proxyDestAddr(dests[i]), now) {
+			res = append(res, dests[i])
+		}
+	}
+
+	if len(res) < 1 {
+		return dests
+	}
+
+	return res
+}
+
+// Report result of call forwarded to destination, destination is ejected after consecutive Unavailable errors.
+func (checker *healthChecker) report(addr string, err error) {
+	if !checker.outlierEnabled {
+		return
+	}
+
+	checker.lock.Lock()
+	defer checker.lock.Unlock()
+
+	state := checker.state(addr)
+	if status.Code(err) != codes.Unavailable {
+		state.errors = 0
+		return
+	}
+
+	state.errors++
+	if state.errors < checker.consecutiveErrors {
+		return
+	}
+
+	state.errors = 0
+	state.ejectedUntil = time.Now().Add(checker.ejection)
+	checker.logger.Warn("Proxy destination ejected",
+		zap.String("entryName", checker.entryName),
+		zap.String("dest", addr),
+		zap.Int("consecutiveErrors", checker.consecutiveErrors),
+		zap.Duration("ejection", checker.ejection))
+	checker.observe(addr, state, time.Now())
+}
+
+// Probe destinations with dial options of each route which contains them, destination is unhealthy if any of
+// probes failed. States of destinations which are not in targets any more are removed.
+func (checker *healthChecker) check(targets []proxyTarget) {
+	results := make(map[string]error, len(targets))
+	if checker.checkEnabled {
+		var lock sync.Mutex
+		var wg sync.WaitGroup
+		for i := range targets {
+			wg.Add(1)
+			go func(target proxyTarget) {
+				defer wg.Done()
+
+				ctx, cancel := context.WithTimeout(context.Background(), checker.timeout)
+				defer cancel()
+				err := checker.probe(ctx, target.addr, target.dial)
+
+				lock.Lock()
+				if results[target.addr] == nil {
+					results[target.addr] = err
+				}
+				lock.Unlock()
+			}(targets[i])
+		}
+		wg.Wait()
+	}
+
+	checker.lock.Lock()
+	defer checker.lock.Unlock()
+
+	now := time.Now()
+	for addr, err := range results {
+		state := checker.state(addr)
+		if failed := err != nil; failed != state.probeFailed {
+			state.probeFailed = failed
+			if failed {
+				checker.logger.Warn("Proxy destination is unhealthy",
+					zap.String("entryName", checker.entryName), zap.String("dest", addr), zap.Error(err))
+			} else {
+				checker.logger.Info("Proxy destination is healthy",
+					zap.String("entryName", checker.entryName), zap.String("dest", addr))
+			}
+		}
+	}
+
+	current := make(map[string]bool, len(targets))
+	probes := make(map[string]bool, len(targets))
+	for i := range targets {
+		current[targets[i].addr] = true
+		probes[probeKey(targets[i].addr, targets[i].dial)] = true
+	}
+
+	for addr, state := range checker.states {
+		if !current[addr] {
+			checker.removeLocked(addr)
+			continue
+		}
+		checker.observe(addr, state, now)
+	}
+
+	// close probe connections of dial options which are not used any more
+	for key, pc := range checker.conns {
+		if !probes[key] {
+			pc.conn.Close()
+			delete(checker.conns, key)
+		}
+	}
+}
+
+// Return state of destination, state is created if missing.
+func (checker *healthChecker) state(addr string) *destHealth {
+	state, ok := checker.states[addr]
+	if !ok {
+		state = &destHealth{}
+		checker.states[addr] = state
+	}
+
+	return state
+}
+
+// Remove state and probe connection of destination.
+func (checker *healthChecker) removeLocked(addr string) {
+	delete(checker.states, addr)
+
+	for key, pc := range checker.conns {
+		if pc.addr == addr {
+			pc.conn.Close()
+			delete(checker.conns, key)
+		}
+	}
+
+	if checker.gauge != nil {
+		checker.gauge.DeleteLabelValues(checker.entryName, checker.entryType, addr)
+	}
+}
+
+// Export health of destination.
+func (checker *healthChecker) observe(addr string, state *destHealth, now time.Time) {
+	if checker.gauge == nil {
+		return
+	}
+
+	value := 0.0
+	if !state.probeFailed && !now.Before(state.ejectedUntil) {
+		value = 1
+	}
+	checker.gauge.WithLabelValues(checker.entryName, checker.entryType, addr).Set(value)
+}
+
+// Probe destination by opening TCP connection.
+func (checker *healthChecker) probeTcp(ctx context.Context, addr string, _ *ProxyDial) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// Probe destination with grpc.health.v1.Health service, connection is kept for next probes.
+//
+// Destination is dialed with the same options as proxied calls, connection is kept for each dial options.
+func (checker *healthChecker) probeGrpc(ctx context.Context, addr string, dial *ProxyDial) error {
+	key := probeKey(addr, dial)
+
+	checker.lock.Lock()
+	pc, ok := checker.conns[key]
+	if !ok {
+		conn, err := grpc.Dial(addr, dial.dialOptions()...)
+		if err != nil {
+			checker.lock.Unlock()
+			return err
+		}
+		pc = &probeConn{addr: addr, conn: conn}
+		checker.conns[key] = pc
+	}
+	checker.lock.Unlock()
+
+	resp, err := healthpb.NewHealthClient(pc.conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("serving status is %s", resp.GetStatus())
+	}
+
+	return nil
+}
+
+// Check destinations returned by targets in background, noop if neither health checking nor outlier detection enabled.
+func (checker *healthChecker) start(targets func() []proxyTarget) {
+	if !checker.checkEnabled && !checker.outlierEnabled {
+		return
+	}
+
+	checker.startOnce.Do(func() {
+		checker.wg.Add(1)
+		go func() {
+			defer checker.wg.Done()
+
+			ticker := time.NewTicker(checker.interval)
+			defer ticker.Stop()
+
+			for {
+				checker.check(targets())
+
+				select {
+				case <-checker.quit:
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	})
+}
+
+// Stop checking and close probe connections.
+func (checker *healthChecker) close() {
+	checker.stopOnce.Do(func() {
+		close(checker.quit)
+	})
+	checker.wg.Wait()
+
+	checker.lock.Lock()
+	defer checker.lock.Unlock()
+
+	for addr := range checker.states {
+		checker.removeLocked(addr)
+	}
+	for key, pc := range checker.conns {
+		pc.conn.Close()
+		delete(checker.conns, key)
+	}
+}
+
+// Key of probe connection, connection is dialed for each distinct dial options of destination.
+func probeKey(addr string, dial *ProxyDial) string {
+	return addr + "|" + dial.key()
+}
+
+type destFilterKey struct{}
+
+// Attach function into context which filters destinations before picked by balancer.
+func withDestFilter(ctx context.Context, filter func([]string) []string) context.Context {
+	return context.WithValue(ctx, destFilterKey{}, filter)
+}
+
+// Filter destinations with function attached with withDestFilter, destinations are returned if missing.
+func filterDests(ctx context.Context, dests []string) []string {
+	if filter, ok := ctx.Value(destFilterKey{}).(func([]string) []string); ok {
+		return filter(dests)
+	}
+
+	return dests
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewHealthChecker(t *testing.T) {
+	// with default values
+	checker := newHealthChecker("ut-proxy", nil, nil)
+	assert.False(t, checker.checkEnabled)
+	assert.False(t, checker.outlierEnabled)
+	assert.Equal(t, defaultHealthCheckInterval, checker.interval)
+	assert.Equal(t, defaultOutlierConsecutiveErrors, checker.consecutiveErrors)
+	assert.NotNil(t, checker.logger)
+	assert.Nil(t, checker.gauge)
+
+	// with values
+	checker = newHealthChecker("ut-proxy", nil, prometheus.NewRegistry())
+	checker.enableCheck(HealthCheckTcp, time.Second, time.Millisecond)
+	checker.enableOutlier(3, time.Minute)
+	assert.True(t, checker.checkEnabled)
+	assert.True(t, checker.outlierEnabled)
+	assert.Equal(t, time.Second, checker.interval)
+	assert.Equal(t, time.Millisecond, checker.timeout)
+	assert.Equal(t, 3, checker.consecutiveErrors)
+	assert.Equal(t, time.Minute, checker.ejection)
+	assert.NotNil(t, checker.gauge)
+
+	// start and close without checking enabled
+	checker = newHealthChecker("ut-proxy", nil, nil)
+	checker.start(func() []proxyTarget { return []proxyTarget{{addr: "localhost:1972"}} })
+	checker.close()
+}
+
+func TestHealthChecker_Report(t *testing.T) {
+	checker := newHealthChecker("ut-proxy", nil, nil)
+	dests := []string{"localhost:1972;weight=2", "localhost:1973"}
+
+	// outlier detection is disabled
+	for i := 0; i < 10; i++ {
+		checker.report("localhost:1972", status.Error(codes.Unavailable, ""))
+	}
+	assert.Equal(t, dests, checker.filter(dests))
+
+	// errors are reset by successful call
+	checker.enableOutlier(2, time.Minute)
+	checker.report("localhost:1972", status.Error(codes.Unavailable, ""))
+	checker.report("localhost:1972", nil)
+	checker.report("localhost:1972", status.Error(codes.Unavailable, ""))
+	assert.True(t, checker.healthy("localhost:1972", time.Now()))
+
+	// ejected after consecutive Unavailable errors
+	checker.report("localhost:1972", status.Error(codes.Unavailable, ""))
+	assert.False(t, checker.healthy("localhost:1972", time.Now()))
+	assert.Equal(t, []string{"localhost:1973"}, checker.filter(dests))
+
+	// ejection expires
+	assert.True(t, checker.healthy("localhost:1972", time.Now().Add(2*time.Minute)))
+
+	// all destinations are returned if none of them is healthy
+	checker.report("localhost:1973", status.Error(codes.Unavailable, ""))
+	checker.report("localhost:1973", status.Error(codes.Unavailable, ""))
+	assert.Equal(t, dests, checker.filter(dests))
+}
+
+func TestHealthChecker_Check(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	checker := newHealthChecker("ut-proxy", nil, registry)
+	checker.enableCheck(HealthCheckGrpc, time.Minute, time.Second)
+	checker.probe = func(ctx context.Context, addr string, dial *ProxyDial) error {
+		if addr == "localhost:1972" {
+			return errors.New("ut-error")
+		}
+		return nil
+	}
+	defer checker.close()
+
+	checker.check([]proxyTarget{{addr: "localhost:1972"}, {addr: "localhost:1973"}})
+	assert.False(t, checker.healthy("localhost:1972", time.Now()))
+	assert.True(t, checker.healthy("localhost:1973", time.Now()))
+	assert.Equal(t, []string{"localhost:1973"}, checker.filter([]string{"localhost:1972", "localhost:1973"}))
+
+	// metrics of destinations
+	assert.Equal(t, float64(0), testutil.ToFloat64(checker.gauge.WithLabelValues("ut-proxy", ProxyEntryType, "localhost:1972")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(checker.gauge.WithLabelValues("ut-proxy", ProxyEntryType, "localhost:1973")))
+
+	// destinations which are removed from rules
+	checker.check([]proxyTarget{{addr: "localhost:1973"}})
+	assert.NotContains(t, checker.states, "localhost:1972")
+	assert.True(t, checker.healthy("localhost:1972", time.Now()))
+
+	// destination is probed with dial options of each route, unhealthy if any of probes failed
+	checker.probe = func(ctx context.Context, addr string, dial *ProxyDial) error {
+		if dial != nil && dial.Authority == "wrong" {
+			return errors.New("ut-error")
+		}
+		return nil
+	}
+	checker.check([]proxyTarget{{addr: "localhost:1973"}, {addr: "localhost:1973", dial: &ProxyDial{Authority: "wrong"}}})
+	assert.False(t, checker.healthy("localhost:1973", time.Now()))
+}
+
+func TestHealthChecker_ProbeTcp(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	addr := lis.Addr().String()
+
+	checker := newHealthChecker("ut-proxy", nil, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, checker.probeTcp(ctx, addr, nil))
+
+	// with closed listener
+	lis.Close()
+	assert.NotNil(t, checker.probeTcp(ctx, addr, nil))
+}
+
+func TestHealthChecker_ProbeGrpc(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	checker := newHealthChecker("ut-proxy", nil, nil)
+	defer checker.close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// serving
+	assert.Nil(t, checker.probeGrpc(ctx, lis.Addr().String(), nil))
+	assert.Len(t, checker.conns, 1)
+
+	// connection is kept for each dial options
+	assert.Nil(t, checker.probeGrpc(ctx, lis.Addr().String(), &ProxyDial{Authority: "backend"}))
+	assert.Len(t, checker.conns, 2)
+
+	// not serving
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	assert.NotNil(t, checker.probeGrpc(ctx, lis.Addr().String(), nil))
+	assert.Len(t, checker.conns, 2)
+}
+
+func TestProxyEntry_HealthCheck(t *testing.T) {
+	entry := NewProxyEntry(
+		WithRuleProxy(NewRule(WithPathPatterns(&PathPattern{
+			Paths:    []string{"ut-path"},
+			Dest:     []string{"localhost:1972", "localhost:1973"},
+			Balancer: NewRoundRobinBalancer(),
+		}))),
+		WithHealthCheckProxy(HealthCheckTcp, time.Minute, 10*time.Millisecond),
+		WithOutlierProxy(1, time.Minute))
+	assert.True(t, entry.health.checkEnabled)
+	assert.True(t, entry.health.outlierEnabled)
+
+	entry.health.probe = func(context.Context, string, *ProxyDial) error { return nil }
+	entry.Bootstrap(context.TODO())
+	defer entry.Interrupt(context.TODO())
+
+	director := entry.GetDirector()
+	ctx := grpc.NewContextWithServerTransportStream(context.TODO(), &MockServerTransportStream{
+		method: "ut-path",
+	})
+
+	// destination is ejected after Unavailable error
+	outgoingCtx, _, err := director(ctx)
+	assert.Nil(t, err)
+	proxyRelease(outgoingCtx, status.Error(codes.Unavailable, ""))
+
+	unhealthy := "localhost:1972"
+	if entry.health.healthy(unhealthy, time.Now()) {
+		unhealthy = "localhost:1973"
+	}
+	assert.False(t, entry.health.healthy(unhealthy, time.Now()))
+
+	// ejected destination is skipped
+	for i := 0; i < 4; i++ {
+		outgoingCtx, conn, err := director(ctx)
+		assert.Nil(t, err)
+		assert.NotEqual(t, unhealthy, conn.Target())
+		proxyRelease(outgoingCtx, nil)
+	}
+}
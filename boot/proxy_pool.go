@@ -0,0 +1,341 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultProxyMaxConns    = 100
+	defaultProxyIdleTimeout = 5 * time.Minute
+)
+
+var proxyPoolLabelKeys = []string{"entryName", "entryType", "dest"}
+
+// BootConfigProxyPool Boot config which is for connection pool of proxy entry.
+//
+// 1: MaxConns: Max number of connections to destinations, default: 100.
+// 2: IdleTimeoutMs: Connections without in-flight RPCs are closed after idle timeout, default: 300000.
+type BootConfigProxyPool struct {
+	MaxConns      int   `yaml:"maxConns" json:"maxConns"`
+	IdleTimeoutMs int64 `yaml:"idleTimeoutMs" json:"idleTimeoutMs"`
+}
+
+// connPool keeps long-lived connections keyed by destination and dial options, connection is shared by RPCs to the
+// same destination with the same dial options.
+//
+// Connection which is released by all RPCs and idle longer than idleTimeout is closed. While maxConns is reached,
+// the least recently used idle connection is closed for new destination, RPC is rejected if none of them is idle.
+type connPool struct {
+	entryName   string
+	entryType   string
+	maxConns    int
+	idleTimeout time.Duration
+	dial        func(dest string, dial *ProxyDial) (*grpc.ClientConn, error)
+	conns       map[string]*pooledConn
+	closed      bool
+	lock        sync.Mutex
+	quit        chan struct{}
+	wg          sync.WaitGroup
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	stateGauge  *prometheus.GaugeVec
+	streamGauge *prometheus.GaugeVec
+}
+
+// pooledConn is connection of one destination dialed with one dial options.
+type pooledConn struct {
+	key      string
+	dest     string
+	conn     *grpc.ClientConn
+	active   int
+	lastUsed time.Time
+}
+
+// Create pool, default values are used if maxConns or idleTimeout is not positive.
+//
+// Connection metrics are registered into registerer if provided.
+func newConnPool(entryName string, maxConns int, idleTimeout time.Duration, registerer prometheus.Registerer) *connPool {
+	if maxConns <= 0 {
+		maxConns = defaultProxyMaxConns
+	}
+
+	if idleTimeout <= 0 {
+		idleTimeout = defaultProxyIdleTimeout
+	}
+
+	pool := &connPool{
+		entryName:   entryName,
+		entryType:   ProxyEntryType,
+		maxConns:    maxConns,
+		idleTimeout: idleTimeout,
+		dial:        dialProxyDest,
+		conns:       make(map[string]*pooledConn),
+		quit:        make(chan struct{}),
+	}
+
+	if registerer != nil {
+		pool.stateGauge = registerProxyGauge(registerer, "rk_grpc_proxy_connection_state",
+			"state of proxy connections, 0: idle, 1: connecting, 2: ready, 3: transient failure, 4: shutdown")
+		pool.streamGauge = registerProxyGauge(registerer, "rk_grpc_proxy_active_streams",
+			"number of in-flight RPCs on proxy connections")
+	}
+
+	return pool
+}
+
+// Dial destination with options without blocking, messages are forwarded as raw bytes.
+func dialProxyDest(dest string, dial *ProxyDial) (*grpc.ClientConn, error) {
+	opts := append(dial.dialOptions(), grpc.WithDefaultCallOptions(grpc.ForceCodec(Codec())))
+	return grpc.Dial(dest, opts...)
+}
+
+// Register gauge into registerer, existing one would be reused.
+func registerProxyGauge(registerer prometheus.Registerer, name, help string) *prometheus.GaugeVec {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: name,
+		Help: help,
+	}, proxyPoolLabelKeys)
+
+	if err := registerer.Register(gauge); err != nil {
+		if existing, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if res, ok := existing.ExistingCollector.(*prometheus.GaugeVec); ok {
+				return res
+			}
+		}
+		return nil
+	}
+
+	return gauge
+}
+
+// Get connection of destination dialed with options, release must be called once RPC finished.
+//
+// Destination dialed with different options uses different connection.
+func (pool *connPool) get(dest string, dial *ProxyDial) (*grpc.ClientConn, func(), error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if pool.closed {
+		return nil, nil, status.Error(codes.Unavailable, "proxy is closed")
+	}
+
+	key := dest + "|" + dial.key()
+	pc, ok := pool.conns[key]
+	if ok && pc.conn.GetState() == connectivity.Shutdown {
+		pool.remove(pc)
+		ok = false
+	}
+
+	if !ok {
+		if len(pool.conns) >= pool.maxConns && !pool.evictLeastRecentlyUsed() {
+			return nil, nil, status.Errorf(codes.ResourceExhausted, "max connections %d of proxy reached", pool.maxConns)
+		}
+
+		conn, err := pool.dial(dest, dial)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pc = &pooledConn{
+			key:  key,
+			dest: dest,
+			conn: conn,
+		}
+		pool.conns[key] = pc
+	}
+
+	pc.active++
+	pc.lastUsed = time.Now()
+	pool.observe(pc)
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			pool.release(pc)
+		})
+	}
+
+	return pc.conn, release, nil
+}
+
+// Release connection used by one RPC.
+func (pool *connPool) release(pc *pooledConn) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	pc.active--
+	pc.lastUsed = time.Now()
+
+	if pool.conns[pc.key] == pc {
+		pool.observe(pc)
+	}
+}
+
+// Close the least recently used connection without in-flight RPCs, false if none of connections is idle.
+func (pool *connPool) evictLeastRecentlyUsed() bool {
+	var lru *pooledConn
+	for _, pc := range pool.conns {
+		if pc.active > 0 {
+			continue
+		}
+
+		if lru == nil || pc.lastUsed.Before(lru.lastUsed) {
+			lru = pc
+		}
+	}
+
+	if lru == nil {
+		return false
+	}
+
+	pool.remove(lru)
+	return true
+}
+
+// Close connections which are idle longer than idle timeout or shutdown, and export states of the others.
+func (pool *connPool) evictIdle(now time.Time) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for _, pc := range pool.conns {
+		switch {
+		case pc.conn.GetState() == connectivity.Shutdown:
+			pool.remove(pc)
+		case pc.active < 1 && now.Sub(pc.lastUsed) >= pool.idleTimeout:
+			pool.remove(pc)
+		default:
+			pool.observe(pc)
+		}
+	}
+}
+
+// Close connection and remove it from pool, in-flight RPCs of connection are cancelled.
+func (pool *connPool) remove(pc *pooledConn) {
+	delete(pool.conns, pc.key)
+	pc.conn.Close()
+
+	// export the other connection of destination dialed with different options
+	for _, other := range pool.conns {
+		if other.dest == pc.dest {
+			pool.observe(other)
+			return
+		}
+	}
+
+	if pool.stateGauge != nil {
+		pool.stateGauge.DeleteLabelValues(pool.entryName, pool.entryType, pc.dest)
+	}
+	if pool.streamGauge != nil {
+		pool.streamGauge.DeleteLabelValues(pool.entryName, pool.entryType, pc.dest)
+	}
+}
+
+// Export state and in-flight RPCs of connection.
+func (pool *connPool) observe(pc *pooledConn) {
+	if pool.stateGauge != nil {
+		pool.stateGauge.WithLabelValues(pool.entryName, pool.entryType, pc.dest).Set(float64(pc.conn.GetState()))
+	}
+	if pool.streamGauge != nil {
+		pool.streamGauge.WithLabelValues(pool.entryName, pool.entryType, pc.dest).Set(float64(pc.active))
+	}
+}
+
+// Start evicting idle connections in background.
+func (pool *connPool) start() {
+	pool.startOnce.Do(func() {
+		pool.wg.Add(1)
+		go func() {
+			defer pool.wg.Done()
+
+			ticker := time.NewTicker(pool.idleTimeout / 2)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-pool.quit:
+					return
+				case now := <-ticker.C:
+					pool.evictIdle(now)
+				}
+			}
+		}()
+	})
+}
+
+// Stop evicting and close all connections, connections are not created after closed.
+func (pool *connPool) close() {
+	pool.stopOnce.Do(func() {
+		close(pool.quit)
+	})
+	pool.wg.Wait()
+
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for _, pc := range pool.conns {
+		pool.remove(pc)
+	}
+	pool.closed = true
+}
+
+// Director routes with rule and returns pooled connection, connection is released by TransparentHandler.
+//
+// Unhealthy destinations are skipped and results of calls are reported to health checker if provided.
+func (pool *connPool) director(getRule func() *rule, health *healthChecker) Director {
+	return func(ctx context.Context) (context.Context, *grpc.ClientConn, error) {
+		matchCtx := ctx
+		if health != nil {
+			matchCtx = withDestFilter(ctx, health.filter)
+		}
+
+		dest, dial, done, ok := getRule().match(matchCtx)
+		if !ok {
+			return nil, nil, status.Errorf(codes.Unimplemented, "Unknown method")
+		}
+
+		conn, release, err := pool.get(dest, dial)
+		if err != nil {
+			done()
+			return nil, nil, err
+		}
+
+		return withProxyRelease(ctx, func(err error) {
+			release()
+			done()
+			if health != nil {
+				health.report(dest, err)
+			}
+		}), conn, nil
+	}
+}
+
+type proxyReleaseKey struct{}
+
+// Attach function into context which is called by TransparentHandler with error of RPC once RPC finished.
+func withProxyRelease(ctx context.Context, release func(error)) context.Context {
+	return context.WithValue(ctx, proxyReleaseKey{}, release)
+}
+
+// Call function attached with withProxyRelease, noop if missing.
+func proxyRelease(ctx context.Context, err error) {
+	if ctx == nil {
+		return
+	}
+
+	if release, ok := ctx.Value(proxyReleaseKey{}).(func(error)); ok {
+		release(err)
+	}
+}
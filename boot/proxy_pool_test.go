@@ -0,0 +1,157 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+// Create pool which counts dials.
+func newCountingConnPool(maxConns int, idleTimeout time.Duration, registerer prometheus.Registerer) (*connPool, *int) {
+	dials := 0
+	pool := newConnPool("ut-proxy", maxConns, idleTimeout, registerer)
+	pool.dial = func(dest string, dial *ProxyDial) (*grpc.ClientConn, error) {
+		dials++
+		return dialProxyDest(dest, dial)
+	}
+
+	return pool, &dials
+}
+
+func TestNewConnPool(t *testing.T) {
+	// with default values
+	pool := newConnPool("ut-proxy", 0, 0, nil)
+	assert.Equal(t, defaultProxyMaxConns, pool.maxConns)
+	assert.Equal(t, defaultProxyIdleTimeout, pool.idleTimeout)
+	assert.Nil(t, pool.stateGauge)
+
+	// with registerer, gauges are reused
+	registry := prometheus.NewRegistry()
+	pool = newConnPool("ut-proxy", 1, time.Second, registry)
+	assert.Equal(t, 1, pool.maxConns)
+	assert.Equal(t, time.Second, pool.idleTimeout)
+	assert.NotNil(t, pool.stateGauge)
+	assert.Equal(t, pool.stateGauge, newConnPool("ut-proxy", 1, time.Second, registry).stateGauge)
+}
+
+func TestConnPool_Get(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pool, dials := newCountingConnPool(2, time.Minute, registry)
+	defer pool.close()
+
+	// connection is shared by the same destination
+	conn1, release1, err := pool.get("localhost:1972", nil)
+	assert.Nil(t, err)
+	conn2, release2, err := pool.get("localhost:1972", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, conn1, conn2)
+	assert.Equal(t, 1, *dials)
+	assert.Equal(t, 2, pool.conns["localhost:1972|"].active)
+
+	// release is idempotent
+	release1()
+	release1()
+	assert.Equal(t, 1, pool.conns["localhost:1972|"].active)
+
+	// metrics of connections
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+	assert.Len(t, families, 2)
+
+	// max connections reached and none of connections is idle
+	_, release3, err := pool.get("localhost:1973", nil)
+	assert.Nil(t, err)
+	_, _, err = pool.get("localhost:1974", nil)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// least recently used idle connection is evicted
+	release3()
+	_, release4, err := pool.get("localhost:1974", nil)
+	assert.Nil(t, err)
+	assert.Len(t, pool.conns, 2)
+	assert.NotContains(t, pool.conns, "localhost:1973|")
+	release4()
+	release2()
+
+	// closed pool rejects new calls
+	pool.close()
+	assert.Empty(t, pool.conns)
+	assert.Equal(t, connectivity.Shutdown, conn1.GetState())
+	_, _, err = pool.get("localhost:1972", nil)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestConnPool_EvictIdle(t *testing.T) {
+	pool, _ := newCountingConnPool(10, time.Minute, nil)
+	defer pool.close()
+
+	_, release1, err := pool.get("localhost:1972", nil)
+	assert.Nil(t, err)
+	_, release2, err := pool.get("localhost:1973", nil)
+	assert.Nil(t, err)
+	release1()
+	defer release2()
+
+	// idle connection is not expired
+	pool.evictIdle(time.Now())
+	assert.Len(t, pool.conns, 2)
+
+	// connection in use is kept
+	pool.evictIdle(time.Now().Add(2 * time.Minute))
+	assert.Len(t, pool.conns, 1)
+	assert.Contains(t, pool.conns, "localhost:1973|")
+
+	// connection which is shutdown is dialed again
+	pool.conns["localhost:1973|"].conn.Close()
+	_, release3, err := pool.get("localhost:1973", nil)
+	assert.Nil(t, err)
+	release3()
+	assert.NotEqual(t, connectivity.Shutdown, pool.conns["localhost:1973|"].conn.GetState())
+}
+
+func TestConnPool_Director(t *testing.T) {
+	pool, dials := newCountingConnPool(10, time.Minute, nil)
+	defer pool.close()
+
+	r := NewRule(WithPathPatterns(&PathPattern{
+		Paths: []string{"ut-path"},
+		Dest:  []string{"localhost:1972"},
+	}))
+	director := pool.director(func() *rule { return r }, nil)
+
+	ctx := grpc.NewContextWithServerTransportStream(context.TODO(), &MockServerTransportStream{
+		method: "ut-path",
+	})
+	for i := 0; i < 3; i++ {
+		outgoingCtx, conn, err := director(ctx)
+		assert.Nil(t, err)
+		assert.NotNil(t, conn)
+		assert.Equal(t, 1, pool.conns["localhost:1972|"].active)
+		proxyRelease(outgoingCtx, nil)
+		assert.Equal(t, 0, pool.conns["localhost:1972|"].active)
+	}
+	assert.Equal(t, 1, *dials)
+
+	// without matched rule
+	ctx = grpc.NewContextWithServerTransportStream(context.TODO(), &MockServerTransportStream{
+		method: "not-exist",
+	})
+	_, _, err := director(ctx)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// without release function
+	proxyRelease(nil, nil)
+	proxyRelease(context.TODO(), nil)
+}
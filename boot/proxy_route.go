@@ -0,0 +1,301 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// BootConfigProxyRoute Boot config which is for composable proxy routes.
+//
+// 1: Name: Name of route, used in logs and errors.
+// 2: Priority: Routes with higher priority are evaluated first, routes with the same priority are evaluated in
+// declared order.
+// 3: Default: Route used if none of routes and rules matched, methods, headers and ips must be empty.
+// 4: Methods: Regex of grpc methods, one of them must match.
+// 5: Headers: Predicates of metadata, all of them must match.
+// 6: Ips: CIDRs of remote IP, one of them must match.
+// 7: Dest, Balancer, HashKey, Dial: Same as rules.
+type BootConfigProxyRoute struct {
+	Name     string              `yaml:"name" json:"name"`
+	Priority int                 `yaml:"priority" json:"priority"`
+	Default  bool                `yaml:"default" json:"default"`
+	Methods  []string            `yaml:"methods" json:"methods"`
+	Headers  []HeaderMatcher     `yaml:"headers" json:"headers"`
+	Ips      []string            `yaml:"ips" json:"ips"`
+	Dest     []string            `yaml:"dest" json:"dest"`
+	Balancer string              `yaml:"balancer" json:"balancer"`
+	HashKey  string              `yaml:"hashKey" json:"hashKey"`
+	Dial     BootConfigProxyDial `yaml:"dial" json:"dial"`
+}
+
+// HeaderMatcher defines predicate of metadata with Name, which matches if any value of metadata matches.
+//
+// One of Exact, Prefix and Regex could be provided, metadata must be present if none of them provided.
+type HeaderMatcher struct {
+	Name    string `yaml:"name" json:"name"`
+	Exact   string `yaml:"exact" json:"exact"`
+	Prefix  string `yaml:"prefix" json:"prefix"`
+	Regex   string `yaml:"regex" json:"regex"`
+	Present bool   `yaml:"present" json:"present"`
+}
+
+// Route defines proxy rule which combines methods, headers and CIDRs, all provided dimensions must match.
+//
+// Regex and CIDRs are compiled once in NewRule, invalid ones never match.
+// Destination is picked with Balancer, random balancer is used if nil.
+// Destination is dialed with Dial, insecure connection is used if nil.
+type Route struct {
+	Name     string
+	Priority int
+	Default  bool
+	Methods  []string
+	Headers  []HeaderMatcher
+	Cidrs    []string
+	Dest     []string
+	Balancer Balancer
+	Dial     *ProxyDial
+}
+
+// compiledRoute is route with compiled regex and CIDRs.
+type compiledRoute struct {
+	*Route
+	methods []*regexp.Regexp
+	headers []*compiledHeaderMatcher
+	subnets []*net.IPNet
+}
+
+type compiledHeaderMatcher struct {
+	HeaderMatcher
+	key   string
+	regex *regexp.Regexp
+	valid bool
+}
+
+// routeInput is attributes of call which routes are matched against.
+type routeInput struct {
+	method    string
+	hasMethod bool
+	md        metadata.MD
+	ip        net.IP
+}
+
+// Extract attributes of call from context.
+func newRouteInput(ctx context.Context) *routeInput {
+	input := &routeInput{}
+	input.method, input.hasMethod = grpc.Method(ctx)
+	input.md, _ = metadata.FromIncomingContext(ctx)
+
+	remoteIp, _, _ := rkgrpcmid.GetRemoteAddressSet(ctx)
+	input.ip = net.ParseIP(remoteIp)
+
+	return input
+}
+
+// Compile regex and CIDRs of route, invalid ones are returned as error and never match.
+func compileRoute(route *Route) (*compiledRoute, error) {
+	var res error
+	copied := *route
+	compiled := &compiledRoute{
+		Route:   &copied,
+		methods: make([]*regexp.Regexp, 0),
+		headers: make([]*compiledHeaderMatcher, 0),
+		subnets: make([]*net.IPNet, 0),
+	}
+
+	if route.Default && (len(route.Methods) > 0 || len(route.Headers) > 0 || len(route.Cidrs) > 0) {
+		res = multierr.Append(res, errors.New("default route must not have methods, headers or ips"))
+	}
+
+	for _, method := range route.Methods {
+		regex, err := regexp.Compile(method)
+		if err != nil {
+			res = multierr.Append(res, fmt.Errorf("invalid method regex %s", method))
+			continue
+		}
+		compiled.methods = append(compiled.methods, regex)
+	}
+
+	for i := range route.Headers {
+		matcher, err := compileHeaderMatcher(&route.Headers[i])
+		res = multierr.Append(res, err)
+		compiled.headers = append(compiled.headers, matcher)
+	}
+
+	for _, cidr := range route.Cidrs {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			res = multierr.Append(res, fmt.Errorf("invalid CIDR %s in ips", cidr))
+			continue
+		}
+		compiled.subnets = append(compiled.subnets, subnet)
+	}
+
+	return compiled, res
+}
+
+// Compile header matcher, invalid matcher never matches.
+func compileHeaderMatcher(matcher *HeaderMatcher) (*compiledHeaderMatcher, error) {
+	compiled := &compiledHeaderMatcher{
+		HeaderMatcher: *matcher,
+		key:           strings.ToLower(matcher.Name),
+	}
+
+	if len(matcher.Name) < 1 {
+		return compiled, errors.New("name of header matcher is missing")
+	}
+
+	predicates := 0
+	for _, v := range []string{matcher.Exact, matcher.Prefix, matcher.Regex} {
+		if len(v) > 0 {
+			predicates++
+		}
+	}
+	if predicates > 1 || (predicates > 0 && matcher.Present) {
+		return compiled, fmt.Errorf("header matcher %s expect one of exact, prefix, regex and present", matcher.Name)
+	}
+
+	if len(matcher.Regex) > 0 {
+		regex, err := regexp.Compile(matcher.Regex)
+		if err != nil {
+			return compiled, fmt.Errorf("invalid regex %s of header matcher %s", matcher.Regex, matcher.Name)
+		}
+		compiled.regex = regex
+	}
+
+	compiled.valid = true
+	return compiled, nil
+}
+
+// Match call with methods, headers and CIDRs of route, empty dimensions are skipped.
+func (route *compiledRoute) match(input *routeInput) bool {
+	if len(route.Methods) > 0 {
+		if !input.hasMethod || !route.matchMethod(input.method) {
+			return false
+		}
+	}
+
+	for _, matcher := range route.headers {
+		if !matcher.match(input.md) {
+			return false
+		}
+	}
+
+	if len(route.Cidrs) > 0 && !route.matchIp(input.ip) {
+		return false
+	}
+
+	return true
+}
+
+func (route *compiledRoute) matchMethod(method string) bool {
+	for _, regex := range route.methods {
+		if regex.MatchString(method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (route *compiledRoute) matchIp(ip net.IP) bool {
+	for _, subnet := range route.subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Match if any value of metadata matches predicate.
+func (matcher *compiledHeaderMatcher) match(md metadata.MD) bool {
+	if !matcher.valid {
+		return false
+	}
+
+	for _, v := range md.Get(matcher.key) {
+		switch {
+		case len(matcher.Exact) > 0:
+			if v == matcher.Exact {
+				return true
+			}
+		case len(matcher.Prefix) > 0:
+			if strings.HasPrefix(v, matcher.Prefix) {
+				return true
+			}
+		case matcher.regex != nil:
+			if matcher.regex.MatchString(v) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sort routes by priority, routes with the same priority keep declared order.
+func sortRoutes(routes []*compiledRoute) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Priority > routes[j].Priority
+	})
+}
+
+// Return the first matched route and its destination, route is returned even if destination is missing.
+func matchRoutes(ctx context.Context, input *routeInput, routes []*compiledRoute) (*compiledRoute, string, func()) {
+	for _, route := range routes {
+		if route.match(input) {
+			dest, done := pickDest(ctx, route.Balancer, route.Dest)
+			return route, dest, done
+		}
+	}
+
+	return nil, "", noopDone
+}
+
+// Convert route in boot config into Route.
+func toProxyRoute(config *BootConfigProxyRoute) (*Route, error) {
+	balancer, err := NewBalancer(config.Balancer, config.HashKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dial, err := toProxyDial(&config.Dial)
+	if err != nil {
+		return nil, err
+	}
+
+	route := &Route{
+		Name:     config.Name,
+		Priority: config.Priority,
+		Default:  config.Default,
+		Methods:  config.Methods,
+		Headers:  config.Headers,
+		Cidrs:    config.Ips,
+		Dest:     config.Dest,
+		Balancer: balancer,
+		Dial:     dial,
+	}
+
+	if _, err := compileRoute(route); err != nil {
+		return nil, err
+	}
+
+	return route, nil
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Create context of call with method, remote address and metadata pairs.
+func newRouteTestCtx(method, remoteAddr string, kv ...string) context.Context {
+	ctx := grpc.NewContextWithServerTransportStream(context.TODO(), &MockServerTransportStream{
+		method: method,
+	})
+
+	md := metadata.Pairs(kv...)
+	if len(remoteAddr) > 0 {
+		md.Set("x-forwarded-remote-addr", remoteAddr)
+	}
+
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+func TestCompileRoute(t *testing.T) {
+	// happy case
+	route, err := compileRoute(&Route{
+		Methods: []string{"/billing.*"},
+		Headers: []HeaderMatcher{{Name: "env", Exact: "canary"}},
+		Cidrs:   []string{"10.0.0.0/8"},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, route.methods, 1)
+	assert.Len(t, route.headers, 1)
+	assert.Len(t, route.subnets, 1)
+
+	// with invalid regex and CIDRs
+	route, err = compileRoute(&Route{
+		Default: true,
+		Methods: []string{"["},
+		Headers: []HeaderMatcher{{Exact: "canary"}, {Name: "env", Regex: "["}, {Name: "env", Exact: "a", Present: true}},
+		Cidrs:   []string{"10.0.0.0/33"},
+	})
+	assert.Len(t, multierr.Errors(err), 6)
+	assert.Empty(t, route.methods)
+	assert.Empty(t, route.subnets)
+
+	// invalid ones never match
+	assert.False(t, route.match(newRouteInput(newRouteTestCtx("[", "10.0.0.1:1949", "env", "a"))))
+}
+
+func TestCompiledHeaderMatcher_Match(t *testing.T) {
+	md := metadata.Pairs("env", "canary-1", "env", "prod", "x-user", "")
+
+	for _, tc := range []struct {
+		matcher HeaderMatcher
+		matched bool
+	}{
+		{HeaderMatcher{Name: "Env", Exact: "prod"}, true},
+		{HeaderMatcher{Name: "env", Exact: "canary"}, false},
+		{HeaderMatcher{Name: "env", Prefix: "canary-"}, true},
+		{HeaderMatcher{Name: "env", Prefix: "dev-"}, false},
+		{HeaderMatcher{Name: "env", Regex: "^canary-[0-9]+$"}, true},
+		{HeaderMatcher{Name: "env", Regex: "^dev"}, false},
+		{HeaderMatcher{Name: "x-user"}, true},
+		{HeaderMatcher{Name: "x-user", Present: true}, true},
+		{HeaderMatcher{Name: "x-missing", Present: true}, false},
+	} {
+		matcher, err := compileHeaderMatcher(&tc.matcher)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.matched, matcher.match(md), tc.matcher)
+	}
+
+	// without metadata
+	matcher, _ := compileHeaderMatcher(&HeaderMatcher{Name: "x-user"})
+	assert.False(t, matcher.match(nil))
+}
+
+func TestRule_MatchRoutes(t *testing.T) {
+	r := NewRule(
+		WithPathPatterns(&PathPattern{
+			Paths: []string{"/billing.*"},
+			Dest:  []string{"localhost:1972"},
+		}),
+		WithRoutes(
+			&Route{
+				Name:    "billing",
+				Methods: []string{"/billing.*"},
+				Dest:    []string{"localhost:1973"},
+			},
+			&Route{
+				Name:     "billing-canary",
+				Priority: 10,
+				Methods:  []string{"/billing.*"},
+				Headers:  []HeaderMatcher{{Name: "env", Exact: "canary"}},
+				Dest:     []string{"localhost:1974"},
+			},
+			&Route{
+				Name:  "internal",
+				Cidrs: []string{"10.0.0.0/8"},
+				Dest:  []string{"localhost:1975"},
+			},
+			&Route{
+				Name:    "default",
+				Default: true,
+				Dest:    []string{"localhost:1976"},
+			}))
+
+	match := func(ctx context.Context) string {
+		dest, _, done, ok := r.match(ctx)
+		assert.True(t, ok)
+		done()
+		return dest
+	}
+
+	// route with higher priority and all dimensions matched
+	assert.Equal(t, "localhost:1974", match(newRouteTestCtx("/billing.v1.Pay/Pay", "", "env", "canary")))
+
+	// routes with the same priority are evaluated in declared order, routes are evaluated before patterns
+	assert.Equal(t, "localhost:1973", match(newRouteTestCtx("/billing.v1.Pay/Pay", "10.0.0.1:1949", "env", "prod")))
+	assert.Equal(t, "localhost:1975", match(newRouteTestCtx("/order.v1.Order/Get", "10.0.0.1:1949")))
+
+	// default route
+	assert.Equal(t, "localhost:1976", match(newRouteTestCtx("/order.v1.Order/Get", "192.168.0.1:1949")))
+
+	// changes of routes after NewRule are not applied
+	r.Routes[0].Methods = nil
+	assert.Equal(t, "localhost:1976", match(newRouteTestCtx("/order.v1.Order/Get", "192.168.0.1:1949")))
+
+	// without default route
+	r = NewRule(WithRoutes(&Route{Methods: []string{"/billing.*"}, Dest: []string{"localhost:1973"}}))
+	_, _, _, ok := r.match(newRouteTestCtx("/order.v1.Order/Get", ""))
+	assert.False(t, ok)
+
+	// dests and dial options of routes
+	dial := &ProxyDial{Authority: "billing"}
+	r = NewRule(WithRoutes(&Route{Default: true, Dest: []string{"localhost:1973;weight=2"}, Dial: dial}))
+	assert.Equal(t, []proxyTarget{{addr: "localhost:1973", dial: dial}}, r.targets())
+	assert.Equal(t, dial, r.dialOf("localhost:1973", r.defaultRoute))
+}
+
+func TestToProxyRule_WithRoutes(t *testing.T) {
+	config := &BootConfigProxy{}
+	assert.Nil(t, unmarshalBootYAML([]byte(`
+routes:
+  - name: billing-canary
+    priority: 10
+    methods: ["/billing.*"]
+    headers:
+      - name: env
+        prefix: canary
+    dest: ["localhost:1973", "localhost:1974"]
+    balancer: roundRobin
+  - default: true
+    dest: ["localhost:1975"]
+`), config))
+
+	r, err := toProxyRule(config)
+	assert.Nil(t, err)
+	assert.Len(t, r.routes, 1)
+	assert.NotNil(t, r.defaultRoute)
+
+	res := make([]string, 0)
+	for i := 0; i < 2; i++ {
+		dest, _, _, ok := r.match(newRouteTestCtx("/billing.v1.Pay/Pay", "", "env", "canary-1"))
+		assert.True(t, ok)
+		res = append(res, dest)
+	}
+	assert.Equal(t, []string{"localhost:1973", "localhost:1974"}, res)
+
+	dest, _, _, ok := r.match(newRouteTestCtx("/billing.v1.Pay/Pay", "", "env", "prod"))
+	assert.True(t, ok)
+	assert.Equal(t, "localhost:1975", dest)
+
+	// with invalid route
+	config.Routes[0].Methods = []string{"["}
+	_, err = toProxyRule(config)
+	assert.NotNil(t, err)
+}
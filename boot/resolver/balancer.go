@@ -0,0 +1,107 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcresolver
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/balancer/roundrobin"
+)
+
+const (
+	// BalancerRoundRobin picks addresses in turn
+	BalancerRoundRobin = roundrobin.Name
+	// BalancerPickFirst picks the first available address
+	BalancerPickFirst = "pick_first"
+	// BalancerWeighted picks addresses in turn proportional to weights of addresses
+	BalancerWeighted = "weighted"
+
+	// WeightedRoundRobinName is registered name of weighted balancer
+	WeightedRoundRobinName = "rk_weighted_round_robin"
+)
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(WeightedRoundRobinName, &weightedPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+// ServiceConfig returns service config in JSON which selects balancer.
+//
+// Supported balancers are round_robin, pick_first and weighted, empty string would be returned if balancer is empty.
+func ServiceConfig(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return "", nil
+	case BalancerRoundRobin:
+	case BalancerPickFirst:
+	case BalancerWeighted, WeightedRoundRobinName:
+		name = WeightedRoundRobinName
+	default:
+		return "", fmt.Errorf("unsupported balancer %s", name)
+	}
+
+	return fmt.Sprintf(`{"loadBalancingConfig":[{"%s":{}}]}`, strings.ToLower(name)), nil
+}
+
+// weightedPickerBuilder builds picker with weights in BalancerAttributes of addresses.
+//
+// Weight of existing address is kept as it is until address removed by resolver.
+type weightedPickerBuilder struct{}
+
+// Build picker with ready SubConns.
+func (*weightedPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) < 1 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	picker := &weightedPicker{
+		items: make([]*weightedItem, 0, len(info.ReadySCs)),
+	}
+	for sc, sci := range info.ReadySCs {
+		picker.items = append(picker.items, &weightedItem{
+			subConn: sc,
+			weight:  GetWeight(sci.Address),
+		})
+	}
+
+	return picker
+}
+
+// weightedPicker picks SubConn with smooth weighted round robin.
+type weightedPicker struct {
+	items []*weightedItem
+	lock  sync.Mutex
+}
+
+type weightedItem struct {
+	subConn balancer.SubConn
+	weight  int
+	current int
+}
+
+// Pick SubConn whose current weight is the largest.
+func (p *weightedPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	total := 0
+	var best *weightedItem
+	for _, item := range p.items {
+		item.current += item.weight
+		total += item.weight
+
+		if best == nil || item.current > best.current {
+			best = item
+		}
+	}
+
+	best.current -= total
+
+	return balancer.PickResult{SubConn: best.subConn}, nil
+}
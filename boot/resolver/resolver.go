@@ -0,0 +1,370 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+// Package rkgrpcresolver provides resolvers of static endpoints, watched file and DNS SRV records
+// which could be used by grpc client together with balancers.
+package rkgrpcresolver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	// SchemeStatic scheme of static resolver
+	SchemeStatic = "rk-static"
+	// SchemeFile scheme of file resolver
+	SchemeFile = "rk-file"
+	// SchemeDns scheme of DNS SRV resolver
+	SchemeDns = "rk-dns"
+
+	// TypeStatic resolves static endpoints
+	TypeStatic = "static"
+	// TypeFile resolves endpoints from file which would be watched
+	TypeFile = "file"
+	// TypeDns resolves endpoints from DNS SRV records
+	TypeDns = "dns"
+
+	defaultRefreshInterval = 5 * time.Second
+	defaultLookupTimeout   = 5 * time.Second
+)
+
+// Endpoint of a backend with weight which is used by weighted balancer.
+type Endpoint struct {
+	Address string `yaml:"address" json:"address"`
+	Weight  int    `yaml:"weight" json:"weight"`
+}
+
+// BootConfig for YAML
+type BootConfig struct {
+	Type              string     `yaml:"type" json:"type"`
+	Endpoints         []Endpoint `yaml:"endpoints" json:"endpoints"`
+	Path              string     `yaml:"path" json:"path"`
+	DnsName           string     `yaml:"dnsName" json:"dnsName"`
+	DnsServer         string     `yaml:"dnsServer" json:"dnsServer"`
+	RefreshIntervalMs int64      `yaml:"refreshIntervalMs" json:"refreshIntervalMs"`
+}
+
+// NewBuilderFromConfig creates Builder from BootConfig, nil would be returned if type is empty.
+func NewBuilderFromConfig(config *BootConfig, name string) (*Builder, error) {
+	interval := time.Duration(config.RefreshIntervalMs) * time.Millisecond
+
+	switch strings.ToLower(config.Type) {
+	case "":
+		return nil, nil
+	case TypeStatic:
+		if len(config.Endpoints) < 1 {
+			return nil, errors.New("endpoints is empty for static resolver")
+		}
+		return NewStaticBuilder(name, config.Endpoints...), nil
+	case TypeFile:
+		if len(config.Path) < 1 {
+			return nil, errors.New("path is empty for file resolver")
+		}
+		return NewFileBuilder(config.Path, interval), nil
+	case TypeDns:
+		if len(config.DnsName) < 1 {
+			return nil, errors.New("dnsName is empty for dns resolver")
+		}
+		return NewDnsBuilder(config.DnsName, config.DnsServer, interval), nil
+	default:
+		return nil, fmt.Errorf("unsupported resolver type %s", config.Type)
+	}
+}
+
+// Builder implements resolver.Builder.
+//
+// Builder resolves addresses with lookup function, and refreshes addresses periodically if interval is larger than zero.
+// Use Target() as dial target together with grpc.WithResolvers(builder).
+type Builder struct {
+	scheme   string
+	endpoint string
+	interval time.Duration
+	lookup   func(ctx context.Context) ([]resolver.Address, error)
+}
+
+// NewStaticBuilder creates Builder which always resolves given endpoints.
+func NewStaticBuilder(name string, endpoints ...Endpoint) *Builder {
+	addrs := make([]resolver.Address, 0)
+	for i := range endpoints {
+		addrs = append(addrs, SetWeight(resolver.Address{Addr: endpoints[i].Address}, endpoints[i].Weight))
+	}
+
+	return &Builder{
+		scheme:   SchemeStatic,
+		endpoint: name,
+		lookup: func(context.Context) ([]resolver.Address, error) {
+			return addrs, nil
+		},
+	}
+}
+
+// NewFileBuilder creates Builder which reads endpoints from file and watches it with interval.
+//
+// One endpoint per line with format of "host:port [weight]", empty lines and lines start with # are ignored.
+func NewFileBuilder(path string, interval time.Duration) *Builder {
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+
+	return &Builder{
+		scheme:   SchemeFile,
+		endpoint: path,
+		interval: interval,
+		lookup: func(context.Context) ([]resolver.Address, error) {
+			return readFile(path)
+		},
+	}
+}
+
+// NewDnsBuilder creates Builder which resolves SRV records of dnsName, e.g. _grpc._tcp.example.com.
+//
+// System DNS server would be used if dnsServer is empty. Only records with the lowest priority are used,
+// weight of SRV record is used as weight of address.
+func NewDnsBuilder(dnsName, dnsServer string, interval time.Duration) *Builder {
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+
+	res := net.DefaultResolver
+	if len(dnsServer) > 0 {
+		res = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, dnsServer)
+			},
+		}
+	}
+
+	return &Builder{
+		scheme:   SchemeDns,
+		endpoint: dnsName,
+		interval: interval,
+		lookup: func(ctx context.Context) ([]resolver.Address, error) {
+			return lookupSRV(ctx, res, dnsName)
+		},
+	}
+}
+
+// Target returns dial target which should be used with builder.
+func (b *Builder) Target() string {
+	return b.scheme + ":///" + b.endpoint
+}
+
+// Scheme returns scheme of builder.
+func (b *Builder) Scheme() string {
+	return b.scheme
+}
+
+// Build creates resolver for target.
+func (b *Builder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &rkResolver{
+		builder: b,
+		cc:      cc,
+		ctx:     ctx,
+		cancel:  cancel,
+		rn:      make(chan struct{}, 1),
+	}
+
+	// resolve synchronously at the first time, resolver keeps watching on failure
+	if err := r.resolve(); err != nil {
+		cc.ReportError(err)
+	}
+
+	r.wg.Add(1)
+	go r.watch()
+
+	return r, nil
+}
+
+// rkResolver resolves addresses with lookup function of builder.
+type rkResolver struct {
+	builder *Builder
+	cc      resolver.ClientConn
+	ctx     context.Context
+	cancel  context.CancelFunc
+	rn      chan struct{}
+	wg      sync.WaitGroup
+	last    []resolver.Address
+}
+
+// ResolveNow is called by grpc while connection needs to be re-resolved.
+func (r *rkResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.rn <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops watching.
+func (r *rkResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// Resolve periodically or while ResolveNow called.
+func (r *rkResolver) watch() {
+	defer r.wg.Done()
+
+	var tick <-chan time.Time
+	if r.builder.interval > 0 {
+		ticker := time.NewTicker(r.builder.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-tick:
+		case <-r.rn:
+		}
+
+		if err := r.resolve(); err != nil {
+			r.cc.ReportError(err)
+		}
+	}
+}
+
+// Lookup addresses and update state of connection if addresses changed.
+func (r *rkResolver) resolve() error {
+	ctx, cancel := context.WithTimeout(r.ctx, defaultLookupTimeout)
+	defer cancel()
+
+	addrs, err := r.builder.lookup(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(addrs) < 1 {
+		return fmt.Errorf("no address resolved from %s", r.builder.Target())
+	}
+
+	if r.last != nil && equalAddresses(r.last, addrs) {
+		return nil
+	}
+
+	r.last = addrs
+	return r.cc.UpdateState(resolver.State{Addresses: addrs})
+}
+
+// Read addresses from file.
+func readFile(path string) ([]resolver.Address, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]resolver.Address, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) < 1 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		weight := 0
+		if len(fields) > 1 {
+			if weight, err = strconv.Atoi(fields[1]); err != nil {
+				return nil, fmt.Errorf("invalid weight in line %s of %s", line, path)
+			}
+		}
+
+		res = append(res, SetWeight(resolver.Address{Addr: fields[0]}, weight))
+	}
+
+	return res, scanner.Err()
+}
+
+// Lookup SRV records, only records with the lowest priority are used.
+func lookupSRV(ctx context.Context, res *net.Resolver, name string) ([]resolver.Address, error) {
+	_, records, err := res.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) < 1 {
+		return nil, fmt.Errorf("no SRV record found for %s", name)
+	}
+
+	priority := records[0].Priority
+	for i := range records {
+		if records[i].Priority < priority {
+			priority = records[i].Priority
+		}
+	}
+
+	addrs := make([]resolver.Address, 0)
+	for i := range records {
+		if records[i].Priority != priority {
+			continue
+		}
+
+		host := strings.TrimSuffix(records[i].Target, ".")
+		addr := net.JoinHostPort(host, strconv.Itoa(int(records[i].Port)))
+		addrs = append(addrs, SetWeight(resolver.Address{Addr: addr}, int(records[i].Weight)))
+	}
+
+	// keep the order stable, so that unchanged records won't update state
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].Addr < addrs[j].Addr
+	})
+
+	return addrs, nil
+}
+
+// Compare addresses and weights.
+func equalAddresses(a, b []resolver.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Addr != b[i].Addr || GetWeight(a[i]) != GetWeight(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ***************** Weight *****************
+
+type weightKey struct{}
+
+// SetWeight returns address with weight in BalancerAttributes, weight less than 1 would be treated as 1.
+func SetWeight(addr resolver.Address, weight int) resolver.Address {
+	if weight < 1 {
+		weight = 1
+	}
+
+	addr.BalancerAttributes = addr.BalancerAttributes.WithValue(weightKey{}, weight)
+	return addr
+}
+
+// GetWeight returns weight of address, 1 would be returned if missing.
+func GetWeight(addr resolver.Address) int {
+	if weight, ok := addr.BalancerAttributes.Value(weightKey{}).(int); ok && weight > 0 {
+		return weight
+	}
+
+	return 1
+}
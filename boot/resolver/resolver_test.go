@@ -0,0 +1,328 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcresolver
+
+import (
+	"context"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	testdata "github.com/rookie-ninja/rk-grpc/v2/example/middleware/proto/testdata"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestNewBuilderFromConfig(t *testing.T) {
+	// empty type
+	builder, err := NewBuilderFromConfig(&BootConfig{}, "ut")
+	assert.Nil(t, builder)
+	assert.Nil(t, err)
+
+	// static
+	builder, err = NewBuilderFromConfig(&BootConfig{
+		Type:      "static",
+		Endpoints: []Endpoint{{Address: "localhost:8080"}},
+	}, "ut")
+	assert.Nil(t, err)
+	assert.Equal(t, "rk-static:///ut", builder.Target())
+	assert.Equal(t, SchemeStatic, builder.Scheme())
+
+	// file
+	builder, err = NewBuilderFromConfig(&BootConfig{Type: "file", Path: "/ut/endpoints"}, "ut")
+	assert.Nil(t, err)
+	assert.Equal(t, "rk-file:////ut/endpoints", builder.Target())
+	assert.Equal(t, defaultRefreshInterval, builder.interval)
+
+	// dns
+	builder, err = NewBuilderFromConfig(&BootConfig{
+		Type:              "DNS",
+		DnsName:           "_grpc._tcp.example.com",
+		RefreshIntervalMs: 1000,
+	}, "ut")
+	assert.Nil(t, err)
+	assert.Equal(t, "rk-dns:///_grpc._tcp.example.com", builder.Target())
+	assert.Equal(t, time.Second, builder.interval)
+
+	// invalid configs
+	for _, config := range []*BootConfig{
+		{Type: "static"},
+		{Type: "file"},
+		{Type: "dns"},
+		{Type: "unknown"},
+	} {
+		builder, err = NewBuilderFromConfig(config, "ut")
+		assert.Nil(t, builder)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestStaticBuilder_RoundRobin(t *testing.T) {
+	addr1, addr2 := startServer(t, "s1"), startServer(t, "s2")
+	builder := NewStaticBuilder("ut", Endpoint{Address: addr1}, Endpoint{Address: addr2})
+
+	counts := callN(t, dial(t, builder, BalancerRoundRobin, 2), 10)
+	assert.Equal(t, 5, counts["s1"])
+	assert.Equal(t, 5, counts["s2"])
+}
+
+func TestStaticBuilder_PickFirst(t *testing.T) {
+	addr1, addr2 := startServer(t, "s1"), startServer(t, "s2")
+	builder := NewStaticBuilder("ut", Endpoint{Address: addr1}, Endpoint{Address: addr2})
+
+	counts := callN(t, dial(t, builder, BalancerPickFirst, 1), 10)
+	assert.Equal(t, 10, counts["s1"])
+}
+
+func TestStaticBuilder_Weighted(t *testing.T) {
+	addr1, addr2 := startServer(t, "s1"), startServer(t, "s2")
+	builder := NewStaticBuilder("ut",
+		Endpoint{Address: addr1, Weight: 3},
+		Endpoint{Address: addr2, Weight: 1})
+
+	counts := callN(t, dial(t, builder, BalancerWeighted, 2), 8)
+	assert.Equal(t, 6, counts["s1"])
+	assert.Equal(t, 2, counts["s2"])
+}
+
+func TestFileBuilder(t *testing.T) {
+	addr1, addr2 := startServer(t, "s1"), startServer(t, "s2")
+
+	filePath := path.Join(t.TempDir(), "endpoints")
+	assert.Nil(t, os.WriteFile(filePath, []byte("# comment\n\n"+addr1+" 2\n"), 0644))
+
+	conn := dial(t, NewFileBuilder(filePath, 10*time.Millisecond), BalancerRoundRobin, 1)
+	assert.Equal(t, 3, callN(t, conn, 3)["s1"])
+
+	// file changed
+	assert.Nil(t, os.WriteFile(filePath, []byte(addr2+"\n"), 0644))
+	assert.Eventually(t, func() bool {
+		return callN(t, conn, 1)["s2"] == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// invalid file
+	assert.Nil(t, os.WriteFile(filePath, []byte(addr2+" invalid\n"), 0644))
+	_, err := readFile(filePath)
+	assert.NotNil(t, err)
+	_, err = readFile(path.Join(t.TempDir(), "not-exist"))
+	assert.NotNil(t, err)
+}
+
+func TestDnsBuilder(t *testing.T) {
+	addr1, addr2 := startServer(t, "s1"), startServer(t, "s2")
+	_, port1, _ := net.SplitHostPort(addr1)
+	_, port2, _ := net.SplitHostPort(addr2)
+
+	dns := startFakeDns(t)
+	dns.SetSRV("_grpc._tcp.ut.local.", srv(0, 3, port1), srv(0, 1, port2), srv(10, 1, "1"))
+
+	builder := NewDnsBuilder("_grpc._tcp.ut.local", dns.Addr(), 10*time.Millisecond)
+
+	// record with lower priority is ignored
+	addrs, err := builder.lookup(context.TODO())
+	assert.Nil(t, err)
+	assert.Len(t, addrs, 2)
+
+	counts := callN(t, dial(t, builder, BalancerWeighted, 2), 8)
+	assert.Equal(t, 6, counts["s1"])
+	assert.Equal(t, 2, counts["s2"])
+
+	// unknown name
+	_, err = NewDnsBuilder("_grpc._tcp.unknown.local", dns.Addr(), 0).lookup(context.TODO())
+	assert.NotNil(t, err)
+}
+
+func TestWeight(t *testing.T) {
+	assert.Equal(t, 1, GetWeight(resolver.Address{}))
+	assert.Equal(t, 1, GetWeight(SetWeight(resolver.Address{}, -1)))
+	assert.Equal(t, 5, GetWeight(SetWeight(resolver.Address{}, 5)))
+}
+
+// ************ Test utility ************
+
+// GreeterServer returns its name as message
+type GreeterServer struct {
+	name string
+}
+
+func (s *GreeterServer) SayHello(ctx context.Context, req *testdata.HelloRequest) (*testdata.HelloResponse, error) {
+	return &testdata.HelloResponse{Message: s.name}, nil
+}
+
+func startServer(t *testing.T, name string) string {
+	lis, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+
+	server := grpc.NewServer()
+	testdata.RegisterGreeterServer(server, &GreeterServer{name: name})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+func dial(t *testing.T, builder *Builder, balancerName string, servers int) *grpc.ClientConn {
+	serviceConfig, err := ServiceConfig(balancerName)
+	assert.Nil(t, err)
+
+	conn, err := grpc.Dial(builder.Target(),
+		grpc.WithResolvers(builder),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	// wait until expected servers are connected
+	client := testdata.NewGreeterClient(conn)
+	assert.Eventually(t, func() bool {
+		seen := make(map[string]bool)
+		for i := 0; i < 8; i++ {
+			resp, err := client.SayHello(context.TODO(), &testdata.HelloRequest{}, grpc.WaitForReady(true))
+			if err != nil {
+				return false
+			}
+			seen[resp.GetMessage()] = true
+		}
+		return len(seen) == servers
+	}, 3*time.Second, 10*time.Millisecond)
+
+	return conn
+}
+
+func callN(t *testing.T, conn *grpc.ClientConn, n int) map[string]int {
+	res := make(map[string]int)
+	client := testdata.NewGreeterClient(conn)
+	for i := 0; i < n; i++ {
+		resp, err := client.SayHello(context.TODO(), &testdata.HelloRequest{}, grpc.WaitForReady(true))
+		assert.Nil(t, err)
+		res[resp.GetMessage()]++
+	}
+
+	return res
+}
+
+func srv(priority, weight uint16, port string) dnsmessage.SRVResource {
+	p, _ := strconv.Atoi(port)
+	return dnsmessage.SRVResource{
+		Priority: priority,
+		Weight:   weight,
+		Port:     uint16(p),
+		Target:   dnsmessage.MustNewName("localhost."),
+	}
+}
+
+// FakeDns is a local DNS server over UDP which answers SRV queries only.
+type FakeDns struct {
+	conn    net.PacketConn
+	records map[string][]dnsmessage.SRVResource
+	lock    sync.Mutex
+}
+
+func startFakeDns(t *testing.T) *FakeDns {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	dns := &FakeDns{
+		conn:    conn,
+		records: make(map[string][]dnsmessage.SRVResource),
+	}
+	go dns.serve()
+	t.Cleanup(func() { conn.Close() })
+
+	return dns
+}
+
+// Addr returns address of DNS server
+func (d *FakeDns) Addr() string {
+	return d.conn.LocalAddr().String()
+}
+
+// SetSRV set SRV records of fully qualified name
+func (d *FakeDns) SetSRV(name string, records ...dnsmessage.SRVResource) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.records[strings.ToLower(name)] = records
+}
+
+func (d *FakeDns) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := d.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		if resp, err := d.answer(buf[:n]); err == nil {
+			d.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (d *FakeDns) answer(req []byte) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(req)
+	if err != nil {
+		return nil, err
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	d.lock.Lock()
+	records, ok := d.records[strings.ToLower(question.Name.String())]
+	d.lock.Unlock()
+
+	builder := dnsmessage.NewBuilder(make([]byte, 0, 512), dnsmessage.Header{
+		ID:            header.ID,
+		Response:      true,
+		Authoritative: true,
+		RCode:         dnsmessage.RCodeSuccess,
+	})
+	if !ok {
+		builder = dnsmessage.NewBuilder(make([]byte, 0, 512), dnsmessage.Header{
+			ID:            header.ID,
+			Response:      true,
+			Authoritative: true,
+			RCode:         dnsmessage.RCodeNameError,
+		})
+	}
+	builder.EnableCompression()
+
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(question); err != nil {
+		return nil, err
+	}
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+
+	if question.Type == dnsmessage.TypeSRV {
+		for i := range records {
+			err := builder.SRVResource(dnsmessage.ResourceHeader{
+				Name:  question.Name,
+				Class: dnsmessage.ClassINET,
+				TTL:   1,
+			}, records[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return builder.Finish()
+}
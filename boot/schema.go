@@ -0,0 +1,128 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+//go:generate sh -c "go run ../cmd/rkgrpc-lint --schema > boot.schema.json"
+
+// SchemaDraft is JSON Schema draft which BootConfigSchema follows.
+const SchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is a subset of JSON Schema which is able to describe BootConfig.
+//
+// AdditionalProperties is either bool or *Schema.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// Property returns schema of property with exact name, or the one with same name under case folding.
+//
+// Nil will be returned if property is missing.
+func (s *Schema) Property(name string) (string, *Schema) {
+	if s == nil {
+		return "", nil
+	}
+
+	if v, ok := s.Properties[name]; ok {
+		return name, v
+	}
+
+	for k, v := range s.Properties {
+		if strings.EqualFold(k, name) {
+			return k, v
+		}
+	}
+
+	if v, ok := s.AdditionalProperties.(*Schema); ok {
+		return name, v
+	}
+
+	return "", nil
+}
+
+// BootConfigSchema Generate JSON Schema of BootConfig from yaml tags of struct fields.
+//
+// Only grpc section is described, other sections of boot.yaml are allowed.
+// Shipped as boot.schema.json, run go generate after changing BootConfig.
+func BootConfigSchema() *Schema {
+	res := toSchema(reflect.TypeOf(BootConfig{}))
+	res.Schema = SchemaDraft
+	res.Title = "rk-grpc boot config"
+	res.AdditionalProperties = true
+
+	return res
+}
+
+// MarshalBootConfigSchema Marshal BootConfigSchema into indented JSON.
+func MarshalBootConfigSchema() ([]byte, error) {
+	res, err := json.MarshalIndent(BootConfigSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(res, '\n'), nil
+}
+
+// Convert type to schema, fields without yaml tag are named with field name.
+func toSchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: toSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: toSchema(t.Elem())}
+	case reflect.Struct:
+		res := &Schema{
+			Type:                 "object",
+			Properties:           make(map[string]*Schema),
+			AdditionalProperties: false,
+		}
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			switch name {
+			case "-":
+				continue
+			case "":
+				name = field.Name
+			}
+
+			res.Properties[name] = toSchema(field.Type)
+		}
+
+		return res
+	default:
+		// any value
+		return &Schema{}
+	}
+}
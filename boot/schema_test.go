@@ -0,0 +1,55 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootConfigSchema(t *testing.T) {
+	schema := BootConfigSchema()
+	assert.Equal(t, SchemaDraft, schema.Schema)
+	assert.Equal(t, true, schema.AdditionalProperties)
+
+	_, grpc := schema.Property("grpc")
+	assert.Equal(t, "array", grpc.Type)
+	assert.Equal(t, false, grpc.Items.AdditionalProperties)
+
+	// with case folding
+	name, port := grpc.Items.Property("PORT")
+	assert.Equal(t, "port", name)
+	assert.Equal(t, "integer", port.Type)
+
+	// proxy rules
+	_, proxy := grpc.Items.Property("proxy")
+	_, rules := proxy.Property("rules")
+	_, ips := rules.Items.Property("ips")
+	assert.Equal(t, "array", ips.Type)
+	assert.Equal(t, "string", ips.Items.Type)
+
+	// optional gateway options
+	_, gwOption := grpc.Items.Property("gwOption")
+	_, marshal := gwOption.Property("marshal")
+	_, multiline := marshal.Property("multiline")
+	assert.Equal(t, "boolean", multiline.Type)
+
+	// missing property
+	name, missing := grpc.Items.Property("not-exist")
+	assert.Empty(t, name)
+	assert.Nil(t, missing)
+}
+
+func TestBootConfigSchema_Shipped(t *testing.T) {
+	expected, err := MarshalBootConfigSchema()
+	assert.Nil(t, err)
+
+	shipped, err := os.ReadFile("boot.schema.json")
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), string(shipped), "boot.schema.json is outdated, run go generate")
+}
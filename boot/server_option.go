@@ -0,0 +1,159 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// BootConfigServerOption Boot config of grpc.ServerOption, zero values are ignored and grpc defaults are used.
+type BootConfigServerOption struct {
+	Keepalive struct {
+		TimeMs                  int64 `yaml:"timeMs" json:"timeMs"`
+		TimeoutMs               int64 `yaml:"timeoutMs" json:"timeoutMs"`
+		MaxConnectionIdleMs     int64 `yaml:"maxConnectionIdleMs" json:"maxConnectionIdleMs"`
+		MaxConnectionAgeMs      int64 `yaml:"maxConnectionAgeMs" json:"maxConnectionAgeMs"`
+		MaxConnectionAgeGraceMs int64 `yaml:"maxConnectionAgeGraceMs" json:"maxConnectionAgeGraceMs"`
+	} `yaml:"keepalive" json:"keepalive"`
+	KeepaliveEnforcement struct {
+		MinTimeMs           int64 `yaml:"minTimeMs" json:"minTimeMs"`
+		PermitWithoutStream bool  `yaml:"permitWithoutStream" json:"permitWithoutStream"`
+	} `yaml:"keepaliveEnforcement" json:"keepaliveEnforcement"`
+	MaxConcurrentStreams       uint32 `yaml:"maxConcurrentStreams" json:"maxConcurrentStreams"`
+	MaxRecvMsgSizeBytes        int    `yaml:"maxRecvMsgSizeBytes" json:"maxRecvMsgSizeBytes"`
+	MaxSendMsgSizeBytes        int    `yaml:"maxSendMsgSizeBytes" json:"maxSendMsgSizeBytes"`
+	InitialWindowSizeBytes     int32  `yaml:"initialWindowSizeBytes" json:"initialWindowSizeBytes"`
+	InitialConnWindowSizeBytes int32  `yaml:"initialConnWindowSizeBytes" json:"initialConnWindowSizeBytes"`
+}
+
+// BootConfigHttpServer Boot config of http.Server which serves grpc-gateway, zero values are ignored.
+type BootConfigHttpServer struct {
+	ReadTimeoutMs       int64 `yaml:"readTimeoutMs" json:"readTimeoutMs"`
+	ReadHeaderTimeoutMs int64 `yaml:"readHeaderTimeoutMs" json:"readHeaderTimeoutMs"`
+	WriteTimeoutMs      int64 `yaml:"writeTimeoutMs" json:"writeTimeoutMs"`
+	IdleTimeoutMs       int64 `yaml:"idleTimeoutMs" json:"idleTimeoutMs"`
+	MaxHeaderBytes      int   `yaml:"maxHeaderBytes" json:"maxHeaderBytes"`
+}
+
+// HttpServerOption Modify http.Server of GrpcEntry before serving.
+type HttpServerOption func(*http.Server)
+
+// ToServerOptions Convert BootConfigServerOption to grpc.ServerOption.
+func ToServerOptions(conf *BootConfigServerOption) []grpc.ServerOption {
+	opts := make([]grpc.ServerOption, 0)
+
+	ka := conf.Keepalive
+	if ka.TimeMs > 0 || ka.TimeoutMs > 0 || ka.MaxConnectionIdleMs > 0 || ka.MaxConnectionAgeMs > 0 || ka.MaxConnectionAgeGraceMs > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  toDuration(ka.TimeMs),
+			Timeout:               toDuration(ka.TimeoutMs),
+			MaxConnectionIdle:     toDuration(ka.MaxConnectionIdleMs),
+			MaxConnectionAge:      toDuration(ka.MaxConnectionAgeMs),
+			MaxConnectionAgeGrace: toDuration(ka.MaxConnectionAgeGraceMs),
+		}))
+	}
+
+	kep := conf.KeepaliveEnforcement
+	if kep.MinTimeMs > 0 || kep.PermitWithoutStream {
+		policy := keepalive.EnforcementPolicy{
+			MinTime:             toDuration(kep.MinTimeMs),
+			PermitWithoutStream: kep.PermitWithoutStream,
+		}
+		// keep default of grpc if only permitWithoutStream was provided
+		if policy.MinTime < 1 {
+			policy.MinTime = 5 * time.Minute
+		}
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(policy))
+	}
+
+	if conf.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(conf.MaxConcurrentStreams))
+	}
+
+	if conf.MaxRecvMsgSizeBytes > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(conf.MaxRecvMsgSizeBytes))
+	}
+
+	if conf.MaxSendMsgSizeBytes > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(conf.MaxSendMsgSizeBytes))
+	}
+
+	if conf.InitialWindowSizeBytes > 0 {
+		opts = append(opts, grpc.InitialWindowSize(conf.InitialWindowSizeBytes))
+	}
+
+	if conf.InitialConnWindowSizeBytes > 0 {
+		opts = append(opts, grpc.InitialConnWindowSize(conf.InitialConnWindowSizeBytes))
+	}
+
+	return opts
+}
+
+// ToGwDialOptions Convert BootConfigServerOption to grpc.DialOption used by grpc-gateway,
+// so that gateway is able to send and receive messages allowed by grpc server.
+func ToGwDialOptions(conf *BootConfigServerOption) []grpc.DialOption {
+	opts := make([]grpc.DialOption, 0)
+	callOpts := make([]grpc.CallOption, 0)
+
+	if conf.MaxRecvMsgSizeBytes > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(conf.MaxRecvMsgSizeBytes))
+	}
+
+	if conf.MaxSendMsgSizeBytes > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(conf.MaxSendMsgSizeBytes))
+	}
+
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	return opts
+}
+
+// ToHttpServerOptions Convert BootConfigHttpServer to HttpServerOption.
+func ToHttpServerOptions(conf *BootConfigHttpServer) []HttpServerOption {
+	opts := make([]HttpServerOption, 0)
+
+	if conf.ReadTimeoutMs > 0 {
+		opts = append(opts, func(server *http.Server) {
+			server.ReadTimeout = toDuration(conf.ReadTimeoutMs)
+		})
+	}
+
+	if conf.ReadHeaderTimeoutMs > 0 {
+		opts = append(opts, func(server *http.Server) {
+			server.ReadHeaderTimeout = toDuration(conf.ReadHeaderTimeoutMs)
+		})
+	}
+
+	if conf.WriteTimeoutMs > 0 {
+		opts = append(opts, func(server *http.Server) {
+			server.WriteTimeout = toDuration(conf.WriteTimeoutMs)
+		})
+	}
+
+	if conf.IdleTimeoutMs > 0 {
+		opts = append(opts, func(server *http.Server) {
+			server.IdleTimeout = toDuration(conf.IdleTimeoutMs)
+		})
+	}
+
+	if conf.MaxHeaderBytes > 0 {
+		opts = append(opts, func(server *http.Server) {
+			server.MaxHeaderBytes = conf.MaxHeaderBytes
+		})
+	}
+
+	return opts
+}
+
+func toDuration(ms int64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
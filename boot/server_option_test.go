@@ -0,0 +1,103 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToServerOptions(t *testing.T) {
+	// with empty config
+	assert.Empty(t, ToServerOptions(&BootConfigServerOption{}))
+
+	// with all fields
+	conf := &BootConfigServerOption{
+		MaxConcurrentStreams:       100,
+		MaxRecvMsgSizeBytes:        1024,
+		MaxSendMsgSizeBytes:        1024,
+		InitialWindowSizeBytes:     65536,
+		InitialConnWindowSizeBytes: 65536,
+	}
+	conf.Keepalive.TimeMs = 1000
+	conf.Keepalive.MaxConnectionAgeMs = 1000
+	conf.KeepaliveEnforcement.PermitWithoutStream = true
+
+	assert.Len(t, ToServerOptions(conf), 7)
+}
+
+func TestToGwDialOptions(t *testing.T) {
+	// with empty config
+	assert.Empty(t, ToGwDialOptions(&BootConfigServerOption{}))
+
+	// with message sizes
+	assert.Len(t, ToGwDialOptions(&BootConfigServerOption{
+		MaxRecvMsgSizeBytes: 1024,
+		MaxSendMsgSizeBytes: 1024,
+	}), 1)
+}
+
+func TestToHttpServerOptions(t *testing.T) {
+	// with empty config
+	assert.Empty(t, ToHttpServerOptions(&BootConfigHttpServer{}))
+
+	// with all fields
+	opts := ToHttpServerOptions(&BootConfigHttpServer{
+		ReadTimeoutMs:       1000,
+		ReadHeaderTimeoutMs: 2000,
+		WriteTimeoutMs:      3000,
+		IdleTimeoutMs:       4000,
+		MaxHeaderBytes:      1024,
+	})
+	assert.Len(t, opts, 5)
+
+	server := &http.Server{}
+	for i := range opts {
+		opts[i](server)
+	}
+	assert.Equal(t, time.Second, server.ReadTimeout)
+	assert.Equal(t, 2*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 3*time.Second, server.WriteTimeout)
+	assert.Equal(t, 4*time.Second, server.IdleTimeout)
+	assert.Equal(t, 1024, server.MaxHeaderBytes)
+}
+
+func TestRegisterGrpcEntryYAML_WithServerOption(t *testing.T) {
+	configFile := `
+---
+grpc:
+  - name: ut-grpc-server-option
+    port: 1965
+    enabled: true
+    serverOption:
+      keepalive:
+        timeMs: 10000
+        timeoutMs: 1000
+        maxConnectionIdleMs: 60000
+        maxConnectionAgeMs: 60000
+        maxConnectionAgeGraceMs: 5000
+      keepaliveEnforcement:
+        minTimeMs: 5000
+        permitWithoutStream: true
+      maxConcurrentStreams: 100
+      maxRecvMsgSizeBytes: 8388608
+      maxSendMsgSizeBytes: 8388608
+    httpServer:
+      readTimeoutMs: 5000
+      maxHeaderBytes: 4096
+`
+	entries := RegisterGrpcEntryYAML([]byte(configFile))
+	entry := entries["ut-grpc-server-option"].(*GrpcEntry)
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	assert.Len(t, entry.ServerOpts, 5)
+	assert.Len(t, entry.HttpServerOpts, 2)
+	assert.Len(t, entry.GwDialOptions, 1)
+}
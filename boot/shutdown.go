@@ -0,0 +1,172 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rookie-ninja/rk-query"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/stats"
+)
+
+// BootConfigShutdown Boot config which is for graceful shutdown of grpc entry.
+//
+// PreStopDelayMs: wait before stopping servers, so that load balancers are able to observe NOT_SERVING status.
+// DrainTimeoutMs: max duration of waiting in-flight RPCs and http requests, remaining ones are cut once exceeded.
+// Zero means wait until all RPCs finished.
+type BootConfigShutdown struct {
+	PreStopDelayMs int64 `yaml:"preStopDelayMs" json:"preStopDelayMs"`
+	DrainTimeoutMs int64 `yaml:"drainTimeoutMs" json:"drainTimeoutMs"`
+}
+
+// inFlightHandler counts in-flight RPCs of grpc server, including RPCs delegated from grpc-gateway, and in-flight
+// http requests which are not delegated to grpc server.
+type inFlightHandler struct {
+	count int64
+	http  int64
+}
+
+// TagRPC implements stats.Handler.
+func (h *inFlightHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+// HandleRPC implements stats.Handler.
+func (h *inFlightHandler) HandleRPC(_ context.Context, s stats.RPCStats) {
+	switch s.(type) {
+	case *stats.Begin:
+		atomic.AddInt64(&h.count, 1)
+	case *stats.End:
+		atomic.AddInt64(&h.count, -1)
+	}
+}
+
+// TagConn implements stats.Handler.
+func (h *inFlightHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn implements stats.Handler.
+func (h *inFlightHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+// Wrap http mux to count in-flight http requests, requests of grpc-gateway on path / are skipped since they are
+// counted as RPCs.
+func (h *inFlightHandler) wrapMux(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if _, pattern := mux.Handler(req); pattern != "/" {
+			atomic.AddInt64(&h.http, 1)
+			defer atomic.AddInt64(&h.http, -1)
+		}
+
+		mux.ServeHTTP(writer, req)
+	})
+}
+
+// Count of in-flight RPCs and http requests.
+func (h *inFlightHandler) get() int64 {
+	if h == nil {
+		return 0
+	}
+
+	return atomic.LoadInt64(&h.count) + atomic.LoadInt64(&h.http)
+}
+
+// Mark entry as not ready and wait for pre-stop delay.
+func (entry *GrpcEntry) preStop(ctx context.Context, event rkquery.Event, logger *zap.Logger) {
+	// Mark all services as NOT_SERVING, so that balancers stop sending new requests while draining, it is done with
+	// or without common service
+	if entry.HealthServer != nil {
+		entry.HealthServer.Shutdown()
+		logger.Info("Marked entry as not ready")
+	}
+
+	if entry.PreStopDelay > 0 {
+		logger.Info("Waiting for pre-stop delay", zap.Duration("preStopDelay", entry.PreStopDelay))
+		event.StartTimer("preStop")
+		select {
+		case <-time.After(entry.PreStopDelay):
+		case <-ctx.Done():
+		}
+		event.EndTimer("preStop")
+	}
+}
+
+// Stop gateway and grpc server gracefully and concurrently within drain timeout, both of them are stopped forcefully
+// once exceeded and remaining RPCs and http requests are counted as streamsCut, exceeded timeout is counted as
+// drainTimeoutExceeded instead of error of event.
+func (entry *GrpcEntry) drain(event rkquery.Event, logger *zap.Logger) {
+	drainCtx, cancel := context.Background(), context.CancelFunc(func() {})
+	if entry.DrainTimeout > 0 {
+		drainCtx, cancel = context.WithTimeout(drainCtx, entry.DrainTimeout)
+	}
+	defer cancel()
+
+	// Servers close their listeners while stopping, and cmux listeners close the root listener as well,
+	// serving errors are expected from now on
+	atomic.StoreInt32(&entry.lisClosed, 1)
+
+	logger.Info("Draining in-flight RPCs",
+		zap.Int64("inFlight", entry.inFlight.get()),
+		zap.Duration("drainTimeout", entry.DrainTimeout))
+	event.StartTimer("drain")
+	defer event.EndTimer("drain")
+
+	wg := sync.WaitGroup{}
+	var httpErr error
+	if entry.HttpServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// http server is closed after drain timeout below, so that remaining requests are counted before
+			if httpErr = entry.HttpServer.Shutdown(drainCtx); httpErr != nil && drainCtx.Err() == nil {
+				entry.HttpServer.Close()
+			}
+		}()
+	}
+
+	if entry.Server != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry.Server.GracefulStop()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var streamsCut int64
+	select {
+	case <-done:
+	case <-drainCtx.Done():
+		streamsCut = entry.inFlight.get()
+		event.SetCounter("drainTimeoutExceeded", 1)
+		logger.Warn("Drain timeout exceeded, stopping servers forcefully", zap.Int64("streamsCut", streamsCut))
+		if entry.HttpServer != nil {
+			entry.HttpServer.Close()
+		}
+		if entry.Server != nil {
+			entry.Server.Stop()
+		}
+		<-done
+	}
+
+	if httpErr != nil && !errors.Is(httpErr, context.DeadlineExceeded) {
+		event.AddErr(httpErr)
+		logger.Warn("Error occurs while stopping http server", zap.Error(httpErr))
+	}
+
+	event.SetCounter("streamsCut", streamsCut)
+}
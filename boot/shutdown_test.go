@@ -0,0 +1,193 @@
+//go:build !race
+// +build !race
+
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+package rkgrpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	testdata "github.com/rookie-ninja/rk-grpc/v2/example/middleware/proto/testdata"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/stats"
+)
+
+func TestInFlightHandler(t *testing.T) {
+	var nilHandler *inFlightHandler
+	assert.Zero(t, nilHandler.get())
+
+	handler := &inFlightHandler{}
+	ctx := handler.TagRPC(context.TODO(), &stats.RPCTagInfo{})
+	ctx = handler.TagConn(ctx, &stats.ConnTagInfo{})
+	handler.HandleConn(ctx, &stats.ConnBegin{})
+
+	handler.HandleRPC(ctx, &stats.Begin{})
+	handler.HandleRPC(ctx, &stats.Begin{})
+	handler.HandleRPC(ctx, &stats.InPayload{})
+	assert.Equal(t, int64(2), handler.get())
+
+	handler.HandleRPC(ctx, &stats.End{})
+	assert.Equal(t, int64(1), handler.get())
+
+	// http requests except the ones of grpc-gateway
+	inHandler := make(chan int64, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(http.ResponseWriter, *http.Request) { inHandler <- handler.get() })
+	mux.HandleFunc("/rk/v1/ready", func(http.ResponseWriter, *http.Request) { inHandler <- handler.get() })
+	wrapped := handler.wrapMux(mux)
+
+	wrapped.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/rk/v1/ready", nil))
+	assert.Equal(t, int64(2), <-inHandler)
+	wrapped.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/hello", nil))
+	assert.Equal(t, int64(1), <-inHandler)
+	assert.Equal(t, int64(1), handler.get())
+}
+
+func TestRegisterGrpcEntryYAML_WithShutdown(t *testing.T) {
+	configFile := `
+---
+grpc:
+  - name: ut-grpc-shutdown
+    port: 1966
+    enabled: true
+    shutdown:
+      preStopDelayMs: 100
+      drainTimeoutMs: 200
+`
+	entries := RegisterGrpcEntryYAML([]byte(configFile))
+	entry := entries["ut-grpc-shutdown"].(*GrpcEntry)
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	assert.Equal(t, 100*time.Millisecond, entry.PreStopDelay)
+	assert.Equal(t, 200*time.Millisecond, entry.DrainTimeout)
+}
+
+func TestGrpcEntry_InterruptWithDrainTimeout(t *testing.T) {
+	defer assertNotPanic(t)
+
+	entry := RegisterGrpcEntry(
+		WithName("ut-drain"),
+		WithPort(1967),
+		WithCommonServiceEntry(rkentry.RegisterCommonServiceEntry(&rkentry.BootCommonService{Enabled: true})),
+		WithShutdown(300*time.Millisecond, 300*time.Millisecond))
+	entry.AddRegFuncGrpc(func(server *grpc.Server) {
+		testdata.RegisterGreeterServer(server, &BlockingGreeterServer{})
+	})
+	entry.HttpMux.HandleFunc("/ut-block", func(writer http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	})
+
+	entry.Bootstrap(context.TODO())
+	time.Sleep(time.Second)
+
+	conn, err := grpc.Dial("localhost:1967", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	// start a call which never finishes
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{})
+		errCh <- err
+	}()
+
+	// start a http request which never finishes
+	httpErrCh := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://localhost:1967/ut-block")
+		if err == nil {
+			resp.Body.Close()
+		}
+		httpErrCh <- err
+	}()
+
+	assert.Eventually(t, func() bool {
+		return entry.inFlight.get() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		entry.Interrupt(context.TODO())
+		close(done)
+	}()
+
+	// entry is marked as not ready while waiting for pre-stop delay
+	assert.Eventually(t, func() bool {
+		return entry.GetServingStatus("") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, 200*time.Millisecond, 10*time.Millisecond)
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "interrupt was not bounded by drain timeout")
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 600*time.Millisecond)
+
+	// blocked call was cut
+	select {
+	case err := <-errCh:
+		assert.NotNil(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "blocked call was not cut")
+	}
+	select {
+	case err := <-httpErrCh:
+		assert.NotNil(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "blocked http request was not cut")
+	}
+}
+
+func TestGrpcEntry_DrainWithTimeout(t *testing.T) {
+	lis, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	entry := &GrpcEntry{
+		DrainTimeout: 100 * time.Millisecond,
+		HttpServer: &http.Server{
+			Handler: http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				<-req.Context().Done()
+			}),
+		},
+	}
+	go entry.HttpServer.Serve(lis)
+
+	// start a http request which never finishes
+	go func() {
+		if resp, err := http.Get("http://" + lis.Addr().String()); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// exceeded timeout is not recorded as error
+	event := rkentry.GlobalAppCtx.GetEventEntryDefault().Start("ut-drain")
+	entry.drain(event, zap.NewNop())
+	assert.Equal(t, int64(1), event.GetCounter("drainTimeoutExceeded"))
+	assert.Equal(t, int64(0), event.GetErrCount(context.DeadlineExceeded))
+}
+
+// ************ Test utility ************
+
+// BlockingGreeterServer blocks until context is done.
+type BlockingGreeterServer struct{}
+
+// SayHello Handle SayHello method.
+func (server *BlockingGreeterServer) SayHello(ctx context.Context, request *testdata.HelloRequest) (*testdata.HelloResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
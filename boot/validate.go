@@ -0,0 +1,320 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v3"
+)
+
+// EntryLookup returns entry with type and name, nil if missing.
+type EntryLookup func(entryType, entryName string) rkentry.Entry
+
+// ValidateBootConfig Validate enabled grpc entries in boot config, all problems are combined with multierr.
+//
+// Disabled entries are skipped, referenced logger, event and cert entries must be registered in rkentry.GlobalAppCtx.
+func ValidateBootConfig(config *BootConfig) error {
+	return ValidateBootConfigWithLookup(config, rkentry.GlobalAppCtx.GetEntry)
+}
+
+// ValidateBootConfigWithLookup Validate enabled grpc entries in boot config, referenced entries are looked up
+// with lookup. CA for mtls is verified only if lookup returns *rkentry.CertEntry.
+func ValidateBootConfigWithLookup(config *BootConfig, lookup EntryLookup) error {
+	var res error
+	names := make(map[string]bool)
+
+	for i := range config.Grpc {
+		element := &config.Grpc[i]
+		if !element.Enabled {
+			continue
+		}
+
+		// wrap errors with name of entry
+		fail := func(format string, args ...interface{}) {
+			res = multierr.Append(res, fmt.Errorf("grpc[%d] %s: %s", i, element.Name, fmt.Sprintf(format, args...)))
+		}
+
+		// name, port 0 is assigned while listening which could not be used as name
+		if len(element.Name) < 1 {
+			if element.Port == 0 {
+				fail("name is required with port 0")
+			} else {
+				fail("name is missing")
+			}
+		} else if names[element.Name] {
+			fail("duplicate entry name")
+		}
+		names[element.Name] = true
+
+		// ports
+		if element.Port > 65535 {
+			fail("port %d is out of range", element.Port)
+		}
+		if element.GwPort > 65535 {
+			fail("gwPort %d is out of range", element.GwPort)
+		}
+
+		// referenced entries
+		if len(element.LoggerEntry) > 0 && lookup(rkentry.LoggerEntryType, element.LoggerEntry) == nil {
+			fail("logger entry %s not found", element.LoggerEntry)
+		}
+		if len(element.EventEntry) > 0 && lookup(rkentry.EventEntryType, element.EventEntry) == nil {
+			fail("event entry %s not found", element.EventEntry)
+		}
+		if len(element.CertEntry) > 0 && lookup(rkentry.CertEntryType, element.CertEntry) == nil {
+			fail("cert entry %s not found", element.CertEntry)
+		}
+
+		// mutual TLS, CA is read from cert entry of mtls or cert entry of server
+		if element.Mtls.Enabled {
+			caEntryName := element.Mtls.CertEntry
+			if len(caEntryName) < 1 {
+				caEntryName = element.CertEntry
+			}
+
+			caEntry := lookup(rkentry.CertEntryType, caEntryName)
+			if len(element.Mtls.CertEntry) > 0 && caEntry == nil {
+				fail("cert entry %s of mtls not found", element.Mtls.CertEntry)
+			} else if certEntry, ok := caEntry.(*rkentry.CertEntry); ok || caEntry == nil {
+				if _, _, err := toMtlsOption(&element.Mtls, certEntry); err != nil {
+					fail("%v", err)
+				}
+			} else if _, err := parseClientAuth(element.Mtls.ClientAuth); err != nil {
+				fail("%v", err)
+			}
+		}
+
+		// config reloading
+		if element.Reload.Enabled && len(element.Reload.Path) < 1 {
+			fail("reload.path is missing")
+		}
+
+		// listeners
+		if _, err := ToListenConfig(&element.Listen.Grpc); err != nil {
+			fail("listen.grpc: %v", err)
+		}
+		if _, err := ToListenConfig(&element.Listen.Gateway); err != nil {
+			fail("listen.gateway: %v", err)
+		}
+
+		// proxy rules
+		if element.Proxy.Enabled {
+			if element.Proxy.Pool.MaxConns < 0 {
+				fail("proxy.pool.maxConns must not be negative")
+			}
+			if element.Proxy.Pool.IdleTimeoutMs < 0 {
+				fail("proxy.pool.idleTimeoutMs must not be negative")
+			}
+			if element.Proxy.HealthCheck.Enabled {
+				switch element.Proxy.HealthCheck.Type {
+				case "", HealthCheckGrpc, HealthCheckTcp:
+				default:
+					fail("invalid proxy.healthCheck.type %s, expect one of grpc and tcp", element.Proxy.HealthCheck.Type)
+				}
+				if element.Proxy.HealthCheck.IntervalMs < 0 {
+					fail("proxy.healthCheck.intervalMs must not be negative")
+				}
+				if element.Proxy.HealthCheck.TimeoutMs < 0 {
+					fail("proxy.healthCheck.timeoutMs must not be negative")
+				}
+			}
+			if element.Proxy.Outlier.Enabled {
+				if element.Proxy.Outlier.ConsecutiveErrors < 0 {
+					fail("proxy.outlier.consecutiveErrors must not be negative")
+				}
+				if element.Proxy.Outlier.EjectionMs < 0 {
+					fail("proxy.outlier.ejectionMs must not be negative")
+				}
+			}
+			for j := range element.Proxy.Destinations {
+				dest := &element.Proxy.Destinations[j]
+				if len(dest.Dest) < 1 {
+					fail("proxy.destinations[%d]: dest is missing", j)
+				}
+				for _, err := range multierr.Errors(validateProxyDial(&dest.Dial, lookup)) {
+					fail("proxy.destinations[%d]: %v", j, err)
+				}
+			}
+			defaultRoutes := 0
+			for j := range element.Proxy.Routes {
+				route := &element.Proxy.Routes[j]
+				if route.Default {
+					if defaultRoutes++; defaultRoutes > 1 {
+						fail("proxy.routes[%d]: duplicate default route", j)
+					}
+				}
+				for _, err := range multierr.Errors(validateProxyRoute(route)) {
+					fail("proxy.routes[%d]: %v", j, err)
+				}
+				for _, err := range multierr.Errors(validateProxyDial(&route.Dial, lookup)) {
+					fail("proxy.routes[%d]: %v", j, err)
+				}
+			}
+			for j := range element.Proxy.Rules {
+				for _, err := range multierr.Errors(validateProxyRule(&element.Proxy, j)) {
+					fail("proxy.rules[%d]: %v", j, err)
+				}
+				for _, err := range multierr.Errors(validateProxyDial(&element.Proxy.Rules[j].Dial, lookup)) {
+					fail("proxy.rules[%d]: %v", j, err)
+				}
+			}
+		}
+
+		// order of interceptors
+		if _, err := resolveInterceptorOrder(element.Middleware.Order); err != nil {
+			for _, err := range multierr.Errors(err) {
+				fail("%v", err)
+			}
+		}
+
+		// method policies
+		for j := range element.Middleware.Policies {
+			if err := element.Middleware.Policies[j].validate(); err != nil {
+				fail("middleware.policies[%d]: %v", j, err)
+			}
+		}
+
+		// error model
+		switch strings.ToLower(element.Middleware.ErrorModel) {
+		case "", "google", "amazon":
+		default:
+			fail("invalid middleware.errorModel %s, expect one of google and amazon", element.Middleware.ErrorModel)
+		}
+	}
+
+	return res
+}
+
+// Validate proxy rule at index i.
+func validateProxyRule(config *BootConfigProxy, i int) error {
+	rule := config.Rules[i]
+	var res error
+
+	if len(rule.Dest) < 1 {
+		res = multierr.Append(res, errors.New("dest is missing"))
+	}
+
+	for _, dest := range rule.Dest {
+		if _, _, err := parseProxyDest(dest); err != nil {
+			res = multierr.Append(res, err)
+		}
+	}
+
+	if _, err := NewBalancer(rule.Balancer, rule.HashKey); err != nil {
+		res = multierr.Append(res, err)
+	}
+
+	switch rule.Type {
+	case HeaderBased:
+		for _, pair := range rule.HeaderPairs {
+			tokens := strings.SplitN(pair, ":", 2)
+			if len(tokens) != 2 || len(strings.TrimSpace(tokens[0])) < 1 {
+				res = multierr.Append(res, fmt.Errorf("malformed headerPairs %s, expect key:value", pair))
+			}
+		}
+	case PathBased:
+		if len(rule.Paths) < 1 {
+			res = multierr.Append(res, fmt.Errorf("paths is missing for type %s", PathBased))
+		}
+	case IpBased:
+		if len(rule.Ips) < 1 {
+			res = multierr.Append(res, fmt.Errorf("ips is missing for type %s", IpBased))
+		}
+		for _, cidr := range rule.Ips {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				res = multierr.Append(res, fmt.Errorf("invalid CIDR %s in ips", cidr))
+			}
+		}
+	default:
+		res = multierr.Append(res, fmt.Errorf("unknown type %s, expect one of %s, %s and %s",
+			rule.Type, HeaderBased, PathBased, IpBased))
+	}
+
+	return res
+}
+
+// Validate composable proxy route, regex and CIDRs are compiled.
+func validateProxyRoute(config *BootConfigProxyRoute) error {
+	var res error
+
+	if len(config.Dest) < 1 {
+		res = multierr.Append(res, errors.New("dest is missing"))
+	}
+
+	for _, dest := range config.Dest {
+		if _, _, err := parseProxyDest(dest); err != nil {
+			res = multierr.Append(res, err)
+		}
+	}
+
+	if _, err := NewBalancer(config.Balancer, config.HashKey); err != nil {
+		res = multierr.Append(res, err)
+	}
+
+	_, err := compileRoute(&Route{
+		Default: config.Default,
+		Methods: config.Methods,
+		Headers: config.Headers,
+		Cidrs:   config.Ips,
+	})
+
+	return multierr.Append(res, err)
+}
+
+// Validate dial options of proxy destinations, cert entry is looked up with lookup.
+func validateProxyDial(config *BootConfigProxyDial, lookup EntryLookup) error {
+	var res error
+
+	if len(config.CertEntry) > 0 && lookup(rkentry.CertEntryType, config.CertEntry) == nil {
+		res = multierr.Append(res, fmt.Errorf("cert entry %s of dial not found", config.CertEntry))
+	}
+
+	if len(config.ServerName) > 0 && len(config.CertEntry) < 1 {
+		res = multierr.Append(res, errors.New("dial.serverName requires dial.certEntry"))
+	}
+
+	if config.Keepalive.TimeMs < 0 || config.Keepalive.TimeoutMs < 0 {
+		res = multierr.Append(res, errors.New("dial.keepalive must not be negative"))
+	}
+
+	if config.MaxRecvMsgSize < 0 || config.MaxSendMsgSize < 0 {
+		res = multierr.Append(res, errors.New("dial.maxRecvMsgSize and dial.maxSendMsgSize must not be negative"))
+	}
+
+	return res
+}
+
+// Decode config map into boot config struct, errors are returned instead of shutting down process.
+// Middlewares of method policies without enabled are enabled.
+func unmarshalBootYAML(raw []byte, config interface{}) (err error) {
+	// report syntax errors before rkentry.UnmarshalBootYAML which shuts down process
+	if err := yaml.Unmarshal(raw, &map[string]interface{}{}); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	rkentry.UnmarshalBootYAML(raw, config)
+	if boot, ok := config.(*BootConfig); ok {
+		enableMethodPolicies(raw, boot)
+	}
+
+	return nil
+}
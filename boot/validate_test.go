@@ -0,0 +1,215 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpc
+
+import (
+	"testing"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/multierr"
+)
+
+func TestValidateBootConfig(t *testing.T) {
+	// with valid config
+	config := &BootConfig{}
+	assert.Nil(t, unmarshalBootYAML([]byte(`
+---
+grpc:
+  - name: ut-valid
+    enabled: true
+    proxy:
+      enabled: true
+      routes:
+        - name: billing-canary
+          priority: 10
+          methods: ["/billing.*"]
+          headers:
+            - name: env
+              exact: canary
+            - name: x-user
+              present: true
+          ips: ["10.0.0.0/8"]
+          dest: ["localhost:8083"]
+        - default: true
+          dest: ["localhost:8084"]
+      rules:
+        - type: headerBased
+          headerPairs: ["key:value"]
+          dest: ["localhost:8081"]
+        - type: ipBased
+          ips: ["10.0.0.0/8"]
+          balancer: weighted
+          dest: ["localhost:8081;weight=3", "localhost:8082"]
+  - name: ut-valid
+    enabled: false
+`), config))
+	assert.Nil(t, ValidateBootConfig(config))
+
+	// with every problem aggregated
+	config = &BootConfig{}
+	assert.Nil(t, unmarshalBootYAML([]byte(`
+---
+grpc:
+  - name: ut-invalid
+    enabled: true
+    loggerEntry: not-exist
+    listen:
+      grpc:
+        network: udp
+    reload:
+      enabled: true
+    middleware:
+      errorModel: unknown
+      order: ["not-exist", "auth", "Auth"]
+      policies:
+        - auth:
+            enabled: false
+        - methods: ["/api.v1.Admin/["]
+    proxy:
+      enabled: true
+      pool:
+        maxConns: -1
+      healthCheck:
+        enabled: true
+        type: http
+      outlier:
+        enabled: true
+        consecutiveErrors: -1
+      rules:
+        - type: unknown
+          dest: ["localhost:8081"]
+        - type: headerBased
+          headerPairs: ["malformed"]
+          dest: ["localhost:8081"]
+        - type: ipBased
+          ips: ["10.0.0.0/33"]
+        - type: pathBased
+          balancer: consistentHash
+          dest: ["localhost:8081;weight=0"]
+          dial:
+            serverName: "backend.internal"
+      destinations:
+        - dial:
+            certEntry: not-exist
+            maxRecvMsgSize: -1
+      routes:
+        - name: billing-canary
+          methods: ["/billing.*", "["]
+          headers:
+            - name: env
+              exact: canary
+              prefix: can
+          dest: ["localhost:8081"]
+        - default: true
+          ips: ["10.0.0.0/8"]
+        - default: true
+          dest: ["localhost:8082"]
+  - name: ut-invalid
+    enabled: true
+    port: 70000
+    gwPort: 70001
+  - enabled: true
+`), config))
+
+	err := ValidateBootConfig(config)
+	assert.NotNil(t, err)
+	errs := multierr.Errors(err)
+	assert.Len(t, errs, 31)
+	assert.Contains(t, err.Error(), "logger entry not-exist not found")
+	assert.Contains(t, err.Error(), "reload.path is missing")
+	assert.Contains(t, err.Error(), "listen.grpc: invalid network of listener")
+	assert.Contains(t, err.Error(), "invalid middleware.errorModel unknown")
+	assert.Contains(t, err.Error(), "middleware.policies[0]: methods is missing")
+	assert.Contains(t, err.Error(), "middleware.policies[1]: invalid method pattern /api.v1.Admin/[")
+	assert.Contains(t, err.Error(), "proxy.pool.maxConns must not be negative")
+	assert.Contains(t, err.Error(), "invalid proxy.healthCheck.type http, expect one of grpc and tcp")
+	assert.Contains(t, err.Error(), "proxy.outlier.consecutiveErrors must not be negative")
+	assert.Contains(t, err.Error(), "proxy.rules[0]: unknown type unknown")
+	assert.Contains(t, err.Error(), "proxy.rules[1]: malformed headerPairs malformed")
+	assert.Contains(t, err.Error(), "proxy.rules[2]: dest is missing")
+	assert.Contains(t, err.Error(), "proxy.rules[2]: invalid CIDR 10.0.0.0/33")
+	assert.Contains(t, err.Error(), "proxy.rules[3]: invalid weight 0 in dest localhost:8081;weight=0")
+	assert.Contains(t, err.Error(), "proxy.rules[3]: hashKey is missing for consistentHash balancer")
+	assert.Contains(t, err.Error(), "proxy.rules[3]: dial.serverName requires dial.certEntry")
+	assert.Contains(t, err.Error(), "proxy.rules[3]: paths is missing for type pathBased")
+	assert.Contains(t, err.Error(), "proxy.destinations[0]: dest is missing")
+	assert.Contains(t, err.Error(), "proxy.routes[0]: invalid method regex [")
+	assert.Contains(t, err.Error(), "proxy.routes[0]: header matcher env expect one of exact, prefix, regex and present")
+	assert.Contains(t, err.Error(), "proxy.routes[1]: dest is missing")
+	assert.Contains(t, err.Error(), "proxy.routes[1]: default route must not have methods, headers or ips")
+	assert.Contains(t, err.Error(), "proxy.routes[2]: duplicate default route")
+	assert.Contains(t, err.Error(), "proxy.destinations[0]: cert entry not-exist of dial not found")
+	assert.Contains(t, err.Error(), "proxy.destinations[0]: dial.maxRecvMsgSize and dial.maxSendMsgSize must not be negative")
+	assert.Contains(t, err.Error(), "unknown interceptor not-exist in middleware.order")
+	assert.Contains(t, err.Error(), "duplicate interceptor auth in middleware.order")
+	assert.Contains(t, err.Error(), "grpc[1] ut-invalid: duplicate entry name")
+	assert.Contains(t, err.Error(), "grpc[1] ut-invalid: port 70000 is out of range")
+	assert.Contains(t, err.Error(), "grpc[1] ut-invalid: gwPort 70001 is out of range")
+	assert.Contains(t, err.Error(), "grpc[2] : name is required with port 0")
+}
+
+func TestNewGrpcEntriesFromYAML(t *testing.T) {
+	// with malformed YAML
+	entries, err := NewGrpcEntriesFromYAML([]byte("grpc: ["))
+	assert.NotNil(t, err)
+	assert.Nil(t, entries)
+
+	// with invalid config, nothing is registered
+	entries, err = NewGrpcEntriesFromYAML([]byte(`
+---
+grpc:
+  - name: ut-new-entries
+    enabled: true
+    mtls:
+      enabled: true
+`))
+	assert.NotNil(t, err)
+	assert.Nil(t, entries)
+	assert.Nil(t, GetGrpcEntry("ut-new-entries"))
+
+	// happy case
+	entries, err = NewGrpcEntriesFromYAML([]byte(`
+---
+grpc:
+  - name: ut-new-entries
+    port: 1971
+    enabled: true
+`))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, uint64(1971), entries["ut-new-entries"].Port)
+	assert.Equal(t, entries["ut-new-entries"], GetGrpcEntry("ut-new-entries"))
+	rkentry.GlobalAppCtx.RemoveEntry(entries["ut-new-entries"])
+}
+
+func TestRegisterGrpcEntries_WithError(t *testing.T) {
+	config := &BootConfig{}
+	assert.Nil(t, unmarshalBootYAML([]byte(`
+---
+grpc:
+  - name: ut-register-valid
+    port: 1971
+    enabled: true
+    sw:
+      enabled: true
+  - name: ut-register-invalid
+    enabled: true
+    sw:
+      enabled: true
+    listen:
+      grpc:
+        network: udp
+`), config))
+
+	// nothing is registered if any of entries failed
+	entries, err := registerGrpcEntries(config)
+	assert.NotNil(t, err)
+	assert.Nil(t, entries)
+	assert.Nil(t, GetGrpcEntry("ut-register-valid"))
+	assert.Nil(t, rkentry.GlobalAppCtx.GetEntry(rkentry.SWEntryType, "ut-register-valid"))
+	assert.Nil(t, rkentry.GlobalAppCtx.GetEntry(rkentry.SWEntryType, "ut-register-invalid"))
+}
@@ -0,0 +1,444 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-grpc/v2/boot"
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v2"
+)
+
+// Issue is a problem found in boot config.
+type Issue struct {
+	// Path of value, like grpc[0].proxy.rules[1].ips, empty if problem is not bound to a value
+	Path    string
+	Message string
+}
+
+// String prints issue as path: message.
+func (i Issue) String() string {
+	if len(i.Path) < 1 {
+		return i.Message
+	}
+
+	return i.Path + ": " + i.Message
+}
+
+type linter struct {
+	issues []Issue
+}
+
+// Lint boot config with --rkset overrides, overrides from RK_ prefixed environment variables are applied as well.
+//
+// Unknown keys and wrong types are reported first, semantic problems like references to missing cert, logger and
+// event entries are reported only if config is well typed. Lint is safe for concurrent use.
+func Lint(raw []byte, rkset []string) []Issue {
+	l := &linter{}
+	schema := rkgrpc.BootConfigSchema()
+
+	// 1: decode raw YAML with the same library as rkentry.UnmarshalBootYAML, values like yes are booleans
+	decoded := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(raw, &decoded); err != nil {
+		return []Issue{{Message: err.Error()}}
+	}
+	doc := normalize(decoded, nil).(map[string]interface{})
+
+	// 2: apply overrides
+	overrides, err := parseOverrides(rkset)
+	if err != nil {
+		return []Issue{{Message: fmt.Sprintf("invalid --rkset, %v", err)}}
+	}
+	mergeOverrides(doc, overrides, schema)
+
+	// 3: check keys and types of grpc section
+	grpcKey, grpcSchema := schema.Property("grpc")
+	for _, k := range sortedKeys(doc) {
+		if strings.EqualFold(k, grpcKey) {
+			if k != grpcKey {
+				l.add(k, "unknown key, did you mean %s", grpcKey)
+			}
+			l.walk(doc[k], grpcSchema, grpcKey)
+		}
+	}
+
+	if len(l.issues) > 0 {
+		return l.issues
+	}
+
+	// 4: validate semantics
+	normalized, err := yaml.Marshal(doc)
+	if err != nil {
+		return []Issue{{Message: err.Error()}}
+	}
+
+	config := &rkgrpc.BootConfig{}
+	if err := yaml.Unmarshal(normalized, config); err != nil {
+		return []Issue{{Message: err.Error()}}
+	}
+
+	for _, err := range multierr.Errors(rkgrpc.ValidateBootConfigWithLookup(config, declaredEntries(doc))) {
+		l.add("", "%v", err)
+	}
+
+	return l.issues
+}
+
+func (l *linter) add(path, format string, args ...interface{}) {
+	l.issues = append(l.issues, Issue{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Check keys and types of value against schema.
+func (l *linter) walk(v interface{}, schema *rkgrpc.Schema, path string) {
+	if v == nil || schema == nil {
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			l.add(path, "expect object, got %s", typeName(v))
+			return
+		}
+
+		for _, k := range sortedKeys(m) {
+			name, child := schema.Property(k)
+			switch {
+			case child == nil:
+				l.add(join(path, k), "unknown key")
+				continue
+			case name != k:
+				l.add(join(path, k), "unknown key, did you mean %s", name)
+			}
+			l.walk(m[k], child, join(path, name))
+		}
+	case "array":
+		s, ok := v.([]interface{})
+		if !ok {
+			l.add(path, "expect array, got %s", typeName(v))
+			return
+		}
+
+		for i := range s {
+			l.walk(s[i], schema.Items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			l.add(path, "expect string, got %s", typeName(v))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			l.add(path, "expect boolean, got %s", typeName(v))
+		}
+	case "integer":
+		if typeName(v) != "integer" {
+			l.add(path, "expect integer, got %s", typeName(v))
+		}
+	case "number":
+		if name := typeName(v); name != "integer" && name != "number" {
+			l.add(path, "expect number, got %s", name)
+		}
+	}
+}
+
+// Name of JSON Schema type of value.
+func typeName(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "integer"
+	case float32:
+		return typeName(float64(val))
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}, map[interface{}]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func join(path, key string) string {
+	if len(path) < 1 {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// Parse --rkset values and RK_ prefixed environment variables in the same way as rkentry.UnmarshalBootYAML, values
+// of flags override environment variables.
+//
+// Malformed environment variables are ignored like rkentry does, malformed --rkset values are returned as error.
+func parseOverrides(rkset []string) (map[string]interface{}, error) {
+	res := make(map[interface{}]interface{})
+
+	env, _ := parseBootOverrides(envOverrides("RK"))
+	overrideMap(res, env)
+
+	flags, err := parseBootOverrides(strings.Join(rkset, ","))
+	if err != nil {
+		return nil, err
+	}
+	overrideMap(res, flags)
+
+	return stringKeys(res), nil
+}
+
+// Convert environment variables with prefix into a set line, RK_GRPC_0_PORT=8081 is converted to grpc[0].port=8081.
+func envOverrides(prefix string) string {
+	res := make([]string, 0)
+	for _, val := range os.Environ() {
+		if !strings.HasPrefix(val, prefix+"_") {
+			continue
+		}
+
+		tokens := strings.SplitN(val, "=", 2)
+		if len(tokens) != 2 {
+			continue
+		}
+
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(tokens[0], prefix+"_"), "_", "."))
+		res = append(res, reformatEnvKey(key)+"="+tokens[1])
+	}
+
+	return strings.Join(res, ",")
+}
+
+// Convert numbers in key into array index, grpc.0.port is converted to grpc[0].port.
+func reformatEnvKey(key string) string {
+	list := make([]string, 0)
+	for _, token := range strings.Split(key, ".") {
+		index, err := strconv.Atoi(token)
+		if err != nil {
+			list = append(list, token)
+			continue
+		}
+
+		if len(list) > 0 {
+			list[len(list)-1] = fmt.Sprintf("%s[%d]", list[len(list)-1], index)
+		}
+	}
+
+	return strings.Join(list, ".")
+}
+
+// Override src with items in override recursively, items with different types are replaced.
+func overrideMap(src, override map[interface{}]interface{}) {
+	for k, overrideItem := range override {
+		originalItem, ok := src[k]
+		if !ok || reflect.TypeOf(originalItem) != reflect.TypeOf(overrideItem) {
+			src[k] = overrideItem
+			continue
+		}
+
+		switch val := overrideItem.(type) {
+		case []interface{}:
+			overrideSlice(originalItem.([]interface{}), val)
+		case map[interface{}]interface{}:
+			overrideMap(originalItem.(map[interface{}]interface{}), val)
+		default:
+			src[k] = overrideItem
+		}
+	}
+}
+
+// Override items of src with items in override recursively, items with different types are skipped.
+func overrideSlice(src, override []interface{}) {
+	for i := range override {
+		if override[i] == nil || i >= len(src) || reflect.TypeOf(override[i]) != reflect.TypeOf(src[i]) {
+			continue
+		}
+
+		switch val := override[i].(type) {
+		case []interface{}:
+			overrideSlice(src[i].([]interface{}), val)
+		case map[interface{}]interface{}:
+			overrideMap(src[i].(map[interface{}]interface{}), val)
+		default:
+			src[i] = override[i]
+		}
+	}
+}
+
+// Merge overrides into doc in the same way as rkentry.UnmarshalBootYAML.
+//
+// Keys of overrides are lower cased, they are replaced with existing keys or names in schema under case folding.
+func mergeOverrides(doc map[string]interface{}, overrides map[string]interface{}, schema *rkgrpc.Schema) {
+	for k, override := range overrides {
+		key := k
+		if name, child := schema.Property(k); child != nil {
+			key = name
+		}
+		for existing := range doc {
+			if strings.EqualFold(existing, k) {
+				key = existing
+			}
+		}
+		_, child := schema.Property(key)
+
+		switch val := override.(type) {
+		case map[interface{}]interface{}:
+			m, ok := doc[key].(map[string]interface{})
+			if !ok {
+				m = make(map[string]interface{})
+				doc[key] = m
+			}
+			mergeOverrides(m, stringKeys(val), child)
+		case []interface{}:
+			s, ok := doc[key].([]interface{})
+			if !ok {
+				doc[key] = normalize(val, child)
+				continue
+			}
+
+			// slice items are overridden only if types are the same
+			for i := range val {
+				if i >= len(s) || val[i] == nil {
+					continue
+				}
+
+				var items *rkgrpc.Schema
+				if child != nil {
+					items = child.Items
+				}
+
+				if om, ok := val[i].(map[interface{}]interface{}); ok {
+					if m, ok := s[i].(map[string]interface{}); ok {
+						mergeOverrides(m, stringKeys(om), items)
+					}
+				} else if fmt.Sprintf("%T", val[i]) == fmt.Sprintf("%T", s[i]) {
+					s[i] = val[i]
+				}
+			}
+		default:
+			doc[key] = val
+		}
+	}
+}
+
+// Convert maps into ones with string keys, keys are replaced with names in schema.
+func normalize(v interface{}, schema *rkgrpc.Schema) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{})
+		mergeOverrides(res, stringKeys(val), schema)
+		return res
+	case []interface{}:
+		var items *rkgrpc.Schema
+		if schema != nil {
+			items = schema.Items
+		}
+
+		res := make([]interface{}, len(val))
+		for i := range val {
+			res[i] = normalize(val[i], items)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+func stringKeys(m map[interface{}]interface{}) map[string]interface{} {
+	res := make(map[string]interface{})
+	for k, v := range m {
+		res[fmt.Sprint(k)] = v
+	}
+
+	return res
+}
+
+// Lookup entries declared in logger, event and cert sections of boot config.
+func declaredEntries(doc map[string]interface{}) rkgrpc.EntryLookup {
+	sections := map[string]string{
+		"logger": rkentry.LoggerEntryType,
+		"event":  rkentry.EventEntryType,
+		"cert":   rkentry.CertEntryType,
+	}
+
+	declared := make(map[string]bool)
+	for k, v := range doc {
+		entryType, ok := sections[strings.ToLower(k)]
+		if !ok {
+			continue
+		}
+
+		elements, _ := v.([]interface{})
+		for i := range elements {
+			if element, ok := elements[i].(map[string]interface{}); ok {
+				if name, ok := element["name"].(string); ok {
+					declared[entryType+"/"+name] = true
+				}
+			}
+		}
+	}
+
+	return func(entryType, entryName string) rkentry.Entry {
+		if declared[entryType+"/"+entryName] {
+			return &declaredEntry{entryName: entryName, entryType: entryType}
+		}
+
+		return nil
+	}
+}
+
+// declaredEntry is an entry declared in boot config, which is not registered.
+type declaredEntry struct {
+	entryName string
+	entryType string
+}
+
+func (e *declaredEntry) Bootstrap(context.Context) {}
+
+func (e *declaredEntry) Interrupt(context.Context) {}
+
+func (e *declaredEntry) GetName() string {
+	return e.entryName
+}
+
+func (e *declaredEntry) GetType() string {
+	return e.entryType
+}
+
+func (e *declaredEntry) GetDescription() string {
+	return "Entry declared in boot config."
+}
+
+func (e *declaredEntry) String() string {
+	return e.entryType + "/" + e.entryName
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint_WithValidConfig(t *testing.T) {
+	raw := `
+---
+logger:
+  - name: my-logger
+event:
+  - name: my-event
+cert:
+  - name: my-cert
+gin:
+  - name: other-entry
+grpc:
+  - name: greeter
+    port: 8080
+    enabled: true
+    loggerEntry: my-logger
+    eventEntry: my-event
+    certEntry: my-cert
+    mtls:
+      enabled: true
+    proxy:
+      enabled: true
+      rules:
+        - type: ipBased
+          ips: ["10.0.0.0/8"]
+          dest: ["localhost:8081"]
+    gwOption:
+      marshal:
+        multiline: true
+`
+	assert.Empty(t, Lint([]byte(raw), nil))
+}
+
+func TestLint_WithUnknownKeysAndWrongTypes(t *testing.T) {
+	raw := `
+---
+grpc:
+  - name: greeter
+    port: "8080"
+    enabled: yes
+    enableReflection: "true"
+    portt: 8080
+    proxy:
+      enabled: true
+      rules:
+        - type: ipBased
+          Ips: ["10.0.0.0/8"]
+          dest: localhost:8081
+`
+	assert.Equal(t, []Issue{
+		{Path: "grpc[0].enableReflection", Message: "expect boolean, got string"},
+		{Path: "grpc[0].port", Message: "expect integer, got string"},
+		{Path: "grpc[0].portt", Message: "unknown key"},
+		{Path: "grpc[0].proxy.rules[0].Ips", Message: "unknown key, did you mean ips"},
+		{Path: "grpc[0].proxy.rules[0].dest", Message: "expect array, got string"},
+	}, Lint([]byte(raw), nil))
+}
+
+func TestLint_WithMissingEntries(t *testing.T) {
+	raw := `
+---
+grpc:
+  - name: greeter
+    enabled: true
+    loggerEntry: my-logger
+    eventEntry: my-event
+    certEntry: my-cert
+  - name: greeter
+    enabled: true
+`
+	assert.Equal(t, []Issue{
+		{Message: "grpc[0] greeter: logger entry my-logger not found"},
+		{Message: "grpc[0] greeter: event entry my-event not found"},
+		{Message: "grpc[0] greeter: cert entry my-cert not found"},
+		{Message: "grpc[1] greeter: duplicate entry name"},
+	}, Lint([]byte(raw), nil))
+}
+
+func TestLint_WithOverrides(t *testing.T) {
+	raw := `
+---
+grpc:
+  - name: greeter
+    port: 8080
+    enabled: true
+    proxy:
+      enabled: true
+      rules:
+        - type: ipBased
+          ips: ["10.0.0.0/8"]
+          dest: ["localhost:8081"]
+`
+	// override existing value
+	assert.Equal(t, []Issue{
+		{Message: "grpc[0] greeter: proxy.rules[0]: unknown type unknown, expect one of headerBased, pathBased and ipBased"},
+	}, Lint([]byte(raw), []string{"grpc[0].proxy.rules[0].type=unknown"}))
+
+	// override missing key with lower cased name
+	assert.Equal(t, []Issue{
+		{Path: "grpc[0].enableReflection", Message: "expect boolean, got string"},
+	}, Lint([]byte(raw), []string{"grpc[0].enablereflection=yes"}))
+
+	// override unknown key
+	assert.Equal(t, []Issue{
+		{Path: "grpc[0].portt", Message: "unknown key"},
+	}, Lint([]byte(raw), []string{"grpc[0].portt=8081"}))
+
+	// override with environment variable, flags take precedence
+	t.Setenv("RK_GRPC_0_PROXY_RULES_0_TYPE", "unknown")
+	assert.Len(t, Lint([]byte(raw), nil), 1)
+	assert.Empty(t, Lint([]byte(raw), []string{"grpc[0].proxy.rules[0].type=ipBased"}))
+
+	// malformed override
+	assert.Equal(t, []Issue{
+		{Message: `invalid --rkset, key "port" has no value`},
+	}, Lint([]byte(raw), []string{"grpc[0].port"}))
+}
+
+func TestLint_Concurrently(t *testing.T) {
+	raw := `
+---
+grpc:
+  - name: greeter
+    port: 8080
+    enabled: true
+`
+	args := append([]string{}, os.Args...)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.Empty(t, Lint([]byte(raw), []string{"grpc[0].port=8081"}))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Len(t, Lint([]byte(raw), []string{"grpc[0].portt=8081"}), 1)
+		}()
+	}
+	wg.Wait()
+
+	// process-global state is not touched
+	assert.Equal(t, args, os.Args)
+}
+
+func TestLint_WithMalformedYAML(t *testing.T) {
+	issues := Lint([]byte("grpc: ["), nil)
+	assert.Len(t, issues, 1)
+	assert.Empty(t, issues[0].Path)
+}
+
+func TestRun(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	// print schema
+	assert.Equal(t, 0, run([]string{"--schema"}, stdout, stderr))
+	assert.True(t, json.Valid(stdout.Bytes()))
+
+	// with missing file
+	assert.Equal(t, 1, run([]string{filepath.Join(t.TempDir(), "boot.yaml")}, stdout, stderr))
+
+	// with issues
+	path := filepath.Join(t.TempDir(), "boot.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte("grpc:\n  - name: greeter\n    portt: 8080\n"), 0644))
+	stdout.Reset()
+	assert.Equal(t, 1, run([]string{path}, stdout, stderr))
+	assert.Equal(t, path+": grpc[0].portt: unknown key\n", stdout.String())
+
+	// with overrides
+	assert.Nil(t, os.WriteFile(path, []byte("grpc:\n  - name: greeter\n    port: 8080\n"), 0644))
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"--rkset", "grpc[0].port=8081", path}, stdout, stderr))
+	assert.Empty(t, stdout.String())
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+// Command rkgrpc-lint validates grpc section of boot.yaml.
+//
+// Usage:
+//
+//	rkgrpc-lint [--rkset key1=val1,key2=val2] [boot.yaml]
+//	rkgrpc-lint --schema
+//
+// Unknown keys, wrong types, invalid values and references to cert, logger and event entries which are not declared
+// in the same file are reported, exit code is 1 if any problems found. --rkset overrides and RK_ prefixed environment
+// variables are applied before validation, in the same way as rkentry.UnmarshalBootYAML.
+//
+// --schema prints JSON Schema of grpc section which is generated from rkgrpc.BootConfig.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rookie-ninja/rk-grpc/v2/boot"
+)
+
+// stringsFlag collects values of flag which is able to be repeated.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Run command with args and return exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	var rkset stringsFlag
+	flags := flag.NewFlagSet("rkgrpc-lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Var(&rkset, "rkset", "override values in boot config, like key1=val1,key2=val2, could be repeated")
+	printSchema := flags.Bool("schema", false, "print JSON Schema of grpc section in boot config")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *printSchema {
+		schema, err := rkgrpc.MarshalBootConfigSchema()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		stdout.Write(schema)
+		return 0
+	}
+
+	path := "boot.yaml"
+	if flags.NArg() > 0 {
+		path = flags.Arg(0)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	issues := Lint(raw, rkset)
+	for i := range issues {
+		fmt.Fprintf(stdout, "%s: %s\n", path, issues[i])
+	}
+
+	if len(issues) > 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcbreaker
+
+import (
+	"context"
+
+	"github.com/rookie-ninja/rk-grpc/v2/boot/error"
+	"google.golang.org/grpc"
+)
+
+// UnaryClientInterceptor Create new unary client interceptor.
+//
+// Calls fail fast with codes.Unavailable while breaker of target and method is open.
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	set := NewOptionSet(opts...)
+
+	return func(ctx context.Context, method string, req, resp interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if set.ShouldIgnore(method) {
+			return invoker(ctx, method, req, resp, cc, opts...)
+		}
+
+		target := getTarget(cc)
+		if !set.Allow(target, method) {
+			return rkgrpcerr.Unavailable("circuit breaker is open").Err()
+		}
+
+		err := invoker(ctx, method, req, resp, cc, opts...)
+		set.Report(target, method, err)
+
+		return err
+	}
+}
+
+// StreamClientInterceptor Create new stream client interceptor.
+//
+// Only result of stream creation is reported to breaker, since stream may live as long as connection.
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	set := NewOptionSet(opts...)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if set.ShouldIgnore(method) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		target := getTarget(cc)
+		if !set.Allow(target, method) {
+			return nil, rkgrpcerr.Unavailable("circuit breaker is open").Err()
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		set.Report(target, method, err)
+
+		return stream, err
+	}
+}
+
+// Get target of connection, empty string returned if connection is nil.
+func getTarget(cc *grpc.ClientConn) string {
+	if cc == nil {
+		return ""
+	}
+
+	return cc.Target()
+}
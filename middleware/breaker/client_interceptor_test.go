@@ -0,0 +1,70 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcbreaker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	inter := UnaryClientInterceptor(
+		WithConsecutiveFailures(2),
+		WithPathToIgnore("/ut.ignore"))
+
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return errUnavailable
+	}
+
+	// trip after 2 failures
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, errUnavailable, inter(context.TODO(), "/ut.service/method", nil, nil, nil, invoker))
+	}
+
+	// fail fast
+	err := inter(context.TODO(), "/ut.service/method", nil, nil, nil, invoker)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 2, calls)
+
+	// ignored method
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, errUnavailable, inter(context.TODO(), "/ut.ignore/method", nil, nil, nil, invoker))
+	}
+	assert.Equal(t, 5, calls)
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	inter := StreamClientInterceptor(
+		WithConsecutiveFailures(1),
+		WithPathToIgnore("/ut.ignore"))
+
+	calls := 0
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		calls++
+		return nil, errUnavailable
+	}
+
+	// trip after 1 failure
+	_, err := inter(context.TODO(), &grpc.StreamDesc{}, nil, "/ut.service/method", streamer)
+	assert.Equal(t, errUnavailable, err)
+
+	// fail fast
+	_, err = inter(context.TODO(), &grpc.StreamDesc{}, nil, "/ut.service/method", streamer)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, calls)
+
+	// ignored method
+	_, err = inter(context.TODO(), &grpc.StreamDesc{}, nil, "/ut.ignore/method", streamer)
+	assert.Equal(t, errUnavailable, err)
+	assert.Equal(t, 2, calls)
+}
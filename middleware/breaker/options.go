@@ -0,0 +1,462 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+// Package rkgrpcbreaker is a middleware which fails fast on outgoing calls while downstream is unhealthy
+package rkgrpcbreaker
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// StateClosed calls are allowed
+	StateClosed State = 0
+	// StateHalfOpen limited number of probes are allowed
+	StateHalfOpen State = 1
+	// StateOpen calls are rejected
+	StateOpen State = 2
+
+	defaultConsecutiveFailures = 5
+	defaultMinRequests         = 10
+	defaultWindow              = 10 * time.Second
+	defaultOpenTimeout         = 5 * time.Second
+	defaultHalfOpenProbes      = 1
+)
+
+var (
+	labelKeys = []string{"entryName", "entryType", "target", "grpcService", "grpcMethod"}
+)
+
+// State of circuit breaker
+type State int
+
+// String returns readable state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// ***************** OptionSet Interface *****************
+
+// OptionSetInterface which is used by interceptors
+type OptionSetInterface interface {
+	GetEntryName() string
+
+	GetEntryType() string
+
+	ShouldIgnore(method string) bool
+
+	// Allow checks whether call to target and method could be sent,
+	// Report must be called with result of call if allowed.
+	Allow(target, method string) bool
+
+	// Report result of call to target and method
+	Report(target, method string, err error)
+
+	// GetState returns current state of breaker of target and method
+	GetState(target, method string) State
+}
+
+// ***************** OptionSet Implementation *****************
+
+// optionSet which is used for middleware implementation
+type optionSet struct {
+	entryName           string
+	entryType           string
+	pathToIgnore        []string
+	consecutiveFailures int
+	errorRate           float64
+	minRequests         int
+	window              time.Duration
+	openTimeout         time.Duration
+	halfOpenProbes      int
+	codes               []codes.Code
+	registerer          prometheus.Registerer
+	gauge               *prometheus.GaugeVec
+	breakers            map[breakerKey]*breaker
+	lock                sync.Mutex
+	now                 func() time.Time
+}
+
+// NewOptionSet Create new optionSet with options.
+func NewOptionSet(opts ...Option) OptionSetInterface {
+	set := &optionSet{
+		entryName:           "fake-entry",
+		entryType:           "",
+		pathToIgnore:        make([]string, 0),
+		consecutiveFailures: defaultConsecutiveFailures,
+		minRequests:         defaultMinRequests,
+		window:              defaultWindow,
+		openTimeout:         defaultOpenTimeout,
+		halfOpenProbes:      defaultHalfOpenProbes,
+		codes:               []codes.Code{codes.Unavailable, codes.DeadlineExceeded},
+		breakers:            make(map[breakerKey]*breaker),
+		now:                 time.Now,
+	}
+
+	for i := range opts {
+		opts[i](set)
+	}
+
+	if set.registerer != nil {
+		set.gauge = registerGauge(set.registerer)
+	}
+
+	return set
+}
+
+// GetEntryName returns entry name
+func (set *optionSet) GetEntryName() string {
+	return set.entryName
+}
+
+// GetEntryType returns entry type
+func (set *optionSet) GetEntryType() string {
+	return set.entryType
+}
+
+// ShouldIgnore determine whether breaker should be ignored based on method
+func (set *optionSet) ShouldIgnore(method string) bool {
+	for i := range set.pathToIgnore {
+		if strings.HasPrefix(method, set.pathToIgnore[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Allow checks whether call to target and method could be sent
+func (set *optionSet) Allow(target, method string) bool {
+	set.lock.Lock()
+	defer set.lock.Unlock()
+
+	b := set.getBreaker(target, method)
+	now := set.now()
+
+	switch b.state {
+	case StateOpen:
+		if now.Before(b.openedAt.Add(set.openTimeout)) {
+			return false
+		}
+		set.transit(b, StateHalfOpen, now)
+		fallthrough
+	case StateHalfOpen:
+		if b.probes >= set.halfOpenProbes {
+			return false
+		}
+		b.probes++
+	}
+
+	return true
+}
+
+// Report result of call to target and method
+func (set *optionSet) Report(target, method string, err error) {
+	set.lock.Lock()
+	defer set.lock.Unlock()
+
+	b := set.getBreaker(target, method)
+	now := set.now()
+	failed := set.isFailure(err)
+
+	switch b.state {
+	case StateHalfOpen:
+		if b.probes > 0 {
+			b.probes--
+		}
+
+		if failed {
+			set.transit(b, StateOpen, now)
+			return
+		}
+
+		b.successes++
+		if b.successes >= set.halfOpenProbes {
+			set.transit(b, StateClosed, now)
+		}
+	case StateClosed:
+		// tumbling window
+		if now.Sub(b.windowStart) > set.window {
+			b.windowStart = now
+			b.total, b.failures = 0, 0
+		}
+
+		b.total++
+		if failed {
+			b.failures++
+			b.consecutive++
+		} else {
+			b.consecutive = 0
+		}
+
+		if set.shouldTrip(b) {
+			set.transit(b, StateOpen, now)
+		}
+	}
+}
+
+// GetState returns current state of breaker of target and method
+func (set *optionSet) GetState(target, method string) State {
+	set.lock.Lock()
+	defer set.lock.Unlock()
+
+	return set.getBreaker(target, method).state
+}
+
+// Check whether error counts as failure.
+func (set *optionSet) isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	code := status.Code(err)
+	for i := range set.codes {
+		if set.codes[i] == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check whether closed breaker should be opened.
+func (set *optionSet) shouldTrip(b *breaker) bool {
+	if set.consecutiveFailures > 0 && b.consecutive >= set.consecutiveFailures {
+		return true
+	}
+
+	if set.errorRate > 0 && b.total >= set.minRequests && float64(b.failures)/float64(b.total) >= set.errorRate {
+		return true
+	}
+
+	return false
+}
+
+// breakerKey identifies breaker with target and method, so that target ending with method prefix won't collide.
+type breakerKey struct {
+	target string
+	method string
+}
+
+// Get or create breaker, lock should be held by caller.
+func (set *optionSet) getBreaker(target, method string) *breaker {
+	key := breakerKey{target: target, method: method}
+	b, ok := set.breakers[key]
+	if !ok {
+		b = &breaker{
+			target:      target,
+			method:      method,
+			state:       StateClosed,
+			windowStart: set.now(),
+		}
+		set.breakers[key] = b
+		set.observe(b)
+	}
+
+	return b
+}
+
+// Change state of breaker, lock should be held by caller.
+func (set *optionSet) transit(b *breaker, state State, now time.Time) {
+	b.state = state
+	b.probes, b.successes = 0, 0
+
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.windowStart = now
+		b.total, b.failures, b.consecutive = 0, 0, 0
+	}
+
+	set.observe(b)
+}
+
+// Export state of breaker into gauge.
+func (set *optionSet) observe(b *breaker) {
+	if set.gauge == nil {
+		return
+	}
+
+	grpcService, grpcMethod := rkgrpcmid.GetGrpcInfo(b.method)
+	set.gauge.WithLabelValues(set.entryName, set.entryType, b.target, grpcService, grpcMethod).Set(float64(b.state))
+}
+
+// Register gauge into registerer, existing one would be reused.
+func registerGauge(registerer prometheus.Registerer) *prometheus.GaugeVec {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rk_grpc_client_breaker_state",
+		Help: "state of circuit breaker of outgoing grpc calls, 0: closed, 1: half-open, 2: open",
+	}, labelKeys)
+
+	if err := registerer.Register(gauge); err != nil {
+		if existing, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if res, ok := existing.ExistingCollector.(*prometheus.GaugeVec); ok {
+				return res
+			}
+		}
+		return nil
+	}
+
+	return gauge
+}
+
+// breaker of single target and method
+type breaker struct {
+	target      string
+	method      string
+	state       State
+	openedAt    time.Time
+	windowStart time.Time
+	total       int
+	failures    int
+	consecutive int
+	probes      int
+	successes   int
+}
+
+// ***************** BootConfig *****************
+
+// BootConfig for YAML
+type BootConfig struct {
+	Enabled             bool     `yaml:"enabled" json:"enabled"`
+	Ignore              []string `yaml:"ignore" json:"ignore"`
+	ConsecutiveFailures int      `yaml:"consecutiveFailures" json:"consecutiveFailures"`
+	ErrorRate           float64  `yaml:"errorRate" json:"errorRate"`
+	MinRequests         int      `yaml:"minRequests" json:"minRequests"`
+	WindowMs            int64    `yaml:"windowMs" json:"windowMs"`
+	OpenTimeoutMs       int64    `yaml:"openTimeoutMs" json:"openTimeoutMs"`
+	HalfOpenProbes      int      `yaml:"halfOpenProbes" json:"halfOpenProbes"`
+	Codes               []string `yaml:"codes" json:"codes"`
+}
+
+// ToOptions convert BootConfig into Option list
+func ToOptions(config *BootConfig, entryName, entryType string, registerer prometheus.Registerer) []Option {
+	opts := make([]Option, 0)
+
+	if config.Enabled {
+		opts = append(opts,
+			WithEntryNameAndType(entryName, entryType),
+			WithPathToIgnore(config.Ignore...),
+			WithConsecutiveFailures(config.ConsecutiveFailures),
+			WithErrorRate(config.ErrorRate, config.MinRequests, time.Duration(config.WindowMs)*time.Millisecond),
+			WithOpenTimeout(time.Duration(config.OpenTimeoutMs)*time.Millisecond),
+			WithHalfOpenProbes(config.HalfOpenProbes),
+			WithRegisterer(registerer))
+
+		if len(config.Codes) > 0 {
+			failureCodes := make([]codes.Code, 0)
+			for i := range config.Codes {
+				if code, ok := rkgrpcmid.ParseCode(config.Codes[i]); ok {
+					failureCodes = append(failureCodes, code)
+				}
+			}
+			opts = append(opts, WithCodes(failureCodes...))
+		}
+	}
+
+	return opts
+}
+
+// ***************** Option *****************
+
+// Option if for middleware options while creating middleware
+type Option func(*optionSet)
+
+// WithEntryNameAndType provide entry name and entry type.
+func WithEntryNameAndType(entryName, entryType string) Option {
+	return func(opt *optionSet) {
+		opt.entryName = entryName
+		opt.entryType = entryType
+	}
+}
+
+// WithPathToIgnore provide paths prefix that will ignore.
+func WithPathToIgnore(paths ...string) Option {
+	return func(opt *optionSet) {
+		for i := range paths {
+			if len(paths[i]) > 0 {
+				opt.pathToIgnore = append(opt.pathToIgnore, paths[i])
+			}
+		}
+	}
+}
+
+// WithConsecutiveFailures provide number of consecutive failures which trips breaker, default is 5.
+func WithConsecutiveFailures(num int) Option {
+	return func(opt *optionSet) {
+		if num > 0 {
+			opt.consecutiveFailures = num
+		}
+	}
+}
+
+// WithErrorRate provide error rate in range of (0, 1] which trips breaker once there are at least
+// minRequests calls in window. Error rate is disabled by default.
+func WithErrorRate(rate float64, minRequests int, window time.Duration) Option {
+	return func(opt *optionSet) {
+		if rate > 0 && rate <= 1 {
+			opt.errorRate = rate
+		}
+
+		if minRequests > 0 {
+			opt.minRequests = minRequests
+		}
+
+		if window > 0 {
+			opt.window = window
+		}
+	}
+}
+
+// WithOpenTimeout provide duration breaker stays open before probes are allowed, default is 5 seconds.
+func WithOpenTimeout(timeout time.Duration) Option {
+	return func(opt *optionSet) {
+		if timeout > 0 {
+			opt.openTimeout = timeout
+		}
+	}
+}
+
+// WithHalfOpenProbes provide number of successful probes which closes breaker, default is 1.
+func WithHalfOpenProbes(num int) Option {
+	return func(opt *optionSet) {
+		if num > 0 {
+			opt.halfOpenProbes = num
+		}
+	}
+}
+
+// WithCodes provide codes which count as failure, default is Unavailable and DeadlineExceeded.
+func WithCodes(failureCodes ...codes.Code) Option {
+	return func(opt *optionSet) {
+		if len(failureCodes) > 0 {
+			opt.codes = failureCodes
+		}
+	}
+}
+
+// WithRegisterer provide prometheus.Registerer, state of breakers will be registered as gauge if provided.
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return func(opt *optionSet) {
+		opt.registerer = registerer
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errUnavailable = status.Error(codes.Unavailable, "ut-error")
+	errInvalid     = status.Error(codes.InvalidArgument, "ut-error")
+)
+
+func TestNewOptionSet(t *testing.T) {
+	// without options
+	set := NewOptionSet().(*optionSet)
+	assert.Equal(t, "fake-entry", set.GetEntryName())
+	assert.Empty(t, set.GetEntryType())
+	assert.Equal(t, defaultConsecutiveFailures, set.consecutiveFailures)
+	assert.Zero(t, set.errorRate)
+	assert.Equal(t, defaultOpenTimeout, set.openTimeout)
+	assert.Equal(t, defaultHalfOpenProbes, set.halfOpenProbes)
+	assert.Equal(t, []codes.Code{codes.Unavailable, codes.DeadlineExceeded}, set.codes)
+	assert.Nil(t, set.gauge)
+
+	// with options
+	set = NewOptionSet(
+		WithEntryNameAndType("ut-entry", "ut-type"),
+		WithPathToIgnore("/ut.ignore", ""),
+		WithConsecutiveFailures(3),
+		WithErrorRate(0.5, 4, time.Minute),
+		WithOpenTimeout(time.Second),
+		WithHalfOpenProbes(2),
+		WithCodes(codes.Internal),
+		WithRegisterer(prometheus.NewRegistry())).(*optionSet)
+
+	assert.Equal(t, "ut-entry", set.GetEntryName())
+	assert.Equal(t, "ut-type", set.GetEntryType())
+	assert.True(t, set.ShouldIgnore("/ut.ignore/method"))
+	assert.False(t, set.ShouldIgnore("/ut.service/method"))
+	assert.Equal(t, 3, set.consecutiveFailures)
+	assert.Equal(t, 0.5, set.errorRate)
+	assert.Equal(t, 4, set.minRequests)
+	assert.Equal(t, time.Minute, set.window)
+	assert.Equal(t, time.Second, set.openTimeout)
+	assert.Equal(t, 2, set.halfOpenProbes)
+	assert.Equal(t, []codes.Code{codes.Internal}, set.codes)
+	assert.NotNil(t, set.gauge)
+}
+
+func TestOptionSet_ConsecutiveFailures(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	set, clock := newTestSet(
+		WithEntryNameAndType("ut-entry", "ut-type"),
+		WithConsecutiveFailures(3),
+		WithOpenTimeout(time.Second),
+		WithRegisterer(reg))
+
+	// success resets consecutive failures
+	report(set, errUnavailable, errUnavailable, nil, errUnavailable, errUnavailable)
+	assert.Equal(t, StateClosed, set.GetState("ut-target", "/ut.service/method"))
+
+	// non-failure codes are not counted
+	report(set, errInvalid, errors.New("ut-error"))
+	assert.Equal(t, StateClosed, set.GetState("ut-target", "/ut.service/method"))
+
+	// trip
+	report(set, errUnavailable, errUnavailable, errUnavailable)
+	assert.Equal(t, StateOpen, set.GetState("ut-target", "/ut.service/method"))
+	assert.False(t, set.Allow("ut-target", "/ut.service/method"))
+	assert.Equal(t, float64(StateOpen), testutil.ToFloat64(registerGauge(reg).WithLabelValues(
+		"ut-entry", "ut-type", "ut-target", "ut.service", "method")))
+
+	// other target and method are not affected
+	assert.True(t, set.Allow("ut-target", "/ut.service/other"))
+	assert.True(t, set.Allow("ut-other", "/ut.service/method"))
+	assert.True(t, set.Allow("ut-target/ut.service", "/method"))
+
+	// half-open after timeout, only one probe allowed
+	*clock = clock.Add(time.Second)
+	assert.True(t, set.Allow("ut-target", "/ut.service/method"))
+	assert.Equal(t, StateHalfOpen, set.GetState("ut-target", "/ut.service/method"))
+	assert.False(t, set.Allow("ut-target", "/ut.service/method"))
+
+	// failed probe opens breaker again
+	set.Report("ut-target", "/ut.service/method", errUnavailable)
+	assert.Equal(t, StateOpen, set.GetState("ut-target", "/ut.service/method"))
+	assert.False(t, set.Allow("ut-target", "/ut.service/method"))
+
+	// successful probe closes breaker
+	*clock = clock.Add(time.Second)
+	assert.True(t, set.Allow("ut-target", "/ut.service/method"))
+	set.Report("ut-target", "/ut.service/method", nil)
+	assert.Equal(t, StateClosed, set.GetState("ut-target", "/ut.service/method"))
+	assert.Equal(t, float64(StateClosed), testutil.ToFloat64(registerGauge(reg).WithLabelValues(
+		"ut-entry", "ut-type", "ut-target", "ut.service", "method")))
+}
+
+func TestOptionSet_ErrorRate(t *testing.T) {
+	set, clock := newTestSet(
+		WithConsecutiveFailures(100),
+		WithErrorRate(0.5, 4, time.Second))
+
+	// not enough requests
+	report(set, errUnavailable, nil, errUnavailable)
+	assert.Equal(t, StateClosed, set.GetState("ut-target", "/ut.service/method"))
+
+	// window expired, counters reset
+	*clock = clock.Add(2 * time.Second)
+	report(set, nil, nil, errUnavailable)
+	assert.Equal(t, StateClosed, set.GetState("ut-target", "/ut.service/method"))
+
+	// 2 of 4 failed
+	report(set, errUnavailable)
+	assert.Equal(t, StateOpen, set.GetState("ut-target", "/ut.service/method"))
+}
+
+func TestOptionSet_HalfOpenProbes(t *testing.T) {
+	set, clock := newTestSet(
+		WithConsecutiveFailures(1),
+		WithHalfOpenProbes(2))
+
+	report(set, errUnavailable)
+	assert.Equal(t, StateOpen, set.GetState("ut-target", "/ut.service/method"))
+
+	*clock = clock.Add(defaultOpenTimeout)
+	assert.True(t, set.Allow("ut-target", "/ut.service/method"))
+	assert.True(t, set.Allow("ut-target", "/ut.service/method"))
+	assert.False(t, set.Allow("ut-target", "/ut.service/method"))
+
+	set.Report("ut-target", "/ut.service/method", nil)
+	assert.Equal(t, StateHalfOpen, set.GetState("ut-target", "/ut.service/method"))
+	set.Report("ut-target", "/ut.service/method", nil)
+	assert.Equal(t, StateClosed, set.GetState("ut-target", "/ut.service/method"))
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "unknown", State(-1).String())
+}
+
+func TestToOptions(t *testing.T) {
+	config := &BootConfig{
+		Enabled: false,
+	}
+
+	// with disabled
+	assert.Empty(t, ToOptions(config, "", "", nil))
+
+	// with enabled
+	config = &BootConfig{
+		Enabled:             true,
+		Ignore:              []string{"/ut.ignore"},
+		ConsecutiveFailures: 2,
+		ErrorRate:           0.3,
+		MinRequests:         20,
+		WindowMs:            1000,
+		OpenTimeoutMs:       2000,
+		HalfOpenProbes:      3,
+		Codes:               []string{"internal", "invalid"},
+	}
+
+	set := NewOptionSet(ToOptions(config, "ut-entry", "ut-type", prometheus.NewRegistry())...).(*optionSet)
+	assert.Equal(t, "ut-entry", set.GetEntryName())
+	assert.True(t, set.ShouldIgnore("/ut.ignore/method"))
+	assert.Equal(t, 2, set.consecutiveFailures)
+	assert.Equal(t, 0.3, set.errorRate)
+	assert.Equal(t, 20, set.minRequests)
+	assert.Equal(t, time.Second, set.window)
+	assert.Equal(t, 2*time.Second, set.openTimeout)
+	assert.Equal(t, 3, set.halfOpenProbes)
+	assert.Equal(t, []codes.Code{codes.Internal}, set.codes)
+	assert.NotNil(t, set.gauge)
+}
+
+// ************ Test utility ************
+
+func newTestSet(opts ...Option) (*optionSet, *time.Time) {
+	clock := time.Now()
+	set := NewOptionSet(opts...).(*optionSet)
+	set.now = func() time.Time {
+		return clock
+	}
+
+	return set, &clock
+}
+
+func report(set *optionSet, errs ...error) {
+	for i := range errs {
+		if set.Allow("ut-target", "/ut.service/method") {
+			set.Report("ut-target", "/ut.service/method", errs[i])
+		}
+	}
+}
@@ -8,12 +8,14 @@ package rkgrpcmid
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"path"
 	"strings"
 
 	rkmid "github.com/rookie-ninja/rk-entry/v2/middleware"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 )
@@ -24,8 +26,13 @@ var (
 	LocalHostname = zap.String("localHostname", rkmid.LocalHostname.String)
 
 	serverPayloadKey = &serverPayload{}
+
+	// PeerIdentityKey is key of verified peer identity in server context payload
+	PeerIdentityKey = peerIdentityKey{}
 )
 
+type peerIdentityKey struct{}
+
 // RpcPayloadAppended a flag used in inner middleware
 var RpcPayloadAppended = rpcPayloadAppended{}
 
@@ -168,6 +175,47 @@ func MergeToOutgoingMD(ctx context.Context, md metadata.MD) context.Context {
 	return ctx
 }
 
+// MatchMethod Match grpc full method with pattern.
+//
+// Supported patterns:
+// 1: "*" matches any method
+// 2: "/pkg.Service/Method" matches exact method
+// 3: "/pkg.Service/*" or "pkg.Service" matches any method of service
+// 4: Any other glob pattern supported by path.Match
+func MatchMethod(pattern, fullMethod string) bool {
+	if pattern == "*" || pattern == fullMethod {
+		return true
+	}
+
+	// service name without slash
+	if !strings.Contains(pattern, "/") {
+		grpcService, _ := GetGrpcInfo(fullMethod)
+		return pattern == grpcService
+	}
+
+	matched, _ := path.Match(pattern, fullMethod)
+	return matched
+}
+
+// ParseCode Parse grpc status code from string, case-insensitive.
+//
+// Both style of "Unavailable" and "UNAVAILABLE" and "DEADLINE_EXCEEDED" are supported.
+func ParseCode(str string) (codes.Code, bool) {
+	normalized := strings.ToLower(strings.ReplaceAll(str, "_", ""))
+	// CANCELLED is used in grpc spec
+	if normalized == "cancelled" {
+		return codes.Canceled, true
+	}
+
+	for i := codes.OK; i <= codes.Unauthenticated; i++ {
+		if strings.ToLower(i.String()) == normalized {
+			return i, true
+		}
+	}
+
+	return codes.Unknown, false
+}
+
 // MergeAndDeduplicateSlice Merge src and targets and deduplicate
 func MergeAndDeduplicateSlice(src []string, target []string) []string {
 	m := make(map[string]bool)
@@ -184,6 +232,54 @@ func MergeAndDeduplicateSlice(src []string, target []string) []string {
 	return src
 }
 
+// PeerIdentity is identity of peer verified with client certificate.
+type PeerIdentity struct {
+	// Subject of leaf certificate in RFC 2253 format
+	Subject string `json:"subject" yaml:"subject"`
+	// CommonName of subject
+	CommonName string `json:"commonName" yaml:"commonName"`
+	// DNSNames in SANs
+	DNSNames []string `json:"dnsNames" yaml:"dnsNames"`
+	// EmailAddresses in SANs
+	EmailAddresses []string `json:"emailAddresses" yaml:"emailAddresses"`
+	// IPAddresses in SANs
+	IPAddresses []string `json:"ipAddresses" yaml:"ipAddresses"`
+	// URIs in SANs
+	URIs []string `json:"uris" yaml:"uris"`
+	// SpiffeId is the first URI SAN with spiffe scheme
+	SpiffeId string `json:"spiffeId" yaml:"spiffeId"`
+}
+
+// NewPeerIdentity Create PeerIdentity from TLS connection state, nil will be returned if peer certificate was not verified.
+func NewPeerIdentity(state tls.ConnectionState) *PeerIdentity {
+	if len(state.VerifiedChains) < 1 || len(state.VerifiedChains[0]) < 1 {
+		return nil
+	}
+
+	cert := state.VerifiedChains[0][0]
+	res := &PeerIdentity{
+		Subject:        cert.Subject.String(),
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		IPAddresses:    make([]string, 0),
+		URIs:           make([]string, 0),
+	}
+
+	for i := range cert.IPAddresses {
+		res.IPAddresses = append(res.IPAddresses, cert.IPAddresses[i].String())
+	}
+
+	for i := range cert.URIs {
+		res.URIs = append(res.URIs, cert.URIs[i].String())
+		if len(res.SpiffeId) < 1 && strings.EqualFold(cert.URIs[i].Scheme, "spiffe") {
+			res.SpiffeId = cert.URIs[i].String()
+		}
+	}
+
+	return res
+}
+
 // ***************************************************
 // ********** Internal usage for context *************
 // ***************************************************
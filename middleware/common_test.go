@@ -7,9 +7,15 @@ package rkgrpcmid
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 )
@@ -124,6 +130,35 @@ func TestMergeToOutgoingMD(t *testing.T) {
 	assert.Len(t, md4, 2)
 }
 
+func TestMatchMethod(t *testing.T) {
+	assert.True(t, MatchMethod("*", "/pkg.Service/Method"))
+	assert.True(t, MatchMethod("/pkg.Service/Method", "/pkg.Service/Method"))
+	assert.True(t, MatchMethod("/pkg.Service/*", "/pkg.Service/Method"))
+	assert.True(t, MatchMethod("pkg.Service", "/pkg.Service/Method"))
+	assert.True(t, MatchMethod("/pkg.*/Get*", "/pkg.Service/GetUser"))
+	assert.False(t, MatchMethod("/pkg.Service/Other", "/pkg.Service/Method"))
+	assert.False(t, MatchMethod("/pkg.Other/*", "/pkg.Service/Method"))
+	assert.False(t, MatchMethod("pkg.Other", "/pkg.Service/Method"))
+	assert.False(t, MatchMethod("[", "/pkg.Service/Method"))
+}
+
+func TestParseCode(t *testing.T) {
+	code, ok := ParseCode("Unavailable")
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unavailable, code)
+
+	code, ok = ParseCode("DEADLINE_EXCEEDED")
+	assert.True(t, ok)
+	assert.Equal(t, codes.DeadlineExceeded, code)
+
+	code, ok = ParseCode("CANCELLED")
+	assert.True(t, ok)
+	assert.Equal(t, codes.Canceled, code)
+
+	_, ok = ParseCode("not-exist")
+	assert.False(t, ok)
+}
+
 func TestMergeAndDeduplicateSlice(t *testing.T) {
 	src := []string{
 		"a", "b", "c",
@@ -191,3 +226,31 @@ func assertNotPanic(t *testing.T) {
 		assert.True(t, true)
 	}
 }
+
+func TestNewPeerIdentity(t *testing.T) {
+	// without verified chains
+	assert.Nil(t, NewPeerIdentity(tls.ConnectionState{}))
+
+	// happy case
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "ut-client", Organization: []string{"ut-org"}},
+		DNSNames:       []string{"ut.local"},
+		EmailAddresses: []string{"ut@ut.local"},
+		IPAddresses:    []net.IP{net.ParseIP("127.0.0.1")},
+		URIs: []*url.URL{
+			{Scheme: "https", Host: "ut.local"},
+			{Scheme: "spiffe", Host: "ut.org", Path: "/ns/ut/sa/client"},
+		},
+	}
+
+	identity := NewPeerIdentity(tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{cert}},
+	})
+	assert.Equal(t, "CN=ut-client,O=ut-org", identity.Subject)
+	assert.Equal(t, "ut-client", identity.CommonName)
+	assert.Equal(t, []string{"ut.local"}, identity.DNSNames)
+	assert.Equal(t, []string{"ut@ut.local"}, identity.EmailAddresses)
+	assert.Equal(t, []string{"127.0.0.1"}, identity.IPAddresses)
+	assert.Len(t, identity.URIs, 2)
+	assert.Equal(t, "spiffe://ut.org/ns/ut/sa/client", identity.SpiffeId)
+}
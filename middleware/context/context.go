@@ -19,7 +19,9 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"net/http"
 )
 
@@ -67,6 +69,16 @@ func GetIncomingHeaders(ctx context.Context) metadata.MD {
 	return metadata.Pairs()
 }
 
+// GetOutgoingHeaders Extract call-scoped outgoing headers
+func GetOutgoingHeaders(ctx context.Context) metadata.MD {
+	// called from client
+	if v, ok := metadata.FromOutgoingContext(ctx); ok {
+		return v
+	}
+
+	return metadata.Pairs()
+}
+
 // AddHeaderToClient Headers that would be sent to client.
 func AddHeaderToClient(ctx context.Context, key, value string) {
 	// set to grpc header
@@ -266,3 +278,28 @@ func GetJwtToken(ctx context.Context) *jwt.Token {
 
 	return nil
 }
+
+// GetPeerIdentity return identity of peer verified with client certificate, nil will be returned if peer
+// was not verified.
+func GetPeerIdentity(ctx context.Context) *rkgrpcmid.PeerIdentity {
+	if ctx == nil {
+		return nil
+	}
+
+	// case 1: injected by GrpcEntry
+	m := rkgrpcmid.GetServerContextPayload(ctx)
+	if v, ok := m[rkgrpcmid.PeerIdentityKey]; ok {
+		if res, ok := v.(*rkgrpcmid.PeerIdentity); ok {
+			return res
+		}
+	}
+
+	// case 2: TLS terminated by grpc transport credentials
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			return rkgrpcmid.NewPeerIdentity(info.State)
+		}
+	}
+
+	return nil
+}
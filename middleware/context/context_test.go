@@ -7,6 +7,9 @@ package rkgrpcctx
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"github.com/golang-jwt/jwt/v4"
 	rkcursor "github.com/rookie-ninja/rk-entry/v2/cursor"
 	"github.com/rookie-ninja/rk-entry/v2/middleware"
@@ -15,7 +18,9 @@ import (
 	"github.com/rookie-ninja/rk-query"
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"net/http"
 	"testing"
 )
@@ -98,6 +103,16 @@ func TestGetIncomingHeaders(t *testing.T) {
 	assert.NotNil(t, GetIncomingHeaders(context.TODO()))
 }
 
+func TestGetOutgoingHeaders(t *testing.T) {
+	// On client side
+	md := metadata.New(map[string]string{})
+	ctx := metadata.NewOutgoingContext(context.TODO(), md)
+	assert.Equal(t, md, GetOutgoingHeaders(ctx))
+
+	// Neither of above
+	assert.NotNil(t, GetOutgoingHeaders(context.TODO()))
+}
+
 func TestAddHeaderToClient(t *testing.T) {
 	defer assertNotPanic(t)
 
@@ -373,3 +388,29 @@ func assertNotPanic(t *testing.T) {
 		assert.True(t, true)
 	}
 }
+
+func TestGetPeerIdentity(t *testing.T) {
+	// with nil context
+	assert.Nil(t, GetPeerIdentity(nil))
+
+	// without identity
+	assert.Nil(t, GetPeerIdentity(context.TODO()))
+
+	// from payload
+	ctx := rkgrpcmid.WrapContextForServer(context.TODO())
+	identity := &rkgrpcmid.PeerIdentity{CommonName: "ut-client"}
+	rkgrpcmid.AddToServerContextPayload(ctx, rkgrpcmid.PeerIdentityKey, identity)
+	assert.Equal(t, identity, GetPeerIdentity(ctx))
+
+	// from peer
+	ctx = peer.NewContext(context.TODO(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{
+					{Subject: pkix.Name{CommonName: "ut-client"}},
+				}},
+			},
+		},
+	})
+	assert.Equal(t, "ut-client", GetPeerIdentity(ctx).CommonName)
+}
@@ -8,6 +8,9 @@ package rkgrpcctx
 import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"io"
+	"sync"
 )
 
 // WrappedServerStream is a thin wrapper around grpc.ServerStream that allows modifying context.
@@ -30,3 +33,106 @@ func WrapServerStream(stream grpc.ServerStream) *WrappedServerStream {
 
 	return &WrappedServerStream{ServerStream: stream, WrappedContext: stream.Context()}
 }
+
+// WrappedClientStream is a thin wrapper around grpc.ClientStream that notifies once the stream is finished.
+//
+// Stream is finished once response is received completely, or context of stream is done, so that streams
+// cancelled or abandoned by caller are finished as well.
+type WrappedClientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	onFinish      []func(err error)
+	finished      bool
+	err           error
+	lock          sync.Mutex
+	done          chan struct{}
+}
+
+// RecvMsg receives message from nested grpc.ClientStream and checks whether stream is finished.
+//
+// io.EOF is treated as successful finish of stream.
+func (w *WrappedClientStream) RecvMsg(m interface{}) error {
+	err := w.ClientStream.RecvMsg(m)
+
+	switch {
+	case err == io.EOF:
+		w.Finish(nil)
+	case err != nil:
+		w.Finish(err)
+	case !w.serverStreams:
+		// only one response would be sent by server
+		w.Finish(nil)
+	}
+
+	return err
+}
+
+// Finish stream with error, onFinish functions will be called only once in order of wrapping.
+func (w *WrappedClientStream) Finish(err error) {
+	w.lock.Lock()
+	if w.finished {
+		w.lock.Unlock()
+		return
+	}
+	w.finished, w.err = true, err
+	onFinish := w.onFinish
+	w.onFinish = nil
+	w.lock.Unlock()
+
+	defer close(w.done)
+	for i := range onFinish {
+		onFinish[i](err)
+	}
+}
+
+// Add onFinish function, which is called at once if stream is already finished.
+func (w *WrappedClientStream) addOnFinish(onFinish func(err error)) {
+	if onFinish == nil {
+		return
+	}
+
+	w.lock.Lock()
+	if !w.finished {
+		w.onFinish = append(w.onFinish, onFinish)
+		w.lock.Unlock()
+		return
+	}
+	err := w.err
+	w.lock.Unlock()
+
+	onFinish(err)
+}
+
+// Finish stream with error of context once context is done, returns once stream finished.
+func (w *WrappedClientStream) watch(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		w.Finish(status.FromContextError(ctx.Err()).Err())
+	case <-w.done:
+	}
+}
+
+// WrapClientStream returns a ClientStream which calls onFinish once stream is finished.
+//
+// ctx should be the context which stream was created with, stream is finished with error of ctx once ctx is done.
+// Stream which is already wrapped by inner interceptors is returned with onFinish added, so that context of stream
+// is watched only once no matter how many interceptors wrap it.
+func WrapClientStream(ctx context.Context, stream grpc.ClientStream, desc *grpc.StreamDesc, onFinish func(err error)) *WrappedClientStream {
+	if existing, ok := stream.(*WrappedClientStream); ok {
+		existing.addOnFinish(onFinish)
+		return existing
+	}
+
+	wrap := &WrappedClientStream{
+		ClientStream:  stream,
+		serverStreams: desc != nil && desc.ServerStreams,
+		done:          make(chan struct{}),
+	}
+	wrap.addOnFinish(onFinish)
+
+	if ctx != nil && ctx.Done() != nil {
+		go wrap.watch(ctx)
+	}
+
+	return wrap
+}
@@ -2,8 +2,13 @@ package rkgrpcctx
 
 import (
 	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
 	"testing"
 )
 
@@ -52,3 +57,95 @@ func TestWrapServerStream(t *testing.T) {
 	})
 	assert.Equal(t, ctx, wrap.Context())
 }
+
+type ErrClientStream struct {
+	grpc.ClientStream
+	err error
+}
+
+func (f ErrClientStream) RecvMsg(m interface{}) error {
+	return f.err
+}
+
+func TestWrapClientStream(t *testing.T) {
+	// server streams, finished with io.EOF
+	var finished int
+	var finishErr error
+	onFinish := func(err error) {
+		finished++
+		finishErr = err
+	}
+
+	wrap := WrapClientStream(context.TODO(), &ErrClientStream{}, &grpc.StreamDesc{ServerStreams: true}, onFinish)
+	assert.Nil(t, wrap.RecvMsg(nil))
+	assert.Equal(t, 0, finished)
+
+	wrap.ClientStream = &ErrClientStream{err: io.EOF}
+	assert.Equal(t, io.EOF, wrap.RecvMsg(nil))
+	assert.Equal(t, 1, finished)
+	assert.Nil(t, finishErr)
+
+	// should be called only once
+	wrap.Finish(errors.New("ut-error"))
+	assert.Equal(t, 1, finished)
+
+	// server streams, finished with error
+	finished = 0
+	wrap = WrapClientStream(context.TODO(), &ErrClientStream{err: errors.New("ut-error")}, &grpc.StreamDesc{ServerStreams: true}, onFinish)
+	assert.NotNil(t, wrap.RecvMsg(nil))
+	assert.Equal(t, 1, finished)
+	assert.NotNil(t, finishErr)
+
+	// single response
+	finished = 0
+	wrap = WrapClientStream(context.TODO(), &ErrClientStream{}, &grpc.StreamDesc{}, onFinish)
+	assert.Nil(t, wrap.RecvMsg(nil))
+	assert.Equal(t, 1, finished)
+	assert.Nil(t, finishErr)
+
+	// without onFinish
+	wrap = WrapClientStream(context.TODO(), &ErrClientStream{}, nil, nil)
+	assert.Nil(t, wrap.RecvMsg(nil))
+
+	// finished once context is cancelled
+	finished = 0
+	ctx, cancel := context.WithCancel(context.TODO())
+	wrap = WrapClientStream(ctx, &ErrClientStream{}, &grpc.StreamDesc{ServerStreams: true}, onFinish)
+	cancel()
+	<-wrap.done
+	assert.Equal(t, 1, finished)
+	assert.Equal(t, codes.Canceled, status.Code(finishErr))
+
+	// watcher exits once stream is finished
+	ctx, cancel = context.WithCancel(context.TODO())
+	defer cancel()
+	wrap = WrapClientStream(ctx, &ErrClientStream{err: io.EOF}, &grpc.StreamDesc{ServerStreams: true}, onFinish)
+	assert.Equal(t, io.EOF, wrap.RecvMsg(nil))
+	assert.Equal(t, 2, finished)
+	assert.Nil(t, finishErr)
+}
+
+func TestWrapClientStream_Nested(t *testing.T) {
+	called := make([]string, 0)
+	onFinish := func(name string) func(err error) {
+		return func(err error) {
+			called = append(called, name)
+		}
+	}
+
+	// stream wrapped by inner interceptor is shared
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	inner := WrapClientStream(ctx, &ErrClientStream{}, &grpc.StreamDesc{ServerStreams: true}, onFinish("inner"))
+	outer := WrapClientStream(ctx, inner, &grpc.StreamDesc{ServerStreams: true}, onFinish("outer"))
+	assert.Equal(t, inner, outer)
+
+	// called in order of wrapping
+	inner.ClientStream = &ErrClientStream{err: io.EOF}
+	assert.Equal(t, io.EOF, outer.RecvMsg(nil))
+	assert.Equal(t, []string{"inner", "outer"}, called)
+
+	// called at once if stream is already finished
+	WrapClientStream(ctx, outer, &grpc.StreamDesc{ServerStreams: true}, onFinish("finished"))
+	assert.Equal(t, []string{"inner", "outer", "finished"}, called)
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+// Package rkgrpcmock provides fixtures shared by tests of client interceptors
+package rkgrpcmock
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+)
+
+// ClientStreamMock is a grpc.ClientStream which finishes with io.EOF once message is received.
+type ClientStreamMock struct {
+	grpc.ClientStream
+}
+
+// RecvMsg returns io.EOF.
+func (f ClientStreamMock) RecvMsg(m interface{}) error {
+	return io.EOF
+}
+
+// NewUnaryInvoker returns grpc.UnaryInvoker which returns err.
+func NewUnaryInvoker(err error) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return err
+	}
+}
+
+// NewStreamer returns grpc.Streamer which returns err if not nil, ClientStreamMock otherwise.
+func NewStreamer(err error) grpc.Streamer {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err != nil {
+			return nil, err
+		}
+
+		return &ClientStreamMock{}, nil
+	}
+}
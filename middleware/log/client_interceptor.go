@@ -0,0 +1,98 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpclog
+
+import (
+	"github.com/rookie-ninja/rk-entry/v2/middleware"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/log"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/context"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor Create new unary client interceptor.
+func UnaryClientInterceptor(opts ...rkmidlog.Option) grpc.UnaryClientInterceptor {
+	set := rkmidlog.NewOptionSet(opts...)
+
+	return func(ctx context.Context, method string, req, resp interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		// call before
+		beforeCtx := clientBeforeCtx(set, method, cc, "UnaryClient")
+		set.Before(beforeCtx)
+
+		// call invoker
+		err := invoker(ctx, method, req, resp, cc, opts...)
+
+		// call after
+		afterCtx := clientAfterCtx(set, ctx, err)
+		set.After(beforeCtx, afterCtx)
+
+		return err
+	}
+}
+
+// StreamClientInterceptor Create new stream client interceptor.
+//
+// Event will be finished once the stream is finished instead of stream was created.
+func StreamClientInterceptor(opts ...rkmidlog.Option) grpc.StreamClientInterceptor {
+	set := rkmidlog.NewOptionSet(opts...)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		// call before
+		beforeCtx := clientBeforeCtx(set, method, cc, "StreamClient")
+		set.Before(beforeCtx)
+
+		// call streamer
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			set.After(beforeCtx, clientAfterCtx(set, ctx, err))
+			return stream, err
+		}
+
+		return rkgrpcctx.WrapClientStream(ctx, stream, desc, func(err error) {
+			set.After(beforeCtx, clientAfterCtx(set, ctx, err))
+		}), nil
+	}
+}
+
+// Create BeforeCtx for client side.
+func clientBeforeCtx(set rkmidlog.OptionSetInterface, method string, cc *grpc.ClientConn, rpcType string) *rkmidlog.BeforeCtx {
+	beforeCtx := set.BeforeCtx(nil)
+	beforeCtx.Input.UrlPath = method
+
+	// remote address
+	if cc != nil {
+		beforeCtx.Input.RemoteAddr = cc.Target()
+	}
+
+	// grpc fields
+	grpcService, grpcMethod := rkgrpcmid.GetGrpcInfo(method)
+	beforeCtx.Input.Fields = append(beforeCtx.Input.Fields, []zap.Field{
+		zap.String("grpcService", grpcService),
+		zap.String("grpcMethod", grpcMethod),
+		zap.String("grpcType", rpcType),
+	}...)
+
+	return beforeCtx
+}
+
+// Create AfterCtx for client side, request id and trace id will be read from outgoing metadata and span.
+func clientAfterCtx(set rkmidlog.OptionSetInterface, ctx context.Context, err error) *rkmidlog.AfterCtx {
+	var requestId, traceId string
+
+	if v := rkgrpcctx.GetOutgoingHeaders(ctx).Get(rkmid.HeaderRequestId); len(v) > 0 {
+		requestId = v[0]
+	}
+
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+		traceId = spanCtx.TraceID().String()
+	}
+
+	return set.AfterCtx(requestId, traceId, status.Code(err).String())
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpclog
+
+import (
+	"context"
+	"errors"
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/log"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"io"
+	"testing"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	beforeCtx := rkmidlog.NewBeforeCtx()
+	afterCtx := rkmidlog.NewAfterCtx()
+	mock := rkmidlog.NewOptionSetMock(beforeCtx, afterCtx)
+	inter := UnaryClientInterceptor(rkmidlog.WithMockOptionSet(mock))
+
+	beforeCtx.Output.Event = rkentry.EventEntryNoop.CreateEventNoop()
+	beforeCtx.Output.Logger = rkentry.LoggerEntryNoop.Logger
+
+	// happy case
+	ctx := metadata.AppendToOutgoingContext(context.TODO(), "x-request-id", "ut-request-id")
+	assert.Nil(t, inter(ctx, "/ut.service/method", nil, nil, nil, rkgrpcmock.NewUnaryInvoker(nil)))
+
+	// with error
+	assert.NotNil(t, inter(context.TODO(), "/ut.service/method", nil, nil, nil, rkgrpcmock.NewUnaryInvoker(errors.New("ut-error"))))
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	beforeCtx := rkmidlog.NewBeforeCtx()
+	afterCtx := rkmidlog.NewAfterCtx()
+	mock := rkmidlog.NewOptionSetMock(beforeCtx, afterCtx)
+	inter := StreamClientInterceptor(rkmidlog.WithMockOptionSet(mock))
+
+	beforeCtx.Output.Event = rkentry.EventEntryNoop.CreateEventNoop()
+	beforeCtx.Output.Logger = rkentry.LoggerEntryNoop.Logger
+
+	// happy case
+	stream, err := inter(context.TODO(), &grpc.StreamDesc{ServerStreams: true}, nil, "/ut.service/method", rkgrpcmock.NewStreamer(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, io.EOF, stream.RecvMsg(nil))
+
+	// with error
+	_, err = inter(context.TODO(), &grpc.StreamDesc{}, nil, "/ut.service/method", rkgrpcmock.NewStreamer(errors.New("ut-error")))
+	assert.NotNil(t, err)
+}
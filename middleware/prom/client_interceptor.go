@@ -0,0 +1,66 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcprom
+
+import (
+	"context"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/prom"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor Create new unary client interceptor.
+func UnaryClientInterceptor(opts ...rkmidprom.Option) grpc.UnaryClientInterceptor {
+	set := rkmidprom.NewOptionSet(opts...)
+
+	return func(ctx context.Context, method string, req, resp interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		beforeCtx := clientBeforeCtx(set, method, "UnaryClient")
+		set.Before(beforeCtx)
+
+		err := invoker(ctx, method, req, resp, cc, opts...)
+
+		afterCtx := set.AfterCtx(status.Code(err).String())
+		set.After(beforeCtx, afterCtx)
+
+		return err
+	}
+}
+
+// StreamClientInterceptor Create new stream client interceptor.
+//
+// Metrics will be recorded once the stream is finished instead of stream was created.
+func StreamClientInterceptor(opts ...rkmidprom.Option) grpc.StreamClientInterceptor {
+	set := rkmidprom.NewOptionSet(opts...)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		beforeCtx := clientBeforeCtx(set, method, "StreamClient")
+		set.Before(beforeCtx)
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			set.After(beforeCtx, set.AfterCtx(status.Code(err).String()))
+			return stream, err
+		}
+
+		return rkgrpcctx.WrapClientStream(ctx, stream, desc, func(err error) {
+			set.After(beforeCtx, set.AfterCtx(status.Code(err).String()))
+		}), nil
+	}
+}
+
+// Create BeforeCtx for client side.
+func clientBeforeCtx(set rkmidprom.OptionSetInterface, method, rpcType string) *rkmidprom.BeforeCtx {
+	beforeCtx := set.BeforeCtx(nil)
+
+	grpcService, grpcMethod := rkgrpcmid.GetGrpcInfo(method)
+	beforeCtx.Input.GrpcService = grpcService
+	beforeCtx.Input.GrpcMethod = grpcMethod
+	beforeCtx.Input.GrpcType = rpcType
+
+	return beforeCtx
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+package rkgrpcprom
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/prom"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"io"
+	"testing"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	beforeCtx := rkmidprom.NewBeforeCtx()
+	afterCtx := rkmidprom.NewAfterCtx()
+	mock := rkmidprom.NewOptionSetMock(beforeCtx, afterCtx)
+	inter := UnaryClientInterceptor(rkmidprom.WithMockOptionSet(mock))
+
+	assert.Nil(t, inter(context.TODO(), "/ut.service/method", nil, nil, nil, rkgrpcmock.NewUnaryInvoker(nil)))
+	assert.Equal(t, "ut.service", beforeCtx.Input.GrpcService)
+	assert.Equal(t, "method", beforeCtx.Input.GrpcMethod)
+	assert.Equal(t, "UnaryClient", beforeCtx.Input.GrpcType)
+
+	rkmidprom.ClearAllMetrics()
+}
+
+func TestUnaryClientInterceptor_WithRegistry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	inter := UnaryClientInterceptor(
+		rkmidprom.WithEntryNameAndType("ut-client", "ut-type"),
+		rkmidprom.WithRegisterer(reg),
+		rkmidprom.WithLabelerType(rkmidprom.LabelerTypeGrpc))
+
+	assert.Nil(t, inter(context.TODO(), "/ut.service/method", nil, nil, nil, rkgrpcmock.NewUnaryInvoker(nil)))
+	assert.NotNil(t, inter(context.TODO(), "/ut.service/method", nil, nil, nil, rkgrpcmock.NewUnaryInvoker(errors.New("ut-error"))))
+
+	count, err := testutil.GatherAndCount(reg, "rk_prom_resCode")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	rkmidprom.ClearAllMetrics()
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	beforeCtx := rkmidprom.NewBeforeCtx()
+	afterCtx := rkmidprom.NewAfterCtx()
+	mock := rkmidprom.NewOptionSetMock(beforeCtx, afterCtx)
+	inter := StreamClientInterceptor(rkmidprom.WithMockOptionSet(mock))
+
+	// happy case
+	stream, err := inter(context.TODO(), &grpc.StreamDesc{ServerStreams: true}, nil, "/ut.service/method", rkgrpcmock.NewStreamer(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, io.EOF, stream.RecvMsg(nil))
+	assert.Equal(t, "StreamClient", beforeCtx.Input.GrpcType)
+
+	// with error
+	_, err = inter(context.TODO(), &grpc.StreamDesc{}, nil, "/ut.service/method", rkgrpcmock.NewStreamer(errors.New("ut-error")))
+	assert.NotNil(t, err)
+
+	rkmidprom.ClearAllMetrics()
+}
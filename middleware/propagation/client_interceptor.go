@@ -0,0 +1,46 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcprop
+
+import (
+	"context"
+	"google.golang.org/grpc"
+)
+
+// UnaryClientInterceptor Create new unary client interceptor.
+//
+// Call downstream with context of server handler, selected incoming headers, request id,
+// active span and baggage will be forwarded.
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	set := NewOptionSet(opts...)
+
+	return func(ctx context.Context, method string, req, resp interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		beforeCtx := set.BeforeCtx(ctx, method)
+		set.Before(beforeCtx)
+
+		if beforeCtx.Output.NewCtx != nil {
+			ctx = beforeCtx.Output.NewCtx
+		}
+
+		return invoker(ctx, method, req, resp, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor Create new stream client interceptor.
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	set := NewOptionSet(opts...)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		beforeCtx := set.BeforeCtx(ctx, method)
+		set.Before(beforeCtx)
+
+		if beforeCtx.Output.NewCtx != nil {
+			ctx = beforeCtx.Output.NewCtx
+		}
+
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
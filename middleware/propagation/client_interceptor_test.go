@@ -0,0 +1,66 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcprop
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"testing"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	// with mock
+	beforeCtx := NewBeforeCtx()
+	inter := UnaryClientInterceptor(WithMockOptionSet(NewOptionSetMock(beforeCtx)))
+	assert.Nil(t, inter(context.TODO(), "/ut.service/method", nil, nil, nil, NewUnaryInvoker(nil)))
+
+	// happy case
+	inter = UnaryClientInterceptor(WithHeaders("x-tenant-id"))
+	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("x-tenant-id", "ut-tenant"))
+	assert.Nil(t, inter(ctx, "/ut.service/method", nil, nil, nil, NewUnaryInvoker(func(ctx context.Context) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		assert.Equal(t, []string{"ut-tenant"}, md.Get("x-tenant-id"))
+	})))
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	// with mock
+	beforeCtx := NewBeforeCtx()
+	inter := StreamClientInterceptor(WithMockOptionSet(NewOptionSetMock(beforeCtx)))
+	_, err := inter(context.TODO(), &grpc.StreamDesc{}, nil, "/ut.service/method", NewStreamer(nil))
+	assert.Nil(t, err)
+
+	// happy case
+	inter = StreamClientInterceptor(WithHeaders("x-tenant-id"))
+	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("x-tenant-id", "ut-tenant"))
+	_, err = inter(ctx, &grpc.StreamDesc{}, nil, "/ut.service/method", NewStreamer(func(ctx context.Context) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		assert.Equal(t, []string{"ut-tenant"}, md.Get("x-tenant-id"))
+	}))
+	assert.Nil(t, err)
+}
+
+// ************ Test utility ************
+
+func NewUnaryInvoker(validate func(ctx context.Context)) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if validate != nil {
+			validate(ctx)
+		}
+		return nil
+	}
+}
+
+func NewStreamer(validate func(ctx context.Context)) grpc.Streamer {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if validate != nil {
+			validate(ctx)
+		}
+		return nil, nil
+	}
+}
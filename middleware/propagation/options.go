@@ -0,0 +1,302 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+// Package rkgrpcprop is a middleware which propagates incoming request context to outgoing calls
+package rkgrpcprop
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rookie-ninja/rk-entry/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/context"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// ***************** OptionSet Interface *****************
+
+// OptionSetInterface mainly for testing purpose
+type OptionSetInterface interface {
+	GetEntryName() string
+
+	GetEntryType() string
+
+	Before(*BeforeCtx)
+
+	BeforeCtx(ctx context.Context, method string) *BeforeCtx
+
+	ShouldIgnore(string) bool
+}
+
+// ***************** OptionSet Implementation *****************
+
+// optionSet which is used for middleware implementation
+type optionSet struct {
+	entryName    string
+	entryType    string
+	headers      map[string]bool
+	propagator   propagation.TextMapPropagator
+	pathToIgnore []string
+	mock         OptionSetInterface
+}
+
+// NewOptionSet Create new optionSet with options.
+func NewOptionSet(opts ...Option) OptionSetInterface {
+	set := &optionSet{
+		entryName:    "fake-entry",
+		entryType:    "",
+		headers:      make(map[string]bool),
+		pathToIgnore: []string{},
+	}
+
+	for i := range opts {
+		opts[i](set)
+	}
+
+	if set.mock != nil {
+		return set.mock
+	}
+
+	if set.propagator == nil {
+		set.propagator = propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{})
+	}
+
+	return set
+}
+
+// GetEntryName returns entry name
+func (set *optionSet) GetEntryName() string {
+	return set.entryName
+}
+
+// GetEntryType returns entry type
+func (set *optionSet) GetEntryType() string {
+	return set.entryType
+}
+
+// BeforeCtx should be created before Before()
+func (set *optionSet) BeforeCtx(ctx context.Context, method string) *BeforeCtx {
+	res := NewBeforeCtx()
+	res.Input.Ctx = ctx
+	res.Input.Method = method
+
+	return res
+}
+
+// Before should run before user handler
+//
+// Following values would be merged into outgoing metadata if missing:
+// 1: Incoming headers in allow list
+// 2: Request id from incoming headers or context payload
+// 3: Active span and baggage
+func (set *optionSet) Before(ctx *BeforeCtx) {
+	if ctx == nil {
+		return
+	}
+
+	ctx.Output.NewCtx = ctx.Input.Ctx
+
+	if ctx.Input.Ctx == nil || set.ShouldIgnore(ctx.Input.Method) {
+		return
+	}
+
+	incoming := rkgrpcctx.GetIncomingHeaders(ctx.Input.Ctx)
+	outgoing := rkgrpcctx.GetOutgoingHeaders(ctx.Input.Ctx)
+	md := metadata.MD{}
+
+	// 1: headers in allow list
+	for k, v := range incoming {
+		if set.headers[k] && len(outgoing.Get(k)) < 1 {
+			md.Set(k, v...)
+		}
+	}
+
+	// 2: request id
+	if len(outgoing.Get(rkmid.HeaderRequestId)) < 1 {
+		if id := rkgrpcctx.GetRequestId(ctx.Input.Ctx); len(id) > 0 {
+			md.Set(rkmid.HeaderRequestId, id)
+		} else if ids := incoming.Get(rkmid.HeaderRequestId); len(ids) > 0 {
+			md.Set(rkmid.HeaderRequestId, ids[0])
+		}
+	}
+
+	// 3: span and baggage
+	spanCtx := trace.SpanContextFromContext(ctx.Input.Ctx)
+	if !spanCtx.IsValid() {
+		spanCtx = rkgrpcctx.GetTraceSpan(ctx.Input.Ctx).SpanContext()
+	}
+
+	bag := baggage.FromContext(ctx.Input.Ctx)
+	if bag.Len() < 1 {
+		bag = baggage.FromContext(propagation.Baggage{}.Extract(
+			context.Background(), &rkgrpcctx.GrpcMetadataCarrier{Md: &incoming}))
+	}
+
+	injectCtx := baggage.ContextWithBaggage(
+		trace.ContextWithSpanContext(context.Background(), spanCtx), bag)
+	injected := metadata.MD{}
+	set.propagator.Inject(injectCtx, &rkgrpcctx.GrpcMetadataCarrier{Md: &injected})
+	for k, v := range injected {
+		if len(outgoing.Get(k)) < 1 {
+			md.Set(k, v...)
+		}
+	}
+
+	if md.Len() > 0 {
+		ctx.Output.NewCtx = rkgrpcmid.MergeToOutgoingMD(ctx.Input.Ctx, md)
+	}
+}
+
+// ShouldIgnore determine whether propagation should be ignored based on method
+func (set *optionSet) ShouldIgnore(path string) bool {
+	for i := range set.pathToIgnore {
+		if strings.HasPrefix(path, set.pathToIgnore[i]) {
+			return true
+		}
+	}
+
+	return rkmid.ShouldIgnoreGlobal(path)
+}
+
+// ***************** OptionSet Mock *****************
+
+// NewOptionSetMock for testing purpose
+func NewOptionSetMock(before *BeforeCtx) OptionSetInterface {
+	return &optionSetMock{
+		before: before,
+	}
+}
+
+type optionSetMock struct {
+	before *BeforeCtx
+}
+
+// GetEntryName returns entry name
+func (mock *optionSetMock) GetEntryName() string {
+	return "mock"
+}
+
+// GetEntryType returns entry type
+func (mock *optionSetMock) GetEntryType() string {
+	return "mock"
+}
+
+// BeforeCtx should be created before Before()
+func (mock *optionSetMock) BeforeCtx(context.Context, string) *BeforeCtx {
+	return mock.before
+}
+
+// Before should run before user handler
+func (mock *optionSetMock) Before(ctx *BeforeCtx) {
+	return
+}
+
+// ShouldIgnore should run before user handler
+func (mock *optionSetMock) ShouldIgnore(string) bool {
+	return false
+}
+
+// ***************** Context *****************
+
+// NewBeforeCtx create new BeforeCtx with fields initialized
+func NewBeforeCtx() *BeforeCtx {
+	ctx := &BeforeCtx{}
+	return ctx
+}
+
+// BeforeCtx context for Before() function
+type BeforeCtx struct {
+	Input struct {
+		Ctx    context.Context
+		Method string
+	}
+	Output struct {
+		NewCtx context.Context
+	}
+}
+
+// ***************** BootConfig *****************
+
+// BootConfig for YAML
+type BootConfig struct {
+	Enabled bool     `yaml:"enabled" json:"enabled"`
+	Headers []string `yaml:"headers" json:"headers"`
+	Ignore  []string `yaml:"ignore" json:"ignore"`
+}
+
+// ToOptions convert BootConfig into Option list
+func ToOptions(config *BootConfig, entryName, entryType string) []Option {
+	opts := make([]Option, 0)
+
+	if config.Enabled {
+		opts = append(opts,
+			WithEntryNameAndType(entryName, entryType),
+			WithHeaders(config.Headers...),
+			WithPathToIgnore(config.Ignore...))
+	}
+
+	return opts
+}
+
+// ***************** Option *****************
+
+// Option if for middleware options while creating middleware
+type Option func(*optionSet)
+
+// WithEntryNameAndType provide entry name and entry type.
+func WithEntryNameAndType(entryName, entryType string) Option {
+	return func(opt *optionSet) {
+		opt.entryName = entryName
+		opt.entryType = entryType
+	}
+}
+
+// WithHeaders provide incoming headers which would be forwarded to outgoing metadata.
+//
+// Headers are case-insensitive.
+func WithHeaders(headers ...string) Option {
+	return func(opt *optionSet) {
+		for i := range headers {
+			if len(headers[i]) > 0 {
+				opt.headers[strings.ToLower(headers[i])] = true
+			}
+		}
+	}
+}
+
+// WithPropagator provide propagation.TextMapPropagator which injects span and baggage.
+//
+// TraceContext and Baggage propagator would be used by default.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(opt *optionSet) {
+		if propagator != nil {
+			opt.propagator = propagator
+		}
+	}
+}
+
+// WithPathToIgnore provide paths prefix that will ignore.
+func WithPathToIgnore(paths ...string) Option {
+	return func(set *optionSet) {
+		for i := range paths {
+			if len(paths[i]) > 0 {
+				set.pathToIgnore = append(set.pathToIgnore, paths[i])
+			}
+		}
+	}
+}
+
+// WithMockOptionSet provide mock OptionSetInterface
+func WithMockOptionSet(mock OptionSetInterface) Option {
+	return func(set *optionSet) {
+		set.mock = mock
+	}
+}
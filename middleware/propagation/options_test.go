@@ -0,0 +1,117 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcprop
+
+import (
+	"context"
+	"github.com/rookie-ninja/rk-entry/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/metadata"
+	"testing"
+)
+
+func TestNewOptionSet(t *testing.T) {
+	// without options
+	set := NewOptionSet().(*optionSet)
+	assert.NotEmpty(t, set.GetEntryName())
+	assert.Empty(t, set.GetEntryType())
+	assert.Empty(t, set.headers)
+	assert.NotNil(t, set.propagator)
+
+	// with options
+	set = NewOptionSet(
+		WithEntryNameAndType("ut-entry", "ut-type"),
+		WithHeaders("X-Tenant-Id", "", "Authorization"),
+		WithPathToIgnore("/ut.ignore", "")).(*optionSet)
+	assert.Equal(t, "ut-entry", set.GetEntryName())
+	assert.Equal(t, "ut-type", set.GetEntryType())
+	assert.True(t, set.headers["x-tenant-id"])
+	assert.True(t, set.headers["authorization"])
+	assert.Len(t, set.headers, 2)
+	assert.True(t, set.ShouldIgnore("/ut.ignore/method"))
+	assert.False(t, set.ShouldIgnore("/ut.service/method"))
+
+	// with mock
+	mock := NewOptionSetMock(NewBeforeCtx())
+	assert.Equal(t, mock, NewOptionSet(WithMockOptionSet(mock)))
+}
+
+func TestOptionSet_Before(t *testing.T) {
+	set := NewOptionSet(WithHeaders("x-tenant-id", "x-exist"))
+
+	// nil BeforeCtx
+	set.Before(nil)
+
+	// server side context
+	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs(
+		"x-tenant-id", "ut-tenant",
+		"x-exist", "incoming",
+		"x-not-allowed", "value",
+		"baggage", "k1=v1"))
+	ctx = rkgrpcmid.WrapContextForServer(ctx)
+	rkgrpcmid.AddToServerContextPayload(ctx, rkmid.HeaderRequestId, "ut-request-id")
+	ctx, span := sdktrace.NewTracerProvider().Tracer("ut").Start(ctx, "ut-span")
+	defer span.End()
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-exist", "outgoing")
+
+	beforeCtx := set.BeforeCtx(ctx, "/ut.service/method")
+	set.Before(beforeCtx)
+
+	md, _ := metadata.FromOutgoingContext(beforeCtx.Output.NewCtx)
+	assert.Equal(t, []string{"ut-tenant"}, md.Get("x-tenant-id"))
+	assert.Equal(t, []string{"outgoing"}, md.Get("x-exist"))
+	assert.Empty(t, md.Get("x-not-allowed"))
+	assert.Equal(t, []string{"ut-request-id"}, md.Get(rkmid.HeaderRequestId))
+	assert.Len(t, md.Get("traceparent"), 1)
+	assert.Contains(t, md.Get("traceparent")[0], span.SpanContext().TraceID().String())
+	assert.Equal(t, []string{"k1=v1"}, md.Get("baggage"))
+}
+
+func TestOptionSet_Before_WithBaggageInContext(t *testing.T) {
+	set := NewOptionSet()
+
+	member, _ := baggage.NewMember("k2", "v2")
+	bag, _ := baggage.New(member)
+	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs(
+		"baggage", "k1=v1",
+		"x-request-id", "ut-request-id"))
+	ctx = baggage.ContextWithBaggage(ctx, bag)
+
+	beforeCtx := set.BeforeCtx(ctx, "/ut.service/method")
+	set.Before(beforeCtx)
+
+	md, _ := metadata.FromOutgoingContext(beforeCtx.Output.NewCtx)
+	assert.Equal(t, []string{"k2=v2"}, md.Get("baggage"))
+	assert.Equal(t, []string{"ut-request-id"}, md.Get(rkmid.HeaderRequestId))
+	assert.Empty(t, md.Get("traceparent"))
+}
+
+func TestOptionSet_Before_Ignore(t *testing.T) {
+	set := NewOptionSet(WithPathToIgnore("/ut.service"))
+
+	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("x-request-id", "ut-request-id"))
+	beforeCtx := set.BeforeCtx(ctx, "/ut.service/method")
+	set.Before(beforeCtx)
+
+	assert.Equal(t, ctx, beforeCtx.Output.NewCtx)
+}
+
+func TestToOptions(t *testing.T) {
+	config := &BootConfig{
+		Enabled: false,
+		Headers: []string{"x-tenant-id"},
+	}
+
+	// with disabled
+	assert.Empty(t, ToOptions(config, "", ""))
+
+	// with enabled
+	config.Enabled = true
+	assert.NotEmpty(t, ToOptions(config, "", ""))
+}
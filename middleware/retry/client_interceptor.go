@@ -0,0 +1,178 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcretry
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryClientInterceptor Create new unary client interceptor.
+//
+// Calls will be retried with exponential backoff and jitter if returned code is retryable in matched Policy.
+// Requests will be hedged if Policy.Hedging is enabled and response is a proto message.
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	set := NewOptionSet(opts...)
+
+	return func(ctx context.Context, method string, req, resp interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		policy := set.GetPolicy(method)
+		if policy == nil {
+			return invoker(ctx, method, req, resp, cc, opts...)
+		}
+
+		if msg, ok := resp.(proto.Message); ok && policy.Hedging {
+			return hedge(set, policy, ctx, method, req, msg, cc, invoker, opts...)
+		}
+
+		return retry(set, policy, ctx, func(ctx context.Context) error {
+			if policy.PerAttemptTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, policy.PerAttemptTimeout)
+				defer cancel()
+			}
+
+			return invoker(ctx, method, req, resp, cc, opts...)
+		}, method)
+	}
+}
+
+// StreamClientInterceptor Create new stream client interceptor.
+//
+// Only failures while creating stream would be retried, since messages sent over stream can not be replayed.
+// PerAttemptTimeout is not applied to streams since context of stream should live with stream.
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	set := NewOptionSet(opts...)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		policy := set.GetPolicy(method)
+		if policy == nil {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		var stream grpc.ClientStream
+		err := retry(set, policy, ctx, func(ctx context.Context) error {
+			var err error
+			stream, err = streamer(ctx, desc, cc, method, opts...)
+			return err
+		}, method)
+
+		return stream, err
+	}
+}
+
+// Call f until succeeded, non-retryable error returned or attempts exhausted.
+func retry(set OptionSetInterface, policy *Policy, ctx context.Context, f func(context.Context) error, method string) error {
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		err = f(ctx)
+
+		if err == nil || attempt >= policy.MaxAttempts || !policy.IsRetryable(status.Code(err)) {
+			return err
+		}
+
+		timer := time.NewTimer(policy.Backoff(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+		}
+
+		set.Record(ctx, method, KindRetry, err)
+	}
+}
+
+// result of hedged attempt
+type hedgeResult struct {
+	resp proto.Message
+	err  error
+}
+
+// Send hedged requests, first successful or non-retryable response wins and rest of requests would be canceled.
+func hedge(set OptionSetInterface, policy *Policy, ctx context.Context, method string, req interface{}, resp proto.Message, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// buffered, so that goroutines would not be blocked after winner returned
+	results := make(chan *hedgeResult, policy.MaxAttempts)
+	send := func() {
+		attemptResp := resp.ProtoReflect().New().Interface()
+		go func() {
+			attemptCtx, attemptCancel := hedgeCtx, context.CancelFunc(func() {})
+			if policy.PerAttemptTimeout > 0 {
+				attemptCtx, attemptCancel = context.WithTimeout(hedgeCtx, policy.PerAttemptTimeout)
+			}
+			defer attemptCancel()
+
+			err := invoker(attemptCtx, method, req, attemptResp, cc, opts...)
+			results <- &hedgeResult{resp: attemptResp, err: err}
+		}()
+	}
+
+	sent, received := 1, 0
+	send()
+
+	timer := time.NewTimer(policy.HedgingDelay)
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+			// no response yet, send hedged request
+			if sent < policy.MaxAttempts {
+				set.Record(ctx, method, KindHedge, lastErr)
+				sent++
+				send()
+				timer.Reset(policy.HedgingDelay)
+			}
+		case res := <-results:
+			received++
+			if res.err == nil {
+				proto.Reset(resp)
+				proto.Merge(resp, res.resp)
+				return nil
+			}
+
+			lastErr = res.err
+			if !policy.IsRetryable(status.Code(res.err)) {
+				return res.err
+			}
+
+			// all requests failed with retryable error
+			if received >= policy.MaxAttempts {
+				return res.err
+			}
+
+			// retryable error, send next hedged request after pushback if server asked, otherwise immediately,
+			// pushback is capped by MaxBackoff like retries
+			if sent < policy.MaxAttempts {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+
+				delay, ok := retryInfoDelay(res.err)
+				if !ok {
+					delay = 0
+				}
+				if delay > policy.MaxBackoff {
+					delay = policy.MaxBackoff
+				}
+				timer.Reset(delay)
+			}
+		}
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	testdata "github.com/rookie-ninja/rk-grpc/v2/example/middleware/proto/testdata"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestUnaryClientInterceptor_Retry(t *testing.T) {
+	server := &GreeterServer{failures: 2, code: codes.Unavailable}
+	reg := prometheus.NewRegistry()
+	conn := dial(t, server, UnaryClientInterceptor(
+		WithEntryNameAndType("ut-client", "ut-type"),
+		WithRegisterer(reg),
+		WithPolicies(Policy{
+			Methods:        []string{"/Greeter/*"},
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		})))
+
+	resp, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello rk!", resp.GetMessage())
+	assert.Equal(t, 3, server.Calls())
+	assert.Equal(t, float64(2), testutil.ToFloat64(registerCounter(reg).WithLabelValues(
+		"ut-client", "ut-type", "Greeter", "SayHello", KindRetry, codes.Unavailable.String())))
+}
+
+func TestUnaryClientInterceptor_Exhausted(t *testing.T) {
+	server := &GreeterServer{failures: 5, code: codes.Unavailable}
+	conn := dial(t, server, UnaryClientInterceptor(
+		WithPolicies(Policy{
+			MaxAttempts:    2,
+			InitialBackoff: time.Millisecond,
+		})))
+
+	_, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 2, server.Calls())
+}
+
+func TestUnaryClientInterceptor_NonRetryable(t *testing.T) {
+	server := &GreeterServer{failures: 5, code: codes.InvalidArgument}
+	conn := dial(t, server, UnaryClientInterceptor(
+		WithPolicies(Policy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		})))
+
+	_, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, 1, server.Calls())
+}
+
+func TestUnaryClientInterceptor_NoPolicy(t *testing.T) {
+	server := &GreeterServer{failures: 5, code: codes.Unavailable}
+	conn := dial(t, server, UnaryClientInterceptor(
+		WithPolicies(Policy{
+			Methods:     []string{"/Other/*"},
+			MaxAttempts: 3,
+		})))
+
+	_, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, server.Calls())
+}
+
+func TestUnaryClientInterceptor_RetryInfo(t *testing.T) {
+	// server asks to wait for 300ms which is much longer than backoff
+	server := &GreeterServer{failures: 1, code: codes.Unavailable, retryDelay: 300 * time.Millisecond}
+	conn := dial(t, server, UnaryClientInterceptor(
+		WithPolicies(Policy{
+			MaxAttempts:    2,
+			InitialBackoff: time.Millisecond,
+		})))
+
+	start := time.Now()
+	_, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) >= 300*time.Millisecond)
+	assert.Equal(t, 2, server.Calls())
+}
+
+func TestUnaryClientInterceptor_ContextCanceled(t *testing.T) {
+	server := &GreeterServer{failures: 5, code: codes.Unavailable}
+	conn := dial(t, server, UnaryClientInterceptor(
+		WithPolicies(Policy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Second,
+			Jitter:         0.1,
+		})))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := testdata.NewGreeterClient(conn).SayHello(ctx, &testdata.HelloRequest{Name: "rk"})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, 1, server.Calls())
+}
+
+func TestUnaryClientInterceptor_PerAttemptTimeout(t *testing.T) {
+	server := &GreeterServer{failures: 1, code: codes.OK, delay: time.Second}
+	conn := dial(t, server, UnaryClientInterceptor(
+		WithPolicies(Policy{
+			MaxAttempts:       2,
+			InitialBackoff:    time.Millisecond,
+			Codes:             []codes.Code{codes.DeadlineExceeded},
+			PerAttemptTimeout: 100 * time.Millisecond,
+		})))
+
+	resp, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello rk!", resp.GetMessage())
+	assert.Equal(t, 2, server.Calls())
+}
+
+func TestUnaryClientInterceptor_Hedging(t *testing.T) {
+	// first call is slow, hedged request should win
+	server := &GreeterServer{failures: 1, code: codes.OK, delay: time.Second}
+	reg := prometheus.NewRegistry()
+	conn := dial(t, server, UnaryClientInterceptor(
+		WithEntryNameAndType("ut-client", "ut-type"),
+		WithRegisterer(reg),
+		WithPolicies(Policy{
+			MaxAttempts:  3,
+			Hedging:      true,
+			HedgingDelay: 50 * time.Millisecond,
+		})))
+
+	start := time.Now()
+	resp, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello rk!", resp.GetMessage())
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, float64(1), testutil.ToFloat64(registerCounter(reg).WithLabelValues(
+		"ut-client", "ut-type", "Greeter", "SayHello", KindHedge, codes.OK.String())))
+}
+
+func TestUnaryClientInterceptor_HedgingWithFailures(t *testing.T) {
+	// retryable failures trigger next hedged request immediately
+	server := &GreeterServer{failures: 2, code: codes.Unavailable}
+	conn := dial(t, server, UnaryClientInterceptor(
+		WithPolicies(Policy{
+			MaxAttempts:  3,
+			Hedging:      true,
+			HedgingDelay: time.Second,
+		})))
+
+	start := time.Now()
+	_, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, 3, server.Calls())
+
+	// all failed
+	server = &GreeterServer{failures: 5, code: codes.Unavailable}
+	conn = dial(t, server, UnaryClientInterceptor(
+		WithPolicies(Policy{
+			MaxAttempts: 2,
+			Hedging:     true,
+		})))
+	_, err = testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 2, server.Calls())
+
+	// non-retryable
+	server = &GreeterServer{failures: 5, code: codes.InvalidArgument}
+	conn = dial(t, server, UnaryClientInterceptor(
+		WithPolicies(Policy{
+			MaxAttempts: 3,
+			Hedging:     true,
+		})))
+	_, err = testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, 1, server.Calls())
+}
+
+func TestUnaryClientInterceptor_HedgingWithRetryInfo(t *testing.T) {
+	// server asks to wait for 10s which is capped by MaxBackoff
+	server := &GreeterServer{failures: 1, code: codes.Unavailable, retryDelay: 10 * time.Second}
+	conn := dial(t, server, UnaryClientInterceptor(
+		WithPolicies(Policy{
+			MaxAttempts:  2,
+			Hedging:      true,
+			HedgingDelay: 10 * time.Second,
+			MaxBackoff:   100 * time.Millisecond,
+		})))
+
+	start := time.Now()
+	_, err := testdata.NewGreeterClient(conn).SayHello(context.TODO(), &testdata.HelloRequest{Name: "rk"})
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, 2, server.Calls())
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	inter := StreamClientInterceptor(
+		WithPolicies(Policy{
+			Methods:        []string{"/ut.service/*"},
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		}))
+
+	// retry until succeeded
+	calls := 0
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		calls++
+		if calls < 3 {
+			return nil, status.Error(codes.Unavailable, "ut-error")
+		}
+		return &ClientStreamMock{}, nil
+	}
+	stream, err := inter(context.TODO(), &grpc.StreamDesc{}, nil, "/ut.service/method", streamer)
+	assert.Nil(t, err)
+	assert.NotNil(t, stream)
+	assert.Equal(t, 3, calls)
+
+	// method without policy
+	calls = 0
+	_, err = inter(context.TODO(), &grpc.StreamDesc{}, nil, "/ut.other/method", streamer)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+// ************ Test utility ************
+
+type ClientStreamMock struct {
+	grpc.ClientStream
+}
+
+// GreeterServer fails first number of calls with code, and sleeps with delay in failed calls.
+type GreeterServer struct {
+	failures   int
+	code       codes.Code
+	delay      time.Duration
+	retryDelay time.Duration
+	calls      int
+	lock       sync.Mutex
+}
+
+func (s *GreeterServer) Calls() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.calls
+}
+
+func (s *GreeterServer) SayHello(ctx context.Context, req *testdata.HelloRequest) (*testdata.HelloResponse, error) {
+	s.lock.Lock()
+	s.calls++
+	calls := s.calls
+	s.lock.Unlock()
+
+	if calls <= s.failures {
+		if s.delay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(s.delay):
+			}
+		}
+
+		if s.code != codes.OK {
+			st := status.New(s.code, "ut-error")
+			if s.retryDelay > 0 {
+				st, _ = st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(s.retryDelay)})
+			}
+			return nil, st.Err()
+		}
+	}
+
+	return &testdata.HelloResponse{Message: fmt.Sprintf("Hello %s!", req.GetName())}, nil
+}
+
+func dial(t *testing.T, server testdata.GreeterServer, inter grpc.UnaryClientInterceptor) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	testdata.RegisterGreeterServer(s, server)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(context.TODO(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(inter))
+	if err != nil {
+		t.Fatal(errors.New("failed to dial bufnet"))
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
@@ -0,0 +1,377 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+// Package rkgrpcretry is a middleware which retries or hedges outgoing calls
+package rkgrpcretry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// KindRetry is used as event counter key and metrics label while retrying
+	KindRetry = "retry"
+	// KindHedge is used as event counter key and metrics label while sending hedged request
+	KindHedge = "hedge"
+
+	defaultMaxAttempts       = 3
+	defaultInitialBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff        = 2 * time.Second
+	defaultBackoffMultiplier = 2.0
+	defaultHedgingDelay      = 100 * time.Millisecond
+)
+
+var (
+	labelKeys = []string{"entryName", "entryType", "grpcService", "grpcMethod", "kind", "resCode"}
+)
+
+// Policy of retry or hedging which applies to matched methods.
+type Policy struct {
+	// Methods matched with rkgrpcmid.MatchMethod, matches all methods if empty
+	Methods []string
+	// MaxAttempts including the original call
+	MaxAttempts int
+	// InitialBackoff of first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff
+	MaxBackoff time.Duration
+	// BackoffMultiplier applies to backoff after each attempt
+	BackoffMultiplier float64
+	// Jitter in range of [0, 1], backoff would be randomized within (1 ± Jitter) * backoff
+	Jitter float64
+	// Codes which are retryable, codes.Unavailable by default
+	Codes []codes.Code
+	// PerAttemptTimeout applies to each attempt if larger than zero
+	PerAttemptTimeout time.Duration
+	// Hedging sends MaxAttempts requests with HedgingDelay in between, first successful response wins.
+	// Should be used for idempotent methods only.
+	Hedging bool
+	// HedgingDelay between hedged requests
+	HedgingDelay time.Duration
+}
+
+// IsRetryable checks whether code is retryable.
+func (p *Policy) IsRetryable(code codes.Code) bool {
+	for i := range p.Codes {
+		if p.Codes[i] == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Backoff calculates delay before next attempt, attempt starts from 1.
+//
+// Delay from errdetails.RetryInfo sent by server will be used if exists, which is capped by MaxBackoff.
+func (p *Policy) Backoff(attempt int, err error) time.Duration {
+	if delay, ok := retryInfoDelay(err); ok {
+		if delay > p.MaxBackoff {
+			delay = p.MaxBackoff
+		}
+		return delay
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(p.BackoffMultiplier, float64(attempt-1))
+	if backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		backoff = backoff * (1 + p.Jitter*(rand.Float64()*2-1))
+	}
+
+	return time.Duration(backoff)
+}
+
+// Fill default values.
+func (p *Policy) normalize() {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = defaultMaxAttempts
+	}
+
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultInitialBackoff
+	}
+
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+
+	if p.BackoffMultiplier < 1 {
+		p.BackoffMultiplier = defaultBackoffMultiplier
+	}
+
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+
+	if len(p.Codes) < 1 {
+		p.Codes = []codes.Code{codes.Unavailable}
+	}
+
+	if p.HedgingDelay <= 0 {
+		p.HedgingDelay = defaultHedgingDelay
+	}
+}
+
+// Read delay from errdetails.RetryInfo in status details.
+func retryInfoDelay(err error) (time.Duration, bool) {
+	s, ok := status.FromError(err)
+	if !ok || s == nil {
+		return 0, false
+	}
+
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			if delay := info.GetRetryDelay().AsDuration(); delay >= 0 {
+				return delay, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// ***************** OptionSet Interface *****************
+
+// OptionSetInterface which is used by interceptors
+type OptionSetInterface interface {
+	GetEntryName() string
+
+	GetEntryType() string
+
+	// GetPolicy returns first policy matches method, nil if not found
+	GetPolicy(method string) *Policy
+
+	// Record attempt of retry or hedging into event and metrics
+	Record(ctx context.Context, method, kind string, err error)
+}
+
+// ***************** OptionSet Implementation *****************
+
+// optionSet which is used for middleware implementation
+type optionSet struct {
+	entryName  string
+	entryType  string
+	policies   []*Policy
+	registerer prometheus.Registerer
+	counter    *prometheus.CounterVec
+}
+
+// NewOptionSet Create new optionSet with options.
+func NewOptionSet(opts ...Option) OptionSetInterface {
+	set := &optionSet{
+		entryName: "fake-entry",
+		entryType: "",
+		policies:  make([]*Policy, 0),
+	}
+
+	for i := range opts {
+		opts[i](set)
+	}
+
+	for i := range set.policies {
+		set.policies[i].normalize()
+	}
+
+	if set.registerer != nil {
+		set.counter = registerCounter(set.registerer)
+	}
+
+	return set
+}
+
+// GetEntryName returns entry name
+func (set *optionSet) GetEntryName() string {
+	return set.entryName
+}
+
+// GetEntryType returns entry type
+func (set *optionSet) GetEntryType() string {
+	return set.entryType
+}
+
+// GetPolicy returns first policy matches method, nil if not found
+func (set *optionSet) GetPolicy(method string) *Policy {
+	for _, policy := range set.policies {
+		if len(policy.Methods) < 1 {
+			return policy
+		}
+
+		for i := range policy.Methods {
+			if rkgrpcmid.MatchMethod(policy.Methods[i], method) {
+				return policy
+			}
+		}
+	}
+
+	return nil
+}
+
+// Record attempt of retry or hedging into event and metrics
+func (set *optionSet) Record(ctx context.Context, method, kind string, err error) {
+	rkgrpcctx.GetEvent(ctx).IncCounter(kind, 1)
+
+	if set.counter != nil {
+		grpcService, grpcMethod := rkgrpcmid.GetGrpcInfo(method)
+		set.counter.WithLabelValues(
+			set.entryName,
+			set.entryType,
+			grpcService,
+			grpcMethod,
+			kind,
+			status.Code(err).String()).Inc()
+	}
+}
+
+// Register counter into registerer, existing one would be reused.
+func registerCounter(registerer prometheus.Registerer) *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rk_grpc_client_retry",
+		Help: "counter of retried and hedged attempts of outgoing grpc calls",
+	}, labelKeys)
+
+	if err := registerer.Register(counter); err != nil {
+		if existing, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if res, ok := existing.ExistingCollector.(*prometheus.CounterVec); ok {
+				return res
+			}
+		}
+		return nil
+	}
+
+	return counter
+}
+
+// ***************** BootConfig *****************
+
+// BootConfig for YAML
+type BootConfig struct {
+	Enabled  bool               `yaml:"enabled" json:"enabled"`
+	Policies []BootConfigPolicy `yaml:"policies" json:"policies"`
+}
+
+// BootConfigPolicy for YAML, converted into Policy
+type BootConfigPolicy struct {
+	Methods             []string `yaml:"methods" json:"methods"`
+	MaxAttempts         int      `yaml:"maxAttempts" json:"maxAttempts"`
+	InitialBackoffMs    int64    `yaml:"initialBackoffMs" json:"initialBackoffMs"`
+	MaxBackoffMs        int64    `yaml:"maxBackoffMs" json:"maxBackoffMs"`
+	BackoffMultiplier   float64  `yaml:"backoffMultiplier" json:"backoffMultiplier"`
+	Jitter              float64  `yaml:"jitter" json:"jitter"`
+	Codes               []string `yaml:"codes" json:"codes"`
+	PerAttemptTimeoutMs int64    `yaml:"perAttemptTimeoutMs" json:"perAttemptTimeoutMs"`
+	Hedging             struct {
+		Enabled bool  `yaml:"enabled" json:"enabled"`
+		DelayMs int64 `yaml:"delayMs" json:"delayMs"`
+	} `yaml:"hedging" json:"hedging"`
+}
+
+// ValidateBootConfig returns error if unknown codes exist in policies of enabled BootConfig.
+func ValidateBootConfig(config *BootConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	for i := range config.Policies {
+		for _, str := range config.Policies[i].Codes {
+			if _, ok := rkgrpcmid.ParseCode(str); !ok {
+				return fmt.Errorf("retry.policies[%d]: unknown code %s", i, str)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ToOptions convert BootConfig into Option list, unknown codes are skipped, use ValidateBootConfig to report them.
+func ToOptions(config *BootConfig, entryName, entryType string, registerer prometheus.Registerer) []Option {
+	opts := make([]Option, 0)
+
+	if config.Enabled {
+		policies := make([]Policy, 0)
+		for i := range config.Policies {
+			element := config.Policies[i]
+
+			policy := Policy{
+				Methods:           element.Methods,
+				MaxAttempts:       element.MaxAttempts,
+				InitialBackoff:    time.Duration(element.InitialBackoffMs) * time.Millisecond,
+				MaxBackoff:        time.Duration(element.MaxBackoffMs) * time.Millisecond,
+				BackoffMultiplier: element.BackoffMultiplier,
+				Jitter:            element.Jitter,
+				Codes:             make([]codes.Code, 0),
+				PerAttemptTimeout: time.Duration(element.PerAttemptTimeoutMs) * time.Millisecond,
+				Hedging:           element.Hedging.Enabled,
+				HedgingDelay:      time.Duration(element.Hedging.DelayMs) * time.Millisecond,
+			}
+
+			for j := range element.Codes {
+				if code, ok := rkgrpcmid.ParseCode(element.Codes[j]); ok {
+					policy.Codes = append(policy.Codes, code)
+				}
+			}
+
+			policies = append(policies, policy)
+		}
+
+		opts = append(opts,
+			WithEntryNameAndType(entryName, entryType),
+			WithPolicies(policies...),
+			WithRegisterer(registerer))
+	}
+
+	return opts
+}
+
+// ***************** Option *****************
+
+// Option if for middleware options while creating middleware
+type Option func(*optionSet)
+
+// WithEntryNameAndType provide entry name and entry type.
+func WithEntryNameAndType(entryName, entryType string) Option {
+	return func(opt *optionSet) {
+		opt.entryName = entryName
+		opt.entryType = entryType
+	}
+}
+
+// WithPolicies provide Policy, policies are matched in order.
+func WithPolicies(policies ...Policy) Option {
+	return func(opt *optionSet) {
+		for i := range policies {
+			policy := policies[i]
+			opt.policies = append(opt.policies, &policy)
+		}
+	}
+}
+
+// WithRegisterer provide prometheus.Registerer, retry metrics will be registered if provided.
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return func(opt *optionSet) {
+		opt.registerer = registerer
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpcretry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestNewOptionSet(t *testing.T) {
+	// without options
+	set := NewOptionSet().(*optionSet)
+	assert.Equal(t, "fake-entry", set.GetEntryName())
+	assert.Empty(t, set.GetEntryType())
+	assert.Nil(t, set.GetPolicy("/ut.service/method"))
+	assert.Nil(t, set.counter)
+
+	// with options
+	set = NewOptionSet(
+		WithEntryNameAndType("ut-entry", "ut-type"),
+		WithRegisterer(prometheus.NewRegistry()),
+		WithPolicies(Policy{
+			Methods: []string{"/ut.service/method"},
+		}, Policy{
+			Methods:     []string{"ut.other"},
+			MaxAttempts: 5,
+		})).(*optionSet)
+
+	assert.Equal(t, "ut-entry", set.GetEntryName())
+	assert.Equal(t, "ut-type", set.GetEntryType())
+	assert.NotNil(t, set.counter)
+
+	// defaults
+	policy := set.GetPolicy("/ut.service/method")
+	assert.Equal(t, defaultMaxAttempts, policy.MaxAttempts)
+	assert.Equal(t, defaultInitialBackoff, policy.InitialBackoff)
+	assert.Equal(t, defaultMaxBackoff, policy.MaxBackoff)
+	assert.Equal(t, defaultBackoffMultiplier, policy.BackoffMultiplier)
+	assert.Equal(t, defaultHedgingDelay, policy.HedgingDelay)
+	assert.Equal(t, []codes.Code{codes.Unavailable}, policy.Codes)
+
+	assert.Equal(t, 5, set.GetPolicy("/ut.other/method").MaxAttempts)
+	assert.Nil(t, set.GetPolicy("/ut.unknown/method"))
+}
+
+func TestPolicy_Backoff(t *testing.T) {
+	policy := &Policy{
+		InitialBackoff:    100 * time.Millisecond,
+		MaxBackoff:        time.Second,
+		BackoffMultiplier: 2,
+	}
+	policy.normalize()
+
+	// exponential
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1, nil))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2, nil))
+	assert.Equal(t, 400*time.Millisecond, policy.Backoff(3, nil))
+	// capped
+	assert.Equal(t, time.Second, policy.Backoff(10, nil))
+
+	// with jitter
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := policy.Backoff(1, nil)
+		assert.True(t, backoff >= 50*time.Millisecond)
+		assert.True(t, backoff <= 150*time.Millisecond)
+	}
+
+	// with RetryInfo
+	st, _ := status.New(codes.Unavailable, "ut-error").WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(500 * time.Millisecond),
+	})
+	assert.Equal(t, 500*time.Millisecond, policy.Backoff(1, st.Err()))
+
+	// with RetryInfo larger than MaxBackoff
+	st, _ = status.New(codes.Unavailable, "ut-error").WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(3 * time.Second),
+	})
+	assert.Equal(t, time.Second, policy.Backoff(1, st.Err()))
+	assert.True(t, policy.Backoff(1, errors.New("ut-error")) < time.Second)
+}
+
+func TestPolicy_IsRetryable(t *testing.T) {
+	policy := &Policy{
+		Codes: []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+	}
+
+	assert.True(t, policy.IsRetryable(codes.Unavailable))
+	assert.True(t, policy.IsRetryable(codes.ResourceExhausted))
+	assert.False(t, policy.IsRetryable(codes.Internal))
+}
+
+func TestOptionSet_Record(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	set := NewOptionSet(
+		WithEntryNameAndType("ut-entry", "ut-type"),
+		WithRegisterer(reg))
+
+	set.Record(context.TODO(), "/ut.service/method", KindRetry, status.Error(codes.Unavailable, "ut-error"))
+	set.Record(context.TODO(), "/ut.service/method", KindRetry, status.Error(codes.Unavailable, "ut-error"))
+
+	// counter registered twice should be reused
+	counter := registerCounter(reg)
+	assert.NotNil(t, counter)
+	assert.Equal(t, float64(2), testutil.ToFloat64(counter.WithLabelValues(
+		"ut-entry", "ut-type", "ut.service", "method", KindRetry, codes.Unavailable.String())))
+}
+
+func TestToOptions(t *testing.T) {
+	config := &BootConfig{
+		Enabled: false,
+	}
+
+	// with disabled
+	assert.Empty(t, ToOptions(config, "", "", nil))
+
+	// with enabled
+	config.Enabled = true
+	config.Policies = []BootConfigPolicy{
+		{
+			Methods:             []string{"/ut.service/*"},
+			MaxAttempts:         4,
+			InitialBackoffMs:    10,
+			MaxBackoffMs:        100,
+			BackoffMultiplier:   1.5,
+			Jitter:              0.2,
+			Codes:               []string{"unavailable", "RESOURCE_EXHAUSTED", "invalid"},
+			PerAttemptTimeoutMs: 1000,
+		},
+	}
+	config.Policies[0].Hedging.Enabled = true
+	config.Policies[0].Hedging.DelayMs = 50
+
+	set := NewOptionSet(ToOptions(config, "ut-entry", "ut-type", prometheus.NewRegistry())...)
+	assert.Equal(t, "ut-entry", set.GetEntryName())
+
+	policy := set.GetPolicy("/ut.service/method")
+	assert.Equal(t, 4, policy.MaxAttempts)
+	assert.Equal(t, 10*time.Millisecond, policy.InitialBackoff)
+	assert.Equal(t, 100*time.Millisecond, policy.MaxBackoff)
+	assert.Equal(t, 1.5, policy.BackoffMultiplier)
+	assert.Equal(t, 0.2, policy.Jitter)
+	assert.Equal(t, []codes.Code{codes.Unavailable, codes.ResourceExhausted}, policy.Codes)
+	assert.Equal(t, time.Second, policy.PerAttemptTimeout)
+	assert.True(t, policy.Hedging)
+	assert.Equal(t, 50*time.Millisecond, policy.HedgingDelay)
+}
+
+func TestValidateBootConfig(t *testing.T) {
+	config := &BootConfig{
+		Policies: []BootConfigPolicy{
+			{Codes: []string{"unavailable"}},
+			{Codes: []string{"RESOURCE_EXHAUSTED", "invalid"}},
+		},
+	}
+
+	// with disabled
+	assert.Nil(t, ValidateBootConfig(config))
+
+	// with unknown code
+	config.Enabled = true
+	assert.EqualError(t, ValidateBootConfig(config), "retry.policies[1]: unknown code invalid")
+
+	// happy case
+	config.Policies[1].Codes = []string{"RESOURCE_EXHAUSTED"}
+	assert.Nil(t, ValidateBootConfig(config))
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpctrace
+
+import (
+	"context"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/tracing"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor Create new unary client interceptor.
+//
+// A client span will be started as child of span in context, and injected into outgoing metadata.
+func UnaryClientInterceptor(opts ...rkmidtrace.Option) grpc.UnaryClientInterceptor {
+	set := rkmidtrace.NewOptionSet(opts...)
+
+	return func(ctx context.Context, method string, req, resp interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		beforeCtx := clientBeforeCtx(set, ctx, method, cc, "UnaryClient")
+		set.Before(beforeCtx)
+
+		// inject span into outgoing metadata
+		ctx = injectToOutgoingContext(set, beforeCtx, ctx)
+
+		// call invoker
+		err := invoker(ctx, method, req, resp, cc, opts...)
+
+		set.After(beforeCtx, clientAfterCtx(set, err))
+
+		return err
+	}
+}
+
+// StreamClientInterceptor Create new stream client interceptor.
+//
+// Span will be ended once the stream is finished instead of stream was created.
+func StreamClientInterceptor(opts ...rkmidtrace.Option) grpc.StreamClientInterceptor {
+	set := rkmidtrace.NewOptionSet(opts...)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		beforeCtx := clientBeforeCtx(set, ctx, method, cc, "StreamClient")
+		set.Before(beforeCtx)
+
+		// inject span into outgoing metadata
+		ctx = injectToOutgoingContext(set, beforeCtx, ctx)
+
+		// call streamer
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			set.After(beforeCtx, clientAfterCtx(set, err))
+			return stream, err
+		}
+
+		return rkgrpcctx.WrapClientStream(ctx, stream, desc, func(err error) {
+			set.After(beforeCtx, clientAfterCtx(set, err))
+		}), nil
+	}
+}
+
+// Create BeforeCtx for client side.
+func clientBeforeCtx(set rkmidtrace.OptionSetInterface, ctx context.Context, method string, cc *grpc.ClientConn, rpcType string) *rkmidtrace.BeforeCtx {
+	beforeCtx := set.BeforeCtx(nil, true)
+	beforeCtx.Input.UrlPath = method
+	beforeCtx.Input.RequestCtx = ctx
+	beforeCtx.Input.SpanName = method
+
+	// metadata carrier, tracing info injected by caller would be used as parent
+	outgoingMD := rkgrpcctx.GetOutgoingHeaders(ctx).Copy()
+	beforeCtx.Input.Carrier = &rkgrpcctx.GrpcMetadataCarrier{Md: &outgoingMD}
+
+	// grpc related meta
+	target := ""
+	if cc != nil {
+		target = cc.Target()
+	}
+	grpcService, grpcMethod := rkgrpcmid.GetGrpcInfo(method)
+	beforeCtx.Input.Attributes = append(beforeCtx.Input.Attributes,
+		attribute.String("local.IP", rkgrpcmid.LocalIp.String),
+		attribute.String("local.hostname", rkgrpcmid.LocalHostname.String),
+		attribute.String("remote.target", target),
+		attribute.String("grpc.service", grpcService),
+		attribute.String("grpc.method", grpcMethod),
+		attribute.String("client.type", rpcType))
+
+	return beforeCtx
+}
+
+// Inject span started in Before() into outgoing metadata.
+func injectToOutgoingContext(set rkmidtrace.OptionSetInterface, beforeCtx *rkmidtrace.BeforeCtx, ctx context.Context) context.Context {
+	if beforeCtx.Output.NewCtx != nil {
+		ctx = beforeCtx.Output.NewCtx
+	}
+
+	carrier, ok := beforeCtx.Input.Carrier.(*rkgrpcctx.GrpcMetadataCarrier)
+	if !ok || set.GetPropagator() == nil {
+		return ctx
+	}
+
+	set.GetPropagator().Inject(ctx, carrier)
+
+	return metadata.NewOutgoingContext(ctx, *carrier.Md)
+}
+
+// Create AfterCtx for client side.
+func clientAfterCtx(set rkmidtrace.OptionSetInterface, err error) *rkmidtrace.AfterCtx {
+	if err != nil {
+		s, _ := status.FromError(err)
+		return set.AfterCtx(int(codes.Error), s.Message(),
+			attribute.Int("grpc.code", int(s.Code())),
+			attribute.String("grpc.status", s.Code().String()))
+	}
+
+	return set.AfterCtx(200, "",
+		attribute.Int("grpc.code", int(codes.Ok)),
+		attribute.String("grpc.status", codes.Ok.String()))
+}
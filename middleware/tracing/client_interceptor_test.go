@@ -0,0 +1,79 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+package rkgrpctrace
+
+import (
+	"context"
+	"errors"
+	"github.com/rookie-ninja/rk-entry/v2/middleware/tracing"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"io"
+	"testing"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	defer assertNotPanic(t)
+
+	beforeCtx := rkmidtrace.NewBeforeCtx()
+	afterCtx := rkmidtrace.NewAfterCtx()
+	mock := rkmidtrace.NewOptionSetMock(beforeCtx, afterCtx, nil, nil, nil)
+	inter := UnaryClientInterceptor(rkmidtrace.WithMockOptionSet(mock))
+
+	// case 1: with error response
+	assert.NotNil(t, inter(context.TODO(), "/ut.service/method", nil, nil, nil, rkgrpcmock.NewUnaryInvoker(errors.New("ut-error"))))
+
+	// case 2: happy case
+	assert.Nil(t, inter(context.TODO(), "/ut.service/method", nil, nil, nil, rkgrpcmock.NewUnaryInvoker(nil)))
+}
+
+func TestUnaryClientInterceptor_Inject(t *testing.T) {
+	inter := UnaryClientInterceptor(
+		rkmidtrace.WithEntryNameAndType("ut-client", "ut-type"),
+		rkmidtrace.WithPropagator(propagation.TraceContext{}))
+
+	// parent span in context
+	ctx, parent := sdktrace.NewTracerProvider().Tracer("ut").Start(context.TODO(), "parent")
+	defer parent.End()
+
+	// keep outgoing metadata provided by caller
+	ctx = metadata.AppendToOutgoingContext(ctx, "key", "value")
+
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		assert.Equal(t, parent.SpanContext().TraceID(), trace.SpanContextFromContext(ctx).TraceID())
+		assert.NotEqual(t, parent.SpanContext().SpanID(), trace.SpanContextFromContext(ctx).SpanID())
+		return nil
+	}
+
+	assert.Nil(t, inter(ctx, "/ut.service/method", nil, nil, nil, invoker))
+	assert.Equal(t, []string{"value"}, outgoing.Get("key"))
+	assert.Len(t, outgoing.Get("traceparent"), 1)
+	assert.Contains(t, outgoing.Get("traceparent")[0], parent.SpanContext().TraceID().String())
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	defer assertNotPanic(t)
+
+	beforeCtx := rkmidtrace.NewBeforeCtx()
+	afterCtx := rkmidtrace.NewAfterCtx()
+	mock := rkmidtrace.NewOptionSetMock(beforeCtx, afterCtx, nil, nil, nil)
+	inter := StreamClientInterceptor(rkmidtrace.WithMockOptionSet(mock))
+
+	// case 1: with error response
+	_, err := inter(context.TODO(), &grpc.StreamDesc{}, nil, "/ut.service/method", rkgrpcmock.NewStreamer(errors.New("ut-error")))
+	assert.NotNil(t, err)
+
+	// case 2: happy case
+	stream, err := inter(context.TODO(), &grpc.StreamDesc{ServerStreams: true}, nil, "/ut.service/method", rkgrpcmock.NewStreamer(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, io.EOF, stream.RecvMsg(nil))
+}
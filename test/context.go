@@ -0,0 +1,112 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpctest
+
+import (
+	"context"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/rookie-ninja/rk-entry/v2/middleware"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware"
+	"github.com/rookie-ninja/rk-query"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+)
+
+// ContextOption Option for NewServerContext.
+type ContextOption func(context.Context) context.Context
+
+// NewServerContext Create server side context as if it was passed through rk middleware,
+// values are able to be read with functions in rkgrpcctx.
+func NewServerContext(parent context.Context, opts ...ContextOption) context.Context {
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx := rkgrpcmid.WrapContextForServer(parent)
+	for i := range opts {
+		ctx = opts[i](ctx)
+	}
+
+	return ctx
+}
+
+// WithJwtToken Provide jwt.Token which is parsed by jwt middleware.
+func WithJwtToken(token *jwt.Token) ContextOption {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, rkmid.JwtTokenKey, token)
+	}
+}
+
+// WithRequestId Provide request id which is generated by meta middleware.
+func WithRequestId(requestId string) ContextOption {
+	return func(ctx context.Context) context.Context {
+		rkgrpcmid.AddToServerContextPayload(ctx, rkmid.HeaderRequestId, requestId)
+		return withIncomingHeader(ctx, rkmid.HeaderRequestId, requestId)
+	}
+}
+
+// WithTraceSpan Provide span which is started by trace middleware, trace id is set if span context is valid.
+func WithTraceSpan(span trace.Span) ContextOption {
+	return func(ctx context.Context) context.Context {
+		rkgrpcmid.AddToServerContextPayload(ctx, rkmid.SpanKey, span)
+		if span.SpanContext().HasTraceID() {
+			rkgrpcmid.AddToServerContextPayload(ctx, rkmid.HeaderTraceId, span.SpanContext().TraceID().String())
+		}
+		return trace.ContextWithSpan(ctx, span)
+	}
+}
+
+// WithLogger Provide zap.Logger which is injected by logging middleware.
+func WithLogger(logger *zap.Logger) ContextOption {
+	return func(ctx context.Context) context.Context {
+		rkgrpcmid.AddToServerContextPayload(ctx, rkmid.LoggerKey, logger)
+		return ctx
+	}
+}
+
+// WithEvent Provide rkquery.Event which is injected by logging middleware.
+func WithEvent(event rkquery.Event) ContextOption {
+	return func(ctx context.Context) context.Context {
+		rkgrpcmid.AddToServerContextPayload(ctx, rkmid.EventKey, event)
+		return ctx
+	}
+}
+
+// WithRecorder Provide logger and a new event of Recorder, so that logs are captured.
+//
+// Event is not finished, call Finish of rec.EventEntry in order to capture it.
+func WithRecorder(rec *Recorder) ContextOption {
+	return func(ctx context.Context) context.Context {
+		ctx = WithLogger(rec.LoggerEntry.Logger)(ctx)
+		return WithEvent(rec.EventEntry.CreateEvent())(ctx)
+	}
+}
+
+// WithEntryName Provide name of entry which is injected by middleware.
+func WithEntryName(entryName string) ContextOption {
+	return func(ctx context.Context) context.Context {
+		rkgrpcmid.AddToServerContextPayload(ctx, rkmid.EntryNameKey, entryName)
+		return ctx
+	}
+}
+
+// WithIncomingHeader Provide header in incoming metadata.
+func WithIncomingHeader(key, value string) ContextOption {
+	return func(ctx context.Context) context.Context {
+		return withIncomingHeader(ctx, key, value)
+	}
+}
+
+// Append key/value into incoming metadata.
+func withIncomingHeader(ctx context.Context, key, value string) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	md.Append(key, value)
+
+	return metadata.NewIncomingContext(ctx, md)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpctest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/context"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewServerContext(t *testing.T) {
+	// without options
+	ctx := NewServerContext(nil)
+	assert.Empty(t, rkgrpcctx.GetRequestId(ctx))
+	assert.Nil(t, rkgrpcctx.GetJwtToken(ctx))
+
+	// with jwt token, request id and entry name
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "ut-user"})
+	ctx = NewServerContext(context.Background(),
+		WithJwtToken(token),
+		WithRequestId("ut-request-id"),
+		WithEntryName("ut-entry"),
+		WithIncomingHeader("x-ut-key", "ut-value"))
+
+	assert.Equal(t, token, rkgrpcctx.GetJwtToken(ctx))
+	assert.Equal(t, "ut-request-id", rkgrpcctx.GetRequestId(ctx))
+	assert.Equal(t, "ut-entry", rkgrpcctx.GetEntryName(ctx))
+	assert.Equal(t, []string{"ut-value"}, rkgrpcctx.GetIncomingHeaders(ctx).Get("x-ut-key"))
+	assert.Equal(t, []string{"ut-request-id"}, rkgrpcctx.GetIncomingHeaders(ctx).Get("x-request-id"))
+}
+
+func TestWithTraceSpan(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.Tracer("ut-tracer").Start(context.Background(), "ut-span")
+	defer span.End()
+
+	ctx := NewServerContext(context.Background(), WithTraceSpan(span))
+	assert.Equal(t, span, rkgrpcctx.GetTraceSpan(ctx))
+	assert.Equal(t, span.SpanContext().TraceID().String(), rkgrpcctx.GetTraceId(ctx))
+	assert.Equal(t, span, trace.SpanFromContext(ctx))
+}
+
+func TestWithRecorder(t *testing.T) {
+	rec := NewRecorder()
+	ctx := NewServerContext(context.Background(), WithRecorder(rec), WithRequestId("ut-request-id"))
+
+	rkgrpcctx.GetLogger(ctx).Info("ut-message")
+	logs := rec.Logs().FilterMessage("ut-message").All()
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "ut-request-id", logs[0].ContextMap()["requestId"])
+
+	event := rkgrpcctx.GetEvent(ctx)
+	event.SetOperation("ut-operation")
+	rec.EventEntry.Finish(event)
+	assert.Len(t, rec.EventsByOperation("ut-operation"), 1)
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+// Package rkgrpctest provides utilities for testing services built with rk-grpc.
+//
+// Harness boots GrpcEntry on an in-memory listener, Recorder captures logs and rk events,
+// and NewServerContext builds server context which carries values injected by rk middleware.
+package rkgrpctest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-grpc/v2/boot"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"gopkg.in/yaml.v3"
+)
+
+// Size of in-memory listener buffer.
+const bufSize = 1024 * 1024
+
+var (
+	// Sequence of harnesses which makes names of entries unique.
+	harnessSeq uint64
+	// Guards rkentry.GlobalAppCtx while registering entries from YAML.
+	yamlLock sync.Mutex
+)
+
+// Harness boots GrpcEntry on in-memory listener and provides a ready client connection.
+//
+// Entry is interrupted and removed from rkentry.GlobalAppCtx once test finished.
+type Harness struct {
+	*Recorder
+	Entry    *rkgrpc.GrpcEntry
+	Listener *bufconn.Listener
+	Conn     *grpc.ClientConn
+	t        testing.TB
+}
+
+// NewHarness Create and bootstrap GrpcEntry with options on in-memory listener.
+//
+// Logs and events of entry are captured by rec, a new Recorder would be created if rec is nil.
+// Name of entry is generated unless provided by WithName in opts.
+func NewHarness(t testing.TB, rec *Recorder, opts ...rkgrpc.GrpcEntryOption) *Harness {
+	t.Helper()
+
+	if rec == nil {
+		rec = NewRecorder()
+	}
+
+	lis := bufconn.Listen(bufSize)
+
+	opts = append([]rkgrpc.GrpcEntryOption{
+		rkgrpc.WithName(fmt.Sprintf("rkgrpctest-%d", atomic.AddUint64(&harnessSeq, 1))),
+		rkgrpc.WithLoggerEntry(rec.LoggerEntry),
+		rkgrpc.WithEventEntry(rec.EventEntry),
+	}, opts...)
+	opts = append(opts, rkgrpc.WithListener(lis))
+
+	return start(t, rec, lis, rkgrpc.RegisterGrpcEntry(opts...))
+}
+
+// NewHarnessFromYAML Create and bootstrap GrpcEntry from YAML on in-memory listener.
+//
+// YAML must contain exactly one enabled grpc entry, port and listen configs are ignored.
+// loggerEntry and eventEntry of grpc entry are replaced with ones of rec, so that logs and events emitted
+// by middleware are captured. A new Recorder would be created if rec is nil.
+func NewHarnessFromYAML(t testing.TB, rec *Recorder, raw []byte) *Harness {
+	t.Helper()
+
+	if rec == nil {
+		rec = NewRecorder()
+	}
+
+	raw, err := withRecorderEntries(raw, rec)
+	if err != nil {
+		t.Fatalf("failed to parse YAML, %v", err)
+	}
+
+	lis := bufconn.Listen(bufSize)
+
+	// recorder entries are referenced by name in YAML, add them to app context temporarily
+	yamlLock.Lock()
+	rkentry.GlobalAppCtx.AddEntry(rec.LoggerEntry)
+	rkentry.GlobalAppCtx.AddEntry(rec.EventEntry)
+	entries, err := rkgrpc.NewGrpcEntriesFromYAML(raw)
+	rkentry.GlobalAppCtx.RemoveEntry(rec.LoggerEntry)
+	rkentry.GlobalAppCtx.RemoveEntry(rec.EventEntry)
+	yamlLock.Unlock()
+
+	if err != nil {
+		t.Fatalf("failed to register grpc entry from YAML, %v", err)
+	}
+
+	if len(entries) != 1 {
+		for _, e := range entries {
+			rkentry.GlobalAppCtx.RemoveEntry(e)
+		}
+		t.Fatalf("expect exactly one enabled grpc entry in YAML, got %d", len(entries))
+	}
+
+	var entry *rkgrpc.GrpcEntry
+	for _, e := range entries {
+		entry = e
+	}
+	rkgrpc.WithListener(lis)(entry)
+
+	return start(t, rec, lis, entry)
+}
+
+// Gateway returns http.Handler of grpc-gateway, including common service, swagger and other http handlers.
+func (h *Harness) Gateway() http.Handler {
+	return h.Entry.HttpServer.Handler
+}
+
+// Dial Create a new client connection to entry, connection is closed once test finished.
+func (h *Harness) Dial(opts ...grpc.DialOption) *grpc.ClientConn {
+	h.t.Helper()
+
+	conn, err := grpc.Dial("bufnet", append(h.dialOptions(), opts...)...)
+	if err != nil {
+		h.t.Fatalf("failed to dial to in-memory listener, %v", err)
+	}
+	h.t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn
+}
+
+// Options used to dial to in-memory listener, server certificate is not verified if TLS is enabled.
+func (h *Harness) dialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{grpc.WithContextDialer(h.dialer)}
+
+	if h.Entry.IsTlsEnabled() {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: true,
+		})))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	return opts
+}
+
+func (h *Harness) dialer(ctx context.Context, _ string) (net.Conn, error) {
+	return h.Listener.DialContext(ctx)
+}
+
+// Bootstrap entry and dial to it.
+func start(t testing.TB, rec *Recorder, lis *bufconn.Listener, entry *rkgrpc.GrpcEntry) *Harness {
+	t.Helper()
+
+	h := &Harness{
+		Recorder: rec,
+		Entry:    entry,
+		Listener: lis,
+		t:        t,
+	}
+
+	// let grpc-gateway dial to in-memory listener
+	entry.AddGwDialOptions(grpc.WithContextDialer(h.dialer))
+
+	if err := entry.BootstrapE(context.Background()); err != nil {
+		rkentry.GlobalAppCtx.RemoveEntry(entry)
+		t.Fatalf("failed to bootstrap grpc entry, %v", err)
+	}
+	t.Cleanup(func() {
+		entry.Interrupt(context.Background())
+		rkentry.GlobalAppCtx.RemoveEntry(entry)
+	})
+
+	h.Conn = h.Dial()
+
+	return h
+}
+
+// Point loggerEntry and eventEntry of grpc entries in YAML to entries of Recorder.
+func withRecorderEntries(raw []byte, rec *Recorder) ([]byte, error) {
+	config := make(map[string]interface{})
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		return nil, err
+	}
+
+	for k, v := range config {
+		if !strings.EqualFold(k, "grpc") {
+			continue
+		}
+
+		elements, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+
+		for i := range elements {
+			if element, ok := elements[i].(map[string]interface{}); ok {
+				for key := range element {
+					if strings.EqualFold(key, "loggerEntry") || strings.EqualFold(key, "eventEntry") {
+						delete(element, key)
+					}
+				}
+				element["loggerEntry"] = rec.LoggerEntry.GetName()
+				element["eventEntry"] = rec.EventEntry.GetName()
+			}
+		}
+	}
+
+	return yaml.Marshal(config)
+}
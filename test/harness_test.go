@@ -0,0 +1,199 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpctest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gwruntime "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-grpc/v2/boot"
+	testdata "github.com/rookie-ninja/rk-grpc/v2/example/middleware/proto/testdata"
+	"github.com/rookie-ninja/rk-grpc/v2/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestNewHarness(t *testing.T) {
+	h := NewHarness(t, nil,
+		rkgrpc.WithGrpcRegF(func(server *grpc.Server) {
+			testdata.RegisterGreeterServer(server, &GreeterServer{})
+		}),
+		rkgrpc.WithGwRegF(registerGreeterGw),
+		rkgrpc.WithCommonServiceEntry(rkentry.RegisterCommonServiceEntry(&rkentry.BootCommonService{Enabled: true})))
+
+	assert.Equal(t, "bufconn", h.Entry.GrpcAddr())
+
+	// grpc
+	resp, err := testdata.NewGreeterClient(h.Conn).SayHello(context.Background(), &testdata.HelloRequest{Name: "rk"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello rk!", resp.Message)
+
+	health, err := healthpb.NewHealthClient(h.Conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.Status)
+
+	// gateway delegates to grpc server through in-memory listener
+	w := httptest.NewRecorder()
+	h.Gateway().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/hello?name=rk", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Hello rk!", w.Body.String())
+
+	w = httptest.NewRecorder()
+	h.Gateway().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rk/v1/ready", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// bootstrap event and logs are captured
+	assert.Len(t, h.EventsByOperation("Bootstrap"), 1)
+	assert.NotZero(t, h.Logs().FilterMessage("gRPC_port:0").Len())
+}
+
+func TestNewHarnessFromYAML(t *testing.T) {
+	configFile := `
+---
+grpc:
+  - name: ut-harness-yaml
+    port: 1970
+    enabled: true
+    loggerEntry: not-exist
+    commonService:
+      enabled: true
+    middleware:
+      logging:
+        enabled: true
+      meta:
+        enabled: true
+`
+	h := NewHarnessFromYAML(t, nil, []byte(configFile))
+
+	assert.Equal(t, "ut-harness-yaml", h.Entry.GetName())
+	assert.Equal(t, h.LoggerEntry, h.Entry.LoggerEntry)
+	assert.Equal(t, h.EventEntry, h.Entry.EventEntry)
+	assert.NotNil(t, rkgrpc.GetGrpcEntry("ut-harness-yaml"))
+
+	// recorder entries are not left in app context
+	assert.Nil(t, rkentry.GlobalAppCtx.GetLoggerEntry(h.LoggerEntry.GetName()))
+	assert.Nil(t, rkentry.GlobalAppCtx.GetEventEntry(h.EventEntry.GetName()))
+
+	h.Reset()
+	_, err := healthpb.NewHealthClient(h.Conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Nil(t, err)
+
+	// event emitted by logging middleware is captured
+	events := h.EventsByOperation("/grpc.health.v1.Health/Check")
+	assert.Len(t, events, 1)
+	assert.Equal(t, "OK", events[0].ResCode)
+	assert.Equal(t, "Ended", events[0].EventStatus)
+	assert.NotEmpty(t, events[0].Payloads)
+}
+
+func TestNewHarnessFromYAML_WithMultipleEntries(t *testing.T) {
+	configFile := `
+---
+grpc:
+  - name: ut-harness-yaml-1
+    enabled: true
+  - name: ut-harness-yaml-2
+    enabled: true
+`
+	ft := &fakeT{TB: t}
+	func() {
+		defer func() {
+			recover()
+		}()
+		NewHarnessFromYAML(ft, nil, []byte(configFile))
+	}()
+
+	assert.True(t, ft.failed)
+	assert.Nil(t, rkgrpc.GetGrpcEntry("ut-harness-yaml-1"))
+	assert.Nil(t, rkgrpc.GetGrpcEntry("ut-harness-yaml-2"))
+}
+
+func TestNewHarnessFromYAML_WithInvalidConfig(t *testing.T) {
+	configFile := `
+---
+grpc:
+  - name: ut-harness-yaml-invalid
+    enabled: true
+    middleware:
+      errorModel: not-exist
+`
+	ft := &fakeT{TB: t}
+	func() {
+		defer func() {
+			recover()
+		}()
+		NewHarnessFromYAML(ft, nil, []byte(configFile))
+	}()
+
+	assert.True(t, ft.failed)
+	assert.Nil(t, rkgrpc.GetGrpcEntry("ut-harness-yaml-invalid"))
+}
+
+func TestHarness_Interrupt(t *testing.T) {
+	rec := NewRecorder()
+	var entry *rkgrpc.GrpcEntry
+	t.Run("harness", func(t *testing.T) {
+		entry = NewHarness(t, rec).Entry
+		assert.NotNil(t, rkgrpc.GetGrpcEntry(entry.GetName()))
+		assert.Empty(t, rec.EventsByOperation("Interrupt"))
+	})
+
+	// entry is interrupted and removed once test finished
+	assert.Nil(t, rkgrpc.GetGrpcEntry(entry.GetName()))
+	assert.Len(t, rec.EventsByOperation("Interrupt"), 1)
+}
+
+// ************ Test utility ************
+
+// GreeterServer greets with name in request.
+type GreeterServer struct{}
+
+// SayHello Handle SayHello method.
+func (server *GreeterServer) SayHello(ctx context.Context, request *testdata.HelloRequest) (*testdata.HelloResponse, error) {
+	rkgrpcctx.GetLogger(ctx).Info("Received request")
+
+	return &testdata.HelloResponse{
+		Message: "Hello " + request.Name + "!",
+	}, nil
+}
+
+// Register GET /v1/hello into gateway, which calls SayHello through endpoint.
+func registerGreeterGw(ctx context.Context, mux *gwruntime.ServeMux, endpoint string, opts []grpc.DialOption) error {
+	conn, err := grpc.DialContext(ctx, endpoint, opts...)
+	if err != nil {
+		return err
+	}
+
+	return mux.HandlePath(http.MethodGet, "/v1/hello", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		resp, err := testdata.NewGreeterClient(conn).SayHello(r.Context(), &testdata.HelloRequest{
+			Name: r.URL.Query().Get("name"),
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(err.Error())
+			return
+		}
+		w.Write([]byte(resp.Message))
+	})
+}
+
+// fakeT records failure instead of failing test.
+type fakeT struct {
+	testing.TB
+	failed bool
+}
+
+// Fatalf records failure and stops caller.
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.failed = true
+	panic("fatal")
+}
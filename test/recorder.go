@@ -0,0 +1,172 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/rookie-ninja/rk-query"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Event is a rk event captured by Recorder.
+type Event struct {
+	Operation   string
+	ResCode     string
+	EventStatus string
+	RemoteAddr  string
+	Ids         map[string]interface{}
+	App         map[string]interface{}
+	Payloads    map[string]interface{}
+	Errors      map[string]interface{}
+	Counters    map[string]interface{}
+	Pairs       map[string]interface{}
+	Timing      map[string]interface{}
+	// Raw is message of event log, empty if event was encoded as JSON
+	Raw string
+}
+
+// Recorder captures logs and rk events emitted through its LoggerEntry and EventEntry.
+type Recorder struct {
+	LoggerEntry *rkentry.LoggerEntry
+	EventEntry  *rkentry.EventEntry
+	logs        *observer.ObservedLogs
+	events      *observer.ObservedLogs
+}
+
+// Sequence of recorders which makes names of logger and event entries unique.
+var recorderSeq uint64
+
+// NewRecorder Create Recorder which captures logs of all levels.
+//
+// Logger and event entries are named uniquely but not added to rkentry.GlobalAppCtx.
+func NewRecorder() *Recorder {
+	loggerCore, logs := observer.New(zapcore.DebugLevel)
+	eventCore, events := observer.New(zapcore.DebugLevel)
+
+	name := fmt.Sprintf("rkgrpctest-recorder-%d", atomic.AddUint64(&recorderSeq, 1))
+
+	loggerEntry := rkentry.RegisterLoggerEntry(&rkentry.BootLogger{
+		Logger: []*rkentry.BootLoggerE{{Name: name}},
+	})[0]
+	rkentry.GlobalAppCtx.RemoveEntry(loggerEntry)
+	loggerEntry.Logger = zap.New(loggerCore)
+
+	eventEntry := rkentry.RegisterEventEntry(&rkentry.BootEvent{
+		Event: []*rkentry.BootEventE{{Name: name}},
+	})[0]
+	rkentry.GlobalAppCtx.RemoveEntry(eventEntry)
+	eventEntry.EventFactory = rkquery.NewEventFactory(
+		rkquery.WithZapLogger(zap.New(eventCore)),
+		rkquery.WithEncoding(rkquery.JSON))
+	eventEntry.EventHelper = rkquery.NewEventHelper(eventEntry.EventFactory)
+
+	return &Recorder{
+		LoggerEntry: loggerEntry,
+		EventEntry:  eventEntry,
+		logs:        logs,
+		events:      events,
+	}
+}
+
+// Logs returns captured logs.
+func (rec *Recorder) Logs() *observer.ObservedLogs {
+	return rec.logs
+}
+
+// Events returns captured rk events in order.
+func (rec *Recorder) Events() []*Event {
+	res := make([]*Event, 0)
+	for _, log := range rec.events.All() {
+		res = append(res, toEvent(log))
+	}
+
+	return res
+}
+
+// EventsByOperation returns captured rk events with operation, grpc full method is operation of RPC events.
+func (rec *Recorder) EventsByOperation(operation string) []*Event {
+	res := make([]*Event, 0)
+	for _, event := range rec.Events() {
+		if event.Operation == operation {
+			res = append(res, event)
+		}
+	}
+
+	return res
+}
+
+// Reset clears captured logs and events.
+func (rec *Recorder) Reset() {
+	rec.logs.TakeAll()
+	rec.events.TakeAll()
+}
+
+// Convert logged entry to Event, both of JSON and CONSOLE encoding are supported.
+func toEvent(log observer.LoggedEntry) *Event {
+	fields := log.ContextMap()
+
+	// CONSOLE encoding, event is marshalled into message with key=value lines
+	if len(fields) < 1 && len(log.Message) > 0 {
+		for _, line := range strings.Split(log.Message, "\n") {
+			tokens := strings.SplitN(line, "=", 2)
+			if len(tokens) != 2 {
+				continue
+			}
+
+			var value interface{} = tokens[1]
+			if strings.HasPrefix(tokens[1], "{") {
+				m := make(map[string]interface{})
+				if err := json.Unmarshal([]byte(tokens[1]), &m); err == nil {
+					value = m
+				}
+			}
+			fields[tokens[0]] = value
+		}
+	}
+
+	res := &Event{
+		Operation:   toString(fields["operation"]),
+		ResCode:     toString(fields["resCode"]),
+		EventStatus: toString(fields["eventStatus"]),
+		RemoteAddr:  toString(fields["remoteAddr"]),
+		Ids:         toMap(fields["ids"]),
+		App:         toMap(fields["app"]),
+		Payloads:    toMap(fields["payloads"]),
+		Errors:      toMap(fields["error"]),
+		Counters:    toMap(fields["counters"]),
+		Pairs:       toMap(fields["pairs"]),
+		Timing:      toMap(fields["timing"]),
+	}
+
+	if len(log.Context) < 1 {
+		res.Raw = log.Message
+	}
+
+	return res
+}
+
+func toString(v interface{}) string {
+	if res, ok := v.(string); ok {
+		return res
+	}
+
+	return ""
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	if res, ok := v.(map[string]interface{}); ok {
+		return res
+	}
+
+	return make(map[string]interface{})
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkgrpctest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rookie-ninja/rk-query"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewRecorder(t *testing.T) {
+	rec := NewRecorder()
+	other := NewRecorder()
+
+	assert.NotEqual(t, rec.LoggerEntry.GetName(), other.LoggerEntry.GetName())
+	assert.NotEqual(t, rec.EventEntry.GetName(), other.EventEntry.GetName())
+
+	rec.LoggerEntry.Info("ut-message")
+	assert.Equal(t, 1, rec.Logs().FilterMessage("ut-message").Len())
+	assert.Zero(t, other.Logs().Len())
+
+	rec.Reset()
+	assert.Zero(t, rec.Logs().Len())
+}
+
+func TestRecorder_Events(t *testing.T) {
+	rec := NewRecorder()
+
+	event := rec.EventEntry.CreateEvent(rkquery.WithOperation("ut-operation"))
+	event.SetStartTime(time.Now())
+	event.SetResCode("OK")
+	event.AddPayloads(zap.String("key", "value"))
+	event.SetCounter("ut-counter", 1)
+	event.AddErr(errors.New("ut-error"))
+	rec.EventEntry.Finish(event)
+
+	events := rec.Events()
+	assert.Len(t, events, 1)
+	assert.Equal(t, "ut-operation", events[0].Operation)
+	assert.Equal(t, "OK", events[0].ResCode)
+	assert.Equal(t, "Ended", events[0].EventStatus)
+	assert.Equal(t, "value", events[0].Payloads["key"])
+	assert.NotEmpty(t, events[0].Counters)
+	assert.NotEmpty(t, events[0].Errors)
+	assert.Empty(t, events[0].Raw)
+
+	assert.Len(t, rec.EventsByOperation("ut-operation"), 1)
+	assert.Empty(t, rec.EventsByOperation("not-exist"))
+
+	rec.Reset()
+	assert.Empty(t, rec.Events())
+}
+
+func TestToEvent_WithConsoleEncoding(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	factory := rkquery.NewEventFactory(
+		rkquery.WithZapLogger(zap.New(core)),
+		rkquery.WithEncoding(rkquery.CONSOLE))
+
+	event := factory.CreateEvent(rkquery.WithOperation("ut-operation"))
+	event.SetStartTime(time.Now())
+	event.SetResCode("OK")
+	event.AddPayloads(zap.String("key", "value"))
+	event.Finish()
+
+	assert.Equal(t, 1, logs.Len())
+	res := toEvent(logs.All()[0])
+	assert.Equal(t, "ut-operation", res.Operation)
+	assert.Equal(t, "OK", res.ResCode)
+	assert.Equal(t, "Ended", res.EventStatus)
+	assert.Equal(t, "value", res.Payloads["key"])
+	assert.NotEmpty(t, res.Raw)
+}